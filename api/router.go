@@ -5,6 +5,7 @@ import (
 	"blog/controllers"
 	"blog/utils"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
 )
 
@@ -14,10 +15,21 @@ func SetupRouter() *gin.Engine {
 	r.Use(Cors())
 	api := r.Group("/api")
 
+	// 回收站：定时彻底删除超过保留期的数据
+	controllers.NewTrashPurger(config.DB).Start()
+	trashController := controllers.NewTrashController(config.DB)
+
+	// 搜索引擎相关路由（挂在根路径，无需鉴权）
+	sitemapController := controllers.NewSitemapController(config.DB)
+	r.GET("/robots.txt", sitemapController.RobotsTxt)
+	r.GET("/sitemap.xml", sitemapController.Sitemap)
+	r.GET("/sitemap/:page", sitemapController.SitemapPage)
+
 	// 用户相关路由
 	userRoutes := api.Group("/user")
 	{
 		userController := controllers.NewUserController(config.DB)
+		followController := controllers.NewFollowController(config.DB)
 
 		// 用户自身接口
 		userRoutes.POST("/register", userController.RegisterUser)
@@ -26,21 +38,44 @@ func SetupRouter() *gin.Engine {
 		userRoutes.PUT("/profile", utils.AuthMiddleware(utils.RoleUser), userController.UpdateUserProfile)
 		userRoutes.GET("/all", utils.AuthMiddleware(utils.RoleMarketer), userController.GetAllUsers)
 
+		// 关注作者
+		userRoutes.POST("/:id/follow", utils.AuthMiddleware(utils.RoleUser), followController.FollowUser)
+		userRoutes.DELETE("/:id/follow", utils.AuthMiddleware(utils.RoleUser), followController.UnfollowUser)
+		userRoutes.GET("/following", utils.AuthMiddleware(utils.RoleUser), followController.ListFollowing)
+		userRoutes.GET("/followers", utils.AuthMiddleware(utils.RoleUser), followController.ListFollowers)
+
 		// 管理员接口
 		userRoutes.POST("/admin/create", utils.AuthMiddleware(utils.RoleAdmin), userController.CreateUserByAdmin)
 		userRoutes.PUT("/admin/:id", utils.AuthMiddleware(utils.RoleAdmin), userController.UpdateUserByAdmin)
 		userRoutes.DELETE("/:id", utils.AuthMiddleware(utils.RoleAdmin), userController.DeleteUser)
 		userRoutes.GET("/admin/all", utils.AuthMiddleware(utils.RoleAdmin), userController.GetAdminAllUsers)
+		// 回收站中的用户（删除用户时其博客、评论、收益记录一并进入回收站）
+		userRoutes.GET("/admin/trash", utils.AuthMiddleware(utils.RoleAdmin), trashController.ListTrashedUsers)
+		userRoutes.POST("/admin/:id/restore", utils.AuthMiddleware(utils.RoleAdmin), trashController.RestoreUser)
 	}
 
 	// 博客相关路由
 	blogRoutes := api.Group("/blog")
 	{
-		blogController := controllers.NewBlogController(config.DB)
+		// 浏览量先写入内存缓冲，由后台协程批量落库
+		viewTracker := controllers.NewViewTracker(config.DB)
+		viewTracker.Start()
+		OnShutdown(func() {
+			if err := viewTracker.Stop(); err != nil {
+				log.Printf("Failed to flush blog views on shutdown: %v", err)
+			}
+		})
+		blogController := controllers.NewBlogController(config.DB, viewTracker)
+		blogAnalyticsController := controllers.NewBlogAnalyticsController(config.DB)
+		engagementController := controllers.NewEngagementController(config.DB)
+		collaboratorController := controllers.NewCollaboratorController(config.DB)
+		reviewController := controllers.NewReviewController(config.DB)
+		blogTransferController := controllers.NewBlogTransferController(config.DB)
+		wordPressController := controllers.NewWordPressController(config.DB)
 
-		// ✅ 创建、更新、删除仅限于作者或管理员
+		// ✅ 创建、更新、删除仅限于作者（协作者）或管理员
 		blogRoutes.POST("/", utils.AuthMiddleware(utils.RoleMarketer), blogController.CreateBlog)
-		blogRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleMarketer), blogController.UpdateBlog)
+		blogRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleUser), blogController.UpdateBlog)
 		blogRoutes.DELETE("/:id", utils.AuthMiddleware(utils.RoleMarketer), blogController.DeleteBlog)
 		// 获取所有用户的博客
 		blogRoutes.GET("/paginated", utils.AuthMiddleware(utils.RoleUser), blogController.GetBlogsPaginated)
@@ -53,13 +88,73 @@ func SetupRouter() *gin.Engine {
 		// 获取当前用户的所有博客的目录
 		blogRoutes.GET("/directory", utils.AuthMiddleware(utils.RoleMarketer), blogController.GetBlogDirectory)
 		blogRoutes.GET("/my", utils.AuthMiddleware(utils.RoleMarketer), blogController.GetMyBlogInfo)
+		// 关注作者的文章流
+		blogRoutes.GET("/feed", utils.AuthMiddleware(utils.RoleUser), blogController.GetFeed)
+
+		// 批量导出（管理员导出全部，其他人导出自己的）与导入（仅管理员）
+		blogRoutes.GET("/export", utils.AuthMiddleware(utils.RoleMarketer), blogTransferController.ExportBlogs)
+		blogRoutes.POST("/import", utils.AuthMiddleware(utils.RoleAdmin), blogTransferController.ImportBlogs)
+		// WordPress WXR 导入（仅管理员，也可以用命令行 import-wxr 导入）
+		blogRoutes.POST("/import/wordpress", utils.AuthMiddleware(utils.RoleAdmin), wordPressController.ImportWXR)
+
+		// 阅读数据（仅作者或管理员）
+		blogRoutes.GET("/analytics/top", utils.AuthMiddleware(utils.RoleMarketer), blogAnalyticsController.GetTopBlogs)
+		blogRoutes.GET("/analytics/:id/daily", utils.AuthMiddleware(utils.RoleMarketer), blogAnalyticsController.GetDailyViews)
+		blogRoutes.GET("/analytics/:id/referrers", utils.AuthMiddleware(utils.RoleMarketer), blogAnalyticsController.GetReferrers)
+
+		// 点赞与收藏
+		blogRoutes.POST("/:id/like", utils.AuthMiddleware(utils.RoleUser), engagementController.LikeBlog)
+		blogRoutes.DELETE("/:id/like", utils.AuthMiddleware(utils.RoleUser), engagementController.UnlikeBlog)
+		blogRoutes.POST("/:id/bookmark", utils.AuthMiddleware(utils.RoleUser), engagementController.BookmarkBlog)
+		blogRoutes.DELETE("/:id/bookmark", utils.AuthMiddleware(utils.RoleUser), engagementController.UnbookmarkBlog)
+		blogRoutes.GET("/bookmarks", utils.AuthMiddleware(utils.RoleUser), engagementController.ListMyBookmarks)
+
+		// 协作者与修改记录（所有者或管理员管理，协作者可查看）
+		blogRoutes.GET("/:id/collaborators", utils.AuthMiddleware(utils.RoleUser), collaboratorController.ListCollaborators)
+		blogRoutes.PUT("/:id/collaborators/:userId", utils.AuthMiddleware(utils.RoleUser), collaboratorController.SetCollaborator)
+		blogRoutes.DELETE("/:id/collaborators/:userId", utils.AuthMiddleware(utils.RoleUser), collaboratorController.RemoveCollaborator)
+		blogRoutes.GET("/:id/audit", utils.AuthMiddleware(utils.RoleUser), collaboratorController.ListBlogAudit)
+
+		// 编辑审核流程（管理员/财务审核）
+		blogRoutes.POST("/:id/submit", utils.AuthMiddleware(utils.RoleMarketer), reviewController.SubmitForReview)
+		blogRoutes.POST("/:id/review/approve", utils.AuthMiddleware(utils.RoleFinance), reviewController.ApproveBlog)
+		blogRoutes.POST("/:id/review/request-changes", utils.AuthMiddleware(utils.RoleFinance), reviewController.RequestChanges)
+		blogRoutes.GET("/:id/reviews", utils.AuthMiddleware(utils.RoleUser), reviewController.ListReviews)
+		blogRoutes.GET("/reviews/pending", utils.AuthMiddleware(utils.RoleFinance), reviewController.ListPendingReviews)
+
+		// 回收站（所有者或管理员）
+		blogRoutes.GET("/trash", utils.AuthMiddleware(utils.RoleUser), trashController.ListTrashedBlogs)
+		blogRoutes.POST("/:id/restore", utils.AuthMiddleware(utils.RoleUser), trashController.RestoreBlog)
 
 	}
 
+	// 系列文章相关路由
+	seriesRoutes := api.Group("/series")
+	{
+		seriesController := controllers.NewSeriesController(config.DB)
+		seriesRoutes.POST("/", utils.AuthMiddleware(utils.RoleMarketer), seriesController.CreateSeries)
+		seriesRoutes.GET("/my", utils.AuthMiddleware(utils.RoleMarketer), seriesController.ListMySeries)
+		seriesRoutes.GET("/:id", utils.AuthMiddleware(utils.RoleUser), seriesController.GetSeries)
+		seriesRoutes.PUT("/:id/posts", utils.AuthMiddleware(utils.RoleMarketer), seriesController.UpdateSeriesPosts)
+	}
+
+	// 博客模板相关路由（管理员维护模板，营销人员用模板生成草稿）
+	blogTemplateRoutes := api.Group("/blog-template")
+	{
+		blogTemplateController := controllers.NewBlogTemplateController(config.DB)
+		blogTemplateRoutes.POST("/", utils.AuthMiddleware(utils.RoleAdmin), blogTemplateController.CreateTemplate)
+		blogTemplateRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleAdmin), blogTemplateController.UpdateTemplate)
+		blogTemplateRoutes.DELETE("/:id", utils.AuthMiddleware(utils.RoleAdmin), blogTemplateController.DeleteTemplate)
+		blogTemplateRoutes.GET("/", utils.AuthMiddleware(utils.RoleMarketer), blogTemplateController.ListTemplates)
+		blogTemplateRoutes.GET("/:id", utils.AuthMiddleware(utils.RoleMarketer), blogTemplateController.GetTemplate)
+		blogTemplateRoutes.POST("/:id/draft", utils.AuthMiddleware(utils.RoleMarketer), blogTemplateController.CreateDraftFromTemplate)
+	}
+
 	// 留言/评论相关路由
 	commentRoutes := api.Group("/comment")
 	{
 		commentController := controllers.NewCommentController(config.DB)
+		commentEngagementController := controllers.NewEngagementController(config.DB)
 		// 创建留言需要普通用户权限（角色值<=4）
 		commentRoutes.POST("/", utils.AuthMiddleware(utils.RoleUser), commentController.CreateComment)
 		commentRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleUser), commentController.UpdateComment)
@@ -67,6 +162,12 @@ func SetupRouter() *gin.Engine {
 		commentRoutes.GET("/:id", commentController.GetComment)
 		commentRoutes.GET("/", commentController.ListComments)
 		commentRoutes.GET("/blog/:blog_id", commentController.ListCommentsByBlog)
+		// 回收站（评论人、博客作者或管理员）
+		commentRoutes.GET("/trash", utils.AuthMiddleware(utils.RoleUser), trashController.ListTrashedComments)
+		commentRoutes.POST("/:id/restore", utils.AuthMiddleware(utils.RoleUser), trashController.RestoreComment)
+		// 评论表情回应
+		commentRoutes.POST("/:id/reactions", utils.AuthMiddleware(utils.RoleUser), commentEngagementController.AddCommentReaction)
+		commentRoutes.DELETE("/:id/reactions", utils.AuthMiddleware(utils.RoleUser), commentEngagementController.RemoveCommentReaction)
 	}
 
 	// 通知相关路由
@@ -92,6 +193,39 @@ func SetupRouter() *gin.Engine {
 		employeeRevenueRoutes.GET("/:id", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.GetEmployeeRevenue)
 		employeeRevenueRoutes.GET("/", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.ListEmployeeRevenue)
 		employeeRevenueRoutes.GET("/user/revenue", utils.AuthMiddleware(utils.RoleUser), employeeRevenueController.GetUserEmployeeRevenueList)
+		employeeRevenueRoutes.GET("/timeseries", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.GetRevenueTimeseries)
+		employeeRevenueRoutes.GET("/report", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.GetRevenueReport)
+		employeeRevenueRoutes.GET("/leaderboard", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.GetRevenueLeaderboard)
+		employeeRevenueRoutes.GET("/export", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.ExportEmployeeRevenue)
+		employeeRevenueRoutes.GET("/user/revenue/export", utils.AuthMiddleware(utils.RoleUser), employeeRevenueController.ExportUserEmployeeRevenue)
+		employeeRevenueRoutes.GET("/report/export", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.ExportRevenueReport)
+		employeeRevenueRoutes.POST("/import", utils.AuthMiddleware(utils.RoleMarketer), employeeRevenueController.ImportEmployeeRevenue)
+		// 按 员工 + 日期 + 平台 + 广告类型 + 地区 新建或覆盖
+		employeeRevenueRoutes.PUT("/", utils.AuthMiddleware(utils.RoleUser), employeeRevenueController.UpsertEmployeeRevenue)
+		employeeRevenueRoutes.GET("/duplicates", utils.AuthMiddleware(utils.RoleAdmin), employeeRevenueController.ListRevenueDuplicates)
+		employeeRevenueRoutes.POST("/duplicates/merge", utils.AuthMiddleware(utils.RoleAdmin), employeeRevenueController.MergeRevenueDuplicates)
+		employeeRevenueRoutes.POST("/duplicates/delete", utils.AuthMiddleware(utils.RoleAdmin), employeeRevenueController.DeleteRevenueDuplicates)
+	}
+
+	// 汇率表相关路由：报表、导出等换算币种时使用，只有管理员可以维护
+	exchangeRateRoutes := api.Group("/exchange-rate")
+	{
+		exchangeRateController := controllers.NewExchangeRateController(config.DB)
+		exchangeRateRoutes.GET("/", utils.AuthMiddleware(utils.RoleMarketer), exchangeRateController.ListExchangeRates)
+		exchangeRateRoutes.POST("/", utils.AuthMiddleware(utils.RoleAdmin), exchangeRateController.CreateExchangeRate)
+		exchangeRateRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleAdmin), exchangeRateController.UpdateExchangeRate)
+		exchangeRateRoutes.DELETE("/:id", utils.AuthMiddleware(utils.RoleAdmin), exchangeRateController.DeleteExchangeRate)
+	}
+
+	// 员工月度目标相关路由：管理员维护目标，投手及以上可以查看完成情况
+	targetRoutes := api.Group("/target")
+	{
+		targetController := controllers.NewTargetController(config.DB)
+		targetRoutes.GET("/", utils.AuthMiddleware(utils.RoleAdmin), targetController.ListTargets)
+		targetRoutes.POST("/", utils.AuthMiddleware(utils.RoleAdmin), targetController.CreateTarget)
+		targetRoutes.PUT("/:id", utils.AuthMiddleware(utils.RoleAdmin), targetController.UpdateTarget)
+		targetRoutes.DELETE("/:id", utils.AuthMiddleware(utils.RoleAdmin), targetController.DeleteTarget)
+		targetRoutes.GET("/attainment", utils.AuthMiddleware(utils.RoleMarketer), targetController.GetTargetAttainment)
 	}
 
 	// 充值流水相关路由
@@ -0,0 +1,26 @@
+package api
+
+import "sync"
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func()
+)
+
+// OnShutdown 注册进程退出前要执行的清理（如把缓冲中的浏览量写库）
+func OnShutdown(hook func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// Shutdown 在 HTTP 服务停止接收请求后调用，按注册的相反顺序执行清理
+func Shutdown() {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
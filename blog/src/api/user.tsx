@@ -69,10 +69,22 @@ export const getAdminAllUsers = async (): Promise<UserProfile[]> => {
     return response.data.data; // 从包装对象中取出真正的用户数组
 };
 
-// 删除用户（管理员权限）
-export const deleteUser = async (id: number): Promise<boolean> => {
+// 删除用户时如何处理其内容：转给 targetId 指定的用户，或匿名化
+export type DeleteUserOption =
+    | { mode: 'reassign'; targetId: number }
+    | { mode: 'anonymize' };
+
+// 删除用户（管理员权限）：必须明确指定转移或匿名化，不提供默认方式
+export const deleteUser = async (id: number, option: DeleteUserOption): Promise<boolean> => {
+    if (option.mode === 'reassign' && !(Number.isInteger(option.targetId) && option.targetId > 0)) {
+        console.error("删除用户失败: 无效的目标用户ID", option.targetId);
+        return false;
+    }
     try {
-        await api.delete(`/user/${id}`);
+        const params = option.mode === 'reassign'
+            ? { mode: 'reassign', target_id: option.targetId }
+            : { mode: 'anonymize' };
+        await api.delete(`/user/${id}`, { params });
         return true;
     } catch (error) {
         console.error("删除用户失败:", error);
@@ -1,6 +1,7 @@
 import React, { useEffect, useState, ChangeEvent } from "react";
 import {
     deleteUser,
+    DeleteUserOption,
     createUserByAdmin,
     updateUserByAdmin,
     UserProfile, getAdminAllUsers,
@@ -80,8 +81,31 @@ const UserManagement: React.FC = () => {
     // 删除用户
     const handleDelete = async (id: number) => {
         if (window.confirm("确定删除该用户吗？")) {
+            // 博客与收益记录转给指定用户，留空则在再次确认后匿名化
+            const input = window.prompt("将该用户的博客与收益记录转给哪个用户ID？留空则匿名化", "");
+            if (input === null) {
+                return;
+            }
+            const target = input.trim();
+            let option: DeleteUserOption;
+            if (target === "") {
+                if (!window.confirm("未指定用户ID，该用户的博客与收益记录将被匿名化，确定继续吗？")) {
+                    return;
+                }
+                option = { mode: "anonymize" };
+            } else {
+                // 只接受正整数，避免 "abc"、"1.5"、"0" 等输入被当作匿名化或无效ID
+                const targetId = /^\d+$/.test(target) ? Number(target) : NaN;
+                if (!Number.isSafeInteger(targetId) || targetId <= 0 || targetId === id) {
+                    setSnackbarMessage("请输入有效的目标用户ID（正整数，且不能是被删除的用户）");
+                    setSnackbarSeverity("error");
+                    setSnackbarOpen(true);
+                    return;
+                }
+                option = { mode: "reassign", targetId };
+            }
             try {
-                const success = await deleteUser(id);
+                const success = await deleteUser(id, option);
                 if (success) {
                     setSnackbarMessage("删除成功");
                     setSnackbarSeverity("success");
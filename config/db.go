@@ -3,9 +3,11 @@ package config
 import (
 	"blog/models"
 	"blog/utils"
+	"fmt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"log"
+	"strings"
 )
 
 var DB *gorm.DB
@@ -26,6 +28,9 @@ func Initialize() {
 		log.Fatalf("Unsupported database type: %s", dbType)
 	}
 
+	// 金额列由浮点数（元）改为整数（分），需要在 AutoMigrate 之前转换已有数据
+	migrateMoneyColumns(DB)
+
 	// 自动迁移数据库模型（开发阶段推荐全部创建）
 	if err := DB.AutoMigrate(
 		&models.Users{},
@@ -34,13 +39,183 @@ func Initialize() {
 		&models.RechargeTransaction{},
 		&models.Comment{},
 		&models.Notification{},
+		&models.BlogViewDaily{},
+		&models.BlogReferrerDaily{},
+		&models.BlogLike{},
+		&models.BlogBookmark{},
+		&models.CommentReaction{},
+		&models.UserFollow{},
+		&models.Series{},
+		&models.SeriesItem{},
+		&models.BlogCollaborator{},
+		&models.BlogAuditLog{},
+		&models.BlogReview{},
+		&models.BlogReviewComment{},
+		&models.BlogTemplate{},
+		&models.ExchangeRate{},
+		&models.Target{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	backfillBlogCommentStats(DB)
+	backfillBlogOwners(DB)
+	backfillBlogPublishedAt(DB)
+	backfillRevenueRecordDates(DB)
+	normalizeRevenueRecordTimes(DB)
+	ensureRevenueNaturalKey(DB)
 	adminInit(DB)
 }
 
+// backfillBlogCommentStats 为新增的评论计数字段补齐历史数据（只处理计数缺失的博客，可重复执行）
+func backfillBlogCommentStats(db *gorm.DB) {
+	if err := db.Model(&models.Blog{}).
+		Where("comment_count = 0 AND EXISTS (SELECT 1 FROM comments WHERE comments.blog_id = blog.id AND comments.deleted_at IS NULL)").
+		UpdateColumns(map[string]interface{}{
+			"comment_count":   gorm.Expr("(SELECT COUNT(*) FROM comments WHERE comments.blog_id = blog.id AND comments.deleted_at IS NULL)"),
+			"last_comment_at": gorm.Expr("(SELECT MAX(comments.created_at) FROM comments WHERE comments.blog_id = blog.id AND comments.deleted_at IS NULL)"),
+		}).Error; err != nil {
+		log.Printf("Failed to backfill blog comment stats: %v", err)
+	}
+}
+
+// backfillBlogOwners 为还没有协作者记录的历史博客补上所有者（作者），可重复执行
+func backfillBlogOwners(db *gorm.DB) {
+	if err := db.Exec(`INSERT INTO blog_collaborators (blog_id, user_id, role, created_at, updated_at)
+		SELECT blog.id, blog.author_id, ?, blog.created_at, blog.created_at FROM blog
+		WHERE NOT EXISTS (SELECT 1 FROM blog_collaborators WHERE blog_collaborators.blog_id = blog.id AND blog_collaborators.user_id = blog.author_id)`,
+		models.CollaboratorOwner).Error; err != nil {
+		log.Printf("Failed to backfill blog owners: %v", err)
+	}
+}
+
+// backfillBlogPublishedAt 为已发布的历史博客补上首次发布时间（取创建时间），避免再次发布时重复通知关注者；可重复执行
+func backfillBlogPublishedAt(db *gorm.DB) {
+	if err := db.Model(&models.Blog{}).Unscoped().
+		Where("status = ? AND published_at IS NULL", models.BlogStatusPublished).
+		UpdateColumn("published_at", gorm.Expr("created_at")).Error; err != nil {
+		log.Printf("Failed to backfill blog published_at: %v", err)
+	}
+}
+
+// migrateMoneyColumns 把仍以浮点数（元）存储的金额列换算为分并改为整数列。
+// 按列类型判断是否已转换，可重复执行；每张表在一个事务中完成，中途失败不会重复换算
+func migrateMoneyColumns(db *gorm.DB) {
+	targets := []struct {
+		model   interface{}
+		table   string
+		columns []string
+	}{
+		{&models.EmployeeRevenue{}, "employee_revenue", []string{"expenditure", "revenue", "product_cost", "platform_fee", "shipping_fee"}},
+		{&models.RechargeTransaction{}, "recharge_transactions", []string{"amount"}},
+	}
+	for _, target := range targets {
+		if !db.Migrator().HasTable(target.table) {
+			continue
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(target.table)
+		if err != nil {
+			log.Printf("Failed to inspect %s columns: %v", target.table, err)
+			continue
+		}
+		floatColumns := make([]string, 0)
+		for _, column := range columnTypes {
+			switch strings.ToLower(column.DatabaseTypeName()) {
+			case "real", "float", "double", "numeric", "decimal":
+				for _, name := range target.columns {
+					if column.Name() == name {
+						floatColumns = append(floatColumns, name)
+					}
+				}
+			}
+		}
+		if len(floatColumns) == 0 {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, name := range floatColumns {
+				if err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ROUND(%s * %d)", target.table, name, name, models.MoneyScale)).Error; err != nil {
+					return err
+				}
+				if err := tx.Migrator().AlterColumn(target.model, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to migrate money columns of %s: %v", target.table, err)
+		}
+		log.Printf("Migrated money columns of %s to minor units: %v", target.table, floatColumns)
+	}
+}
+
+// backfillRevenueRecordDates 为新增的业务日期字段补齐历史数据（按 revenue.timezone 取记录时间的日期），可重复执行
+func backfillRevenueRecordDates(db *gorm.DB) {
+	loc := utils.RevenueLocation()
+	for {
+		var records []models.EmployeeRevenue
+		if err := db.Unscoped().Select("id, record_time").Where("record_date = ''").Limit(500).Find(&records).Error; err != nil {
+			log.Printf("Failed to backfill revenue record dates: %v", err)
+			return
+		}
+		if len(records) == 0 {
+			return
+		}
+		for _, record := range records {
+			if err := db.Unscoped().Model(&models.EmployeeRevenue{}).Where("id = ?", record.ID).
+				UpdateColumn("record_date", record.RecordTime.In(loc).Format("2006-01-02")).Error; err != nil {
+				log.Printf("Failed to backfill revenue record dates: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// normalizeRevenueRecordTimes 把历史记录时间统一转换为 UTC：SQLite 按文本保存时间并保留写入时的时区偏移，
+// 偏移不同的值无法直接比较大小。只处理偏移不为 0 的记录，可重复执行
+func normalizeRevenueRecordTimes(db *gorm.DB) {
+	var lastID uint
+	converted := 0
+	for {
+		var records []models.EmployeeRevenue
+		if err := db.Unscoped().Select("id, record_time").Where("id > ?", lastID).Order("id").Limit(500).Find(&records).Error; err != nil {
+			log.Printf("Failed to normalize revenue record times: %v", err)
+			return
+		}
+		if len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			lastID = record.ID
+			if _, offset := record.RecordTime.Zone(); offset == 0 {
+				continue
+			}
+			if err := db.Unscoped().Model(&models.EmployeeRevenue{}).Where("id = ?", record.ID).
+				UpdateColumn("record_time", record.RecordTime.UTC()).Error; err != nil {
+				log.Printf("Failed to normalize revenue record times: %v", err)
+				return
+			}
+			converted++
+		}
+	}
+	if converted > 0 {
+		log.Printf("Normalized %d revenue record times to UTC", converted)
+	}
+}
+
+// ensureRevenueNaturalKey 创建收益记录唯一索引；有历史重复数据时只记录日志，待管理员处理后自动补建
+func ensureRevenueNaturalKey(db *gorm.DB) {
+	created, err := models.EnsureRevenueNaturalKey(db)
+	if err != nil {
+		log.Printf("Failed to create revenue unique index: %v", err)
+	} else if !created {
+		log.Printf("Duplicate revenue records found, unique index %s not created; resolve them via GET /api/employee-revenue/duplicates",
+			models.RevenueNaturalKeyIndex)
+	}
+}
+
 func adminInit(db *gorm.DB) {
 	jwtTools := utils.NewJWTTools()
 
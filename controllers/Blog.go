@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"blog/models"
+	"blog/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
@@ -18,27 +19,83 @@ type BlogController interface {
 	DeleteBlog(ctx *gin.Context)
 	GetCurrentUserBlogs(ctx *gin.Context)
 	GetMyBlogInfo(ctx *gin.Context)
+	GetFeed(ctx *gin.Context) // 关注作者的文章流
 }
 
 type blogController struct {
-	db *gorm.DB
+	db    *gorm.DB
+	views *ViewTracker
 }
 
-func NewBlogController(db *gorm.DB) BlogController {
-	return &blogController{db: db}
+func NewBlogController(db *gorm.DB, views *ViewTracker) BlogController {
+	return &blogController{db: db, views: views}
 }
 
 type BlogWithAuthor struct {
-	ID        uint   `json:"id"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	AuthorID  uint   `json:"author_id"`
-	Category  string `json:"category"`
-	Tags      string `json:"tags"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Nickname  string `json:"nickname"`
+	ID            uint    `json:"id"`
+	Title         string  `json:"title"`
+	Content       string  `json:"content"`
+	AuthorID      uint    `json:"author_id"`
+	Category      string  `json:"category"`
+	Tags          string  `json:"tags"`
+	Status        string  `json:"status"`
+	Slug          string  `json:"slug"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
+	Nickname      string  `json:"nickname"`
+	ViewCount     int64   `json:"view_count"`
+	LikeCount     int64   `json:"like_count"`
+	BookmarkCount int64   `json:"bookmark_count"`
+	CommentCount  int64   `json:"comment_count"`
+	LastCommentAt *string `json:"last_comment_at"`
+
+	Series *SeriesNavigation `json:"series,omitempty" gorm:"-"` // 所属系列的上下篇导航（仅详情接口返回）
+
+	// 游标分页使用的排序键，不返回给前端
+	CursorTime time.Time `json:"-" gorm:"column:cursor_time"`
+	CursorID   uint      `json:"-" gorm:"column:cursor_id"`
+}
+
+// blogListColumns 博客列表统一查询的字段（不含正文）
+const blogListColumns = `
+	blog.id,
+	blog.title,
+	blog.author_id,
+	blog.category,
+	blog.tags,
+	blog.status,
+	blog.slug,
+	blog.created_at,
+	blog.updated_at,
+	blog.view_count,
+	blog.like_count,
+	blog.bookmark_count,
+	blog.comment_count,
+	blog.last_comment_at,
+	users.nickname as nickname`
+
+// blogListQuery 构造关联 users 表的博客查询，并带上游标分页需要的排序键
+func blogListQuery(db *gorm.DB, withContent bool) *gorm.DB {
+	columns := blogListColumns
+	if withContent {
+		columns += ", blog.content"
+	}
+	return db.Model(&models.Blog{}).
+		Select(columns + ", blog.created_at as cursor_time, blog.id as cursor_id").
+		Joins("LEFT JOIN users ON users.id = blog.user_id")
+}
+
+// pageBlogs 截掉为判断下一页而多取的一条，并生成下一页游标
+func pageBlogs(p utils.Pagination, blogs []BlogWithAuthor) ([]BlogWithAuthor, string) {
+	if blogs == nil {
+		blogs = []BlogWithAuthor{}
+	}
+	if len(blogs) <= p.Limit {
+		return blogs, ""
+	}
+	blogs = blogs[:p.Limit]
+	last := blogs[len(blogs)-1]
+	return blogs, utils.EncodeCursor(last.CursorTime, last.CursorID)
 }
 
 // ✅ 创建博客（仅限登录用户）
@@ -57,65 +114,124 @@ func (c *blogController) CreateBlog(ctx *gin.Context) {
 	}
 
 	userID, _ := userIDRaw.(uint)
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	// 需要审核的分类不能直接发布
+	if err := checkStatusChange(models.BlogStatusDraft, blog.Status, utils.ReviewRequired(blog.Category), role); err != nil {
+		writeStatusChangeError(ctx, err)
+		return
+	}
 
 	blog.UserID = userID
 	blog.AuthorID = userID
+	// 计数字段由服务端维护，忽略请求体中的值
+	blog.ViewCount, blog.LikeCount, blog.BookmarkCount, blog.CommentCount = 0, 0, 0, 0
+	blog.LastCommentAt, blog.PublishedAt = nil, nil
 
-	if err := c.db.Create(&blog).Error; err != nil {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		return createBlogWithOwner(tx, &blog, userID, gin.H{"title": blog.Title, "status": blog.Status})
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create blog"})
 		return
 	}
 
+	// 直接发布的文章通知关注者
+	if blog.Status == "published" {
+		notifyFollowers(c.db, &blog)
+	}
+
 	ctx.JSON(http.StatusCreated, blog)
 }
 
-// ✅ 获取单个博客详情（所有用户都可查看）
+// createBlogWithOwner 创建博客，将作者作为所有者写入协作者表，并以 operatorID 记录创建操作。
+// 直接发布的博客以创建时间（导入时为原文时间）作为首次发布时间
+func createBlogWithOwner(tx *gorm.DB, blog *models.Blog, operatorID uint, audit gin.H) error {
+	if blog.Status == models.BlogStatusPublished && blog.PublishedAt == nil {
+		publishedAt := blog.CreatedAt
+		if publishedAt.IsZero() {
+			publishedAt = time.Now()
+		}
+		blog.PublishedAt = &publishedAt
+	}
+	if err := tx.Create(blog).Error; err != nil {
+		return err
+	}
+	owner := models.BlogCollaborator{BlogID: blog.ID, UserID: blog.AuthorID, Role: models.CollaboratorOwner}
+	if err := tx.Create(&owner).Error; err != nil {
+		return err
+	}
+	return recordBlogAudit(tx, blog.ID, operatorID, "create", audit)
+}
+
+// ✅ 获取单个博客详情（已发布的所有用户可查看，其他状态仅作者、协作者和管理员可查看）
 func (c *blogController) GetBlogByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	var blog BlogWithAuthor
-	if err := c.db.Table("blog").
-		Select(`
-			blog.id,
-			blog.title,
-			blog.content,
-			blog.author_id,
-			blog.category,
-			blog.tags,
-			blog.status,
-			blog.created_at,
-			blog.updated_at,
-			users.nickname as nickname
-		`).
-		Joins("LEFT JOIN users ON users.id = blog.user_id").
+	if err := blogListQuery(c.db, true).
 		Where("blog.id = ?", id).
 		First(&blog).Error; err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
 		return
 	}
 
+	userIDRaw, _ := ctx.Get("userId")
+	userID, _ := userIDRaw.(uint)
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	// 不可见时与不存在一样返回 404，不暴露未发布的博客
+	target := models.Blog{AuthorID: blog.AuthorID, Status: blog.Status}
+	target.ID = blog.ID
+	visible, err := canViewBlog(c.db, &target, userID, role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission"})
+		return
+	}
+	if !visible {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return
+	}
+
+	// 记录浏览量（作者本人访问不计入），写入内存缓冲，由后台批量落库
+	if c.views != nil && (userID == 0 || userID != blog.AuthorID) {
+		viewer := "ip:" + ctx.ClientIP()
+		if userID != 0 {
+			viewer = "user:" + strconv.Itoa(int(userID))
+		}
+		// 前端 SPA 通过 ref 参数传入 document.referrer，缺省时使用请求头
+		referrer := ctx.Query("ref")
+		if referrer == "" {
+			referrer = ctx.GetHeader("Referer")
+		}
+		c.views.Record(blog.ID, viewer, referrer, time.Now())
+	}
+
+	// 系列文章的上下篇导航
+	series, err := loadSeriesNavigation(c.db, blog.ID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series navigation"})
+		return
+	}
+	blog.Series = series
+
 	ctx.JSON(http.StatusOK, blog)
 }
 
-// ✅ 获取博客列表（分页查询 + 权限判断）
+// ✅ 获取博客列表（筛选 + 排序 + 分页）
 func (c *blogController) GetBlogsPaginated(ctx *gin.Context) {
-	pageStr := ctx.DefaultQuery("page", "1")
-	date := ctx.Query("date")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
+	f, p, ok := parseBlogListParams(ctx, 6)
+	if !ok {
+		return
 	}
-	limit := 6
-	offset := (page - 1) * limit
 
-	// 根据日期筛选条件构造查询
-	query := c.db.Model(&models.Blog{})
-	if date != "" {
-		startDate, _ := time.Parse("2006-01-02", date)
-		endDate := startDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
-		query = query.Where("created_at BETWEEN ? AND ?", startDate, endDate)
-	}
+	// 计数与取数共用同一组筛选条件；未发布的博客只返回当前用户作为作者或协作者的（管理员不受限制）
+	userIDRaw, _ := ctx.Get("userId")
+	userID, _ := userIDRaw.(uint)
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	query := visibleBlogs(f.Apply(c.db.Model(&models.Blog{})), userID, role).Session(&gorm.Session{})
 
 	// 统计总数
 	var total int64
@@ -126,46 +242,15 @@ func (c *blogController) GetBlogsPaginated(ctx *gin.Context) {
 
 	// 查询数据并关联 users 表取 nickname
 	var blogs []BlogWithAuthor
-	dataQuery := c.db.Table("blog").
-		Select(`
-            blog.id,
-            blog.title,
-            blog.content,
-            blog.author_id,
-            blog.category,
-            blog.tags,
-            blog.status,
-            blog.created_at,
-            blog.updated_at,
-            users.nickname as nickname
-        `).
-		Joins("LEFT JOIN users ON users.id = blog.user_id").
-		Order("blog.created_at DESC").
-		Limit(limit).
-		Offset(offset)
-
-	if date != "" {
-		startDate, _ := time.Parse("2006-01-02", date)
-		endDate := startDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
-		dataQuery = dataQuery.Where("blog.created_at BETWEEN ? AND ?", startDate, endDate)
-	}
-	userIDStr := ctx.Query("userId")
-	if userIDStr != "" {
-		if userID, err := strconv.Atoi(userIDStr); err == nil {
-			query = query.Where("user_id = ?", userID)
-			dataQuery = dataQuery.Where("blog.user_id = ?", userID)
-		}
-	}
-
-	if err := dataQuery.Scan(&blogs).Error; err != nil {
+	if err := f.Paginate(blogListQuery(query, true), p).Scan(&blogs).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
 		return
 	}
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	ctx.JSON(http.StatusOK, gin.H{
-		"data":       blogs,
-		"totalPages": totalPages,
-	})
+
+	blogs, nextCursor := f.pageBlogs(p, blogs)
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
 }
 
 // ✅ 获取博客目录（根据身份筛选）
@@ -182,23 +267,19 @@ func (c *blogController) GetBlogDirectory(ctx *gin.Context) {
 		return
 	}
 
+	// 支持与博客列表相同的筛选条件，作者固定为当前用户（collaborating=true 时改为参与协作的博客）
+	f, err := parseBlogFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if f.CollaboratorID == 0 {
+		f.AuthorID = userID
+	}
+
 	// 查询当前用户的所有博客（不再区分管理员）
 	var results []BlogWithAuthor
-	if err := c.db.Table("blog").
-		Select(`
-            blog.id,
-            blog.title,
-            blog.author_id,
-            blog.category,
-            blog.tags,
-            blog.status,
-            blog.created_at,
-            blog.updated_at,
-            users.nickname as nickname
-        `).
-		Joins("LEFT JOIN users ON users.id = blog.user_id").
-		Where("blog.author_id = ?", userID).
-		Order("blog.created_at DESC").
+	if err := f.Order(f.Apply(blogListQuery(c.db, false))).
 		Scan(&results).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blog directory"})
 		return
@@ -214,68 +295,129 @@ func (c *blogController) GetBlogDirectory(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, directory)
 }
 
-// ✅ 更新博客（仅限作者或管理员）
+// ✅ 更新博客（所有者、编辑或管理员；编辑不能修改状态）
 func (c *blogController) UpdateBlog(ctx *gin.Context) {
-	id := ctx.Param("id")
+	blog, userID, blogRole, ok := authorizeBlog(ctx, c.db, models.CollaboratorEditor)
+	if !ok {
+		return
+	}
 
-	var blog models.Blog
-	if err := c.db.First(&blog, id).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+	var updateData models.Blog
+	if err := ctx.ShouldBindJSON(&updateData); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// 主键、归属与计数字段由服务端维护，Updates 会忽略零值
+	updateData.BaseModel = models.BaseModel{}
+	updateData.UserID, updateData.AuthorID = 0, 0
+	updateData.ViewCount, updateData.LikeCount, updateData.BookmarkCount, updateData.CommentCount = 0, 0, 0, 0
+	updateData.LastCommentAt, updateData.PublishedAt = nil, nil
 
-	userIDRaw, _ := ctx.Get("userId")
-	userID, _ := userIDRaw.(uint)
+	if updateData.Status == blog.Status {
+		updateData.Status = ""
+	}
+	if blogRole != models.CollaboratorOwner && updateData.Status != "" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change the status"})
+		return
+	}
+
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	// 原分类或新分类任一需要审核，都按需要审核处理，避免先换到无需审核的分类发布再换回
+	reviewRequired := utils.ReviewRequired(blog.Category) ||
+		(updateData.Category != "" && utils.ReviewRequired(updateData.Category))
 
-	if blog.AuthorID != userID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	changes := blogChanges(blog, &updateData)
+	_, statusChanged := changes["status"]
+	edited := len(changes) > 0 && !(statusChanged && len(changes) == 1)
+
+	// 审核中的文章不能修改内容；审核通过或已发布的文章修改内容（含分类）后需要重新审核
+	from := blog.Status
+	if edited && from == models.BlogStatusInReview {
+		writeStatusChangeError(ctx, errBlogInReview)
+		return
+	}
+	resubmit := edited && reviewRequired &&
+		(from == models.BlogStatusApproved || from == models.BlogStatusPublished)
+	if resubmit {
+		from = models.BlogStatusInReview
+	}
+	if err := checkStatusChange(from, updateData.Status, reviewRequired, role); err != nil {
+		writeStatusChangeError(ctx, err)
 		return
 	}
 
-	var updateData models.Blog
-	if err := ctx.ShouldBindJSON(&updateData); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	firstPublish := false
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(blog).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		// 只有首次发布时才记录发布时间，撤回后再次发布不重复通知关注者
+		if updateData.Status == models.BlogStatusPublished {
+			now := time.Now()
+			result := tx.Model(&models.Blog{}).Where("id = ? AND published_at IS NULL", blog.ID).UpdateColumn("published_at", now)
+			if result.Error != nil {
+				return result.Error
+			}
+			if firstPublish = result.RowsAffected > 0; firstPublish {
+				blog.PublishedAt = &now
+			}
+		}
+		if err := recordBlogAudit(tx, blog.ID, userID, "update", changes); err != nil {
+			return err
+		}
+		if resubmit && !statusChanged {
+			_, err := transitionBlog(tx, blog, userID, "resubmit", models.BlogStatusInReview, "", nil)
+			return err
+		}
+		// 他人（如管理员）修改状态时通知作者
+		if statusChanged && userID != blog.AuthorID {
+			return notifyBlogAuthor(tx, blog, updateData.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update blog"})
 		return
 	}
 
-	c.db.Model(&blog).Updates(updateData)
+	// 首次发布时通知关注者
+	if firstPublish {
+		notifyFollowers(c.db, blog)
+	}
 	ctx.JSON(http.StatusOK, blog)
 }
 
-// ✅ 删除博客（仅限作者或管理员）
+// ✅ 删除博客（仅限所有者或管理员），移入回收站
 func (c *blogController) DeleteBlog(ctx *gin.Context) {
-	id := ctx.Param("id")
-
-	var blog models.Blog
-	if err := c.db.First(&blog, id).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+	blog, userID, _, ok := authorizeBlog(ctx, c.db, models.CollaboratorOwner)
+	if !ok {
 		return
 	}
 
-	userIDRaw, _ := ctx.Get("userId")
-	userID, _ := userIDRaw.(uint)
-
-	if blog.AuthorID != userID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+	// 博客连同评论移入回收站；系列、协作者等关联数据保留以便恢复，由清理任务到期后一并删除
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := trashBlogs(tx, []uint{blog.ID}, time.Now()); err != nil {
+			return err
+		}
+		return recordBlogAudit(tx, blog.ID, userID, "delete", gin.H{"title": blog.Title})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete blog"})
 		return
 	}
-
-	c.db.Delete(&blog)
-	ctx.JSON(http.StatusOK, gin.H{"message": "Blog deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Blog moved to trash"})
 }
 
 func (c *blogController) GetCurrentUserBlogs(ctx *gin.Context) {
-	// 获取页码参数
-	pageStr := ctx.DefaultQuery("page", "1")
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
+	// 获取筛选与分页参数（与博客列表一致）
+	f, p, ok := parseBlogListParams(ctx, 6)
+	if !ok {
+		return
 	}
-	limit := 6
-	offset := (page - 1) * limit
-
-	// 获取日期筛选参数，格式应为 "YYYY-MM-DD"
-	date := ctx.Query("date")
 
 	// 获取当前用户ID
 	userIDRaw, exists := ctx.Get("userId")
@@ -283,76 +425,42 @@ func (c *blogController) GetCurrentUserBlogs(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	userID, ok := userIDRaw.(uint)
-	if !ok || userID == 0 {
+	userID, isUint := userIDRaw.(uint)
+	if !isUint || userID == 0 {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	// 构造计数查询：根据当前用户和（可选）日期进行筛选
-	countQuery := c.db.Model(&models.Blog{}).Where("user_id = ?", userID)
-	if date != "" {
-		if startDate, err := time.Parse("2006-01-02", date); err == nil {
-			endDate := startDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
-			countQuery = countQuery.Where("created_at BETWEEN ? AND ?", startDate, endDate)
-		}
+	// 计数与取数共用同一组筛选条件，作者固定为当前用户（collaborating=true 时改为参与协作的博客）
+	if f.CollaboratorID == 0 {
+		f.AuthorID = userID
 	}
+	query := f.Apply(c.db.Model(&models.Blog{})).Session(&gorm.Session{})
 
 	var total int64
-	if err := countQuery.Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count blogs"})
 		return
 	}
 
-	// 构造数据查询：关联 users 表获取昵称，并根据条件查询当前用户的博客
-	dataQuery := c.db.Table("blog").
-		Select(`
-			blog.id,
-			blog.title,
-			blog.content,
-			blog.user_id AS author_id,
-			blog.category,
-			blog.tags,
-			blog.status,
-			blog.created_at,
-			blog.updated_at,
-			users.nickname as nickname
-		`).
-		Joins("LEFT JOIN users ON users.id = blog.user_id").
-		Where("blog.user_id = ?", userID)
-	if date != "" {
-		if startDate, err := time.Parse("2006-01-02", date); err == nil {
-			endDate := startDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
-			dataQuery = dataQuery.Where("blog.created_at BETWEEN ? AND ?", startDate, endDate)
-		}
-	}
-
-	dataQuery = dataQuery.Order("blog.created_at DESC").
-		Limit(limit).
-		Offset(offset)
-
 	var blogs []BlogWithAuthor
-	if err := dataQuery.Scan(&blogs).Error; err != nil {
+	if err := f.Paginate(blogListQuery(query, true), p).Scan(&blogs).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user's blogs"})
 		return
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	ctx.JSON(http.StatusOK, gin.H{
-		"data":       blogs,
-		"totalPages": totalPages,
-	})
+	blogs, nextCursor := f.pageBlogs(p, blogs)
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
 }
 
 func (c *blogController) GetMyBlogInfo(ctx *gin.Context) {
-	// 获取分页参数
-	pageStr := ctx.DefaultQuery("page", "1")
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
+	// 获取筛选与分页参数（与博客列表一致）
+	f, p, ok := parseBlogListParams(ctx, 6)
+	if !ok {
+		return
 	}
-	limit := 6
-	offset := (page - 1) * limit
 
 	// 从 token 中获取当前用户 ID
 	userIDRaw, exists := ctx.Get("userId")
@@ -360,44 +468,31 @@ func (c *blogController) GetMyBlogInfo(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	userID, ok := userIDRaw.(uint)
-	if !ok || userID == 0 {
+	userID, isUint := userIDRaw.(uint)
+	if !isUint || userID == 0 {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
+	if f.CollaboratorID == 0 {
+		f.AuthorID = userID
+	}
+	query := f.Apply(c.db.Model(&models.Blog{})).Session(&gorm.Session{})
+
 	// 查询当前用户的博客总数
 	var total int64
-	if err := c.db.Model(&models.Blog{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count blogs"})
 		return
 	}
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
 
 	// 查询当前用户的博客数据（分页）
 	var blogs []BlogWithAuthor
-	if err := c.db.Table("blog").
-		Select(`
-			blog.id,
-			blog.title,
-			blog.content,
-			blog.user_id AS author_id,
-			blog.category,
-			blog.tags,
-			blog.status,
-			blog.created_at,
-			blog.updated_at,
-			users.nickname as nickname
-		`).
-		Joins("LEFT JOIN users ON users.id = blog.user_id").
-		Where("blog.user_id = ?", userID).
-		Order("blog.created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Scan(&blogs).Error; err != nil {
+	if err := f.Paginate(blogListQuery(query, true), p).Scan(&blogs).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
 		return
 	}
+	blogs, nextCursor := f.pageBlogs(p, blogs)
 
 	// 构造目录数据：按年月分组
 	directory := make(map[string][]BlogWithAuthor)
@@ -413,10 +508,51 @@ func (c *blogController) GetMyBlogInfo(ctx *gin.Context) {
 		profile = models.Users{}
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"blogs":      blogs,
-		"totalPages": totalPages,
-		"directory":  directory,
-		"profile":    profile,
-	})
+	resp := p.Meta(total, nextCursor)
+	resp["blogs"] = blogs
+	resp["directory"] = directory
+	resp["profile"] = profile
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ✅ 获取关注作者已发布的文章（基于 (created_at, id) 的游标分页）
+func (c *blogController) GetFeed(ctx *gin.Context) {
+	userIDRaw, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(uint)
+	if !ok || userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	p, err := utils.ParsePagination(ctx, 10)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	query := c.db.Model(&models.Blog{}).
+		Joins("JOIN user_follows ON user_follows.followee_id = blog.author_id AND user_follows.follower_id = ?", userID).
+		Where("blog.status = ?", "published").
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count feed"})
+		return
+	}
+
+	var blogs []BlogWithAuthor
+	if err := p.Apply(blogListQuery(query, true), "blog.created_at", "blog.id").Scan(&blogs).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+
+	blogs, nextCursor := pageBlogs(p, blogs)
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BlogAnalyticsController 定义作者查看博客阅读数据的接口
+type BlogAnalyticsController interface {
+	GetDailyViews(ctx *gin.Context) // 单篇博客每日浏览量
+	GetTopBlogs(ctx *gin.Context)   // 作者浏览量最高的博客
+	GetReferrers(ctx *gin.Context)  // 单篇博客来源统计
+}
+
+type blogAnalyticsController struct {
+	db *gorm.DB
+}
+
+// NewBlogAnalyticsController 创建一个新的 BlogAnalyticsController
+func NewBlogAnalyticsController(db *gorm.DB) BlogAnalyticsController {
+	return &blogAnalyticsController{db: db}
+}
+
+// DailyViews 每日浏览量
+type DailyViews struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+}
+
+// TopBlog 浏览量排行中的一篇博客
+type TopBlog struct {
+	BlogID    uint   `json:"blog_id"`
+	Title     string `json:"title"`
+	Views     int64  `json:"views"`
+	ViewCount int64  `json:"view_count"`
+}
+
+// ReferrerViews 来源统计
+type ReferrerViews struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+// parseAnalyticsRange 解析 startDate / endDate（YYYY-MM-DD），缺省为最近 30 天，区间不超过一年
+func parseAnalyticsRange(ctx *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	startDate := endDate.AddDate(0, 0, -29)
+
+	if s := ctx.Query("startDate"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate format"})
+			return startDate, endDate, false
+		}
+		startDate = t
+	}
+	if s := ctx.Query("endDate"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate format"})
+			return startDate, endDate, false
+		}
+		endDate = t
+	}
+	if endDate.Before(startDate) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "endDate must not be before startDate"})
+		return startDate, endDate, false
+	}
+	// 每日浏览量会逐日补 0，限制区间长度（按日历天数比较，不受夏令时影响）
+	if endDate.After(startDate.AddDate(0, 0, 366)) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Date range must not exceed one year"})
+		return startDate, endDate, false
+	}
+	return startDate, endDate, true
+}
+
+// authorizedBlog 查找博客并校验当前用户是作者或管理员
+func (c *blogAnalyticsController) authorizedBlog(ctx *gin.Context) (*models.Blog, bool) {
+	var blog models.Blog
+	if err := c.db.First(&blog, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return nil, false
+	}
+
+	userIDRaw, _ := ctx.Get("userId")
+	userID, _ := userIDRaw.(uint)
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	if blog.AuthorID != userID && role > utils.RoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, false
+	}
+	return &blog, true
+}
+
+// GetDailyViews 获取单篇博客在时间范围内的每日浏览量（无数据的日期补 0）
+func (c *blogAnalyticsController) GetDailyViews(ctx *gin.Context) {
+	blog, ok := c.authorizedBlog(ctx)
+	if !ok {
+		return
+	}
+	startDate, endDate, ok := parseAnalyticsRange(ctx)
+	if !ok {
+		return
+	}
+
+	var rows []DailyViews
+	if err := c.db.Model(&models.BlogViewDaily{}).
+		Select("date, SUM(views) as views").
+		Where("blog_id = ? AND date BETWEEN ? AND ?", blog.ID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Group("date").
+		Scan(&rows).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch daily views"})
+		return
+	}
+
+	byDate := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row.Views
+	}
+
+	var total int64
+	daily := make([]DailyViews, 0)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		daily = append(daily, DailyViews{Date: key, Views: byDate[key]})
+		total += byDate[key]
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"blog_id":    blog.ID,
+		"data":       daily,
+		"total":      total,
+		"view_count": blog.ViewCount,
+	})
+}
+
+// GetTopBlogs 获取作者在时间范围内浏览量最高的博客（管理员可通过 userId 查看其他作者）
+func (c *blogAnalyticsController) GetTopBlogs(ctx *gin.Context) {
+	userIDRaw, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	authorID, _ := userIDRaw.(uint)
+
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	if userIDStr := ctx.Query("userId"); userIDStr != "" {
+		id, err := strconv.Atoi(userIDStr)
+		if err != nil || id < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId"})
+			return
+		}
+		if uint(id) != authorID && role > utils.RoleAdmin {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+		authorID = uint(id)
+	}
+
+	startDate, endDate, ok := parseAnalyticsRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	var results []TopBlog
+	if err := c.db.Table("blog_view_daily").
+		Select("blog.id as blog_id, blog.title, SUM(blog_view_daily.views) as views, blog.view_count").
+		Joins("JOIN blog ON blog.id = blog_view_daily.blog_id AND blog.deleted_at IS NULL").
+		Where("blog.author_id = ?", authorID).
+		Where("blog_view_daily.date BETWEEN ? AND ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Group("blog.id, blog.title, blog.view_count").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&results).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top blogs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// GetReferrers 获取单篇博客在时间范围内的来源统计
+func (c *blogAnalyticsController) GetReferrers(ctx *gin.Context) {
+	blog, ok := c.authorizedBlog(ctx)
+	if !ok {
+		return
+	}
+	startDate, endDate, ok := parseAnalyticsRange(ctx)
+	if !ok {
+		return
+	}
+
+	var results []ReferrerViews
+	if err := c.db.Model(&models.BlogReferrerDaily{}).
+		Select("referrer, SUM(views) as views").
+		Where("blog_id = ? AND date BETWEEN ? AND ?", blog.ID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Group("referrer").
+		Order("views DESC").
+		Limit(20).
+		Scan(&results).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch referrers"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"blog_id": blog.ID, "data": results})
+}
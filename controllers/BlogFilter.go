@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// blogStatuses 允许筛选的博客状态
+var blogStatuses = map[string]bool{
+	models.BlogStatusDraft:     true,
+	models.BlogStatusInReview:  true,
+	models.BlogStatusApproved:  true,
+	models.BlogStatusPublished: true,
+}
+
+// blogSorts 支持的排序方式
+var blogSorts = map[string]bool{
+	"newest":         true,
+	"oldest":         true,
+	"most_viewed":    true,
+	"most_commented": true,
+}
+
+// blogCommentCountExpr 按评论数排序时使用的表达式（评论数随评论增删同事务维护）
+const blogCommentCountExpr = "blog.comment_count"
+
+// BlogFilter 博客列表的筛选与排序条件
+type BlogFilter struct {
+	StartDate *time.Time // 创建时间起（含）
+	EndDate   *time.Time // 创建时间止（含当天）
+	Category  string
+	Tags      []string // 必须同时包含的标签
+	AuthorID  uint
+	Status    string
+	Keyword   string // 标题或正文包含的关键字
+	Sort      string
+
+	CollaboratorID uint // 只看该用户以编辑/查看者身份参与协作的博客
+}
+
+// parseBlogFilter 解析筛选参数：
+// date（单日）或 startDate/endDate（YYYY-MM-DD）、category、tags（逗号分隔，也可重复传 tag）、
+// authorId（兼容旧参数 userId）、status、keyword、sort（newest/oldest/most_viewed/most_commented）、
+// collaborating=true（只看当前用户参与协作的博客）
+func parseBlogFilter(ctx *gin.Context) (BlogFilter, error) {
+	f := BlogFilter{Sort: "newest"}
+
+	parseDate := func(name string) (*time.Time, error) {
+		value := ctx.Query(name)
+		if value == "" {
+			return nil, nil
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, errors.New("Invalid " + name + " format, expected YYYY-MM-DD")
+		}
+		return &t, nil
+	}
+
+	date, err := parseDate("date")
+	if err != nil {
+		return f, err
+	}
+	if f.StartDate, err = parseDate("startDate"); err != nil {
+		return f, err
+	}
+	if f.EndDate, err = parseDate("endDate"); err != nil {
+		return f, err
+	}
+	if date != nil {
+		if f.StartDate != nil || f.EndDate != nil {
+			return f, errors.New("date cannot be combined with startDate/endDate")
+		}
+		f.StartDate, f.EndDate = date, date
+	}
+	if f.StartDate != nil && f.EndDate != nil && f.EndDate.Before(*f.StartDate) {
+		return f, errors.New("endDate must not be before startDate")
+	}
+
+	f.Category = strings.TrimSpace(ctx.Query("category"))
+
+	rawTags := ctx.QueryArray("tag")
+	if tags := ctx.Query("tags"); tags != "" {
+		rawTags = append(rawTags, strings.Split(tags, ",")...)
+	}
+	for _, tag := range rawTags {
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), " ", "")
+		if tag != "" {
+			f.Tags = append(f.Tags, tag)
+		}
+	}
+	if len(f.Tags) > 10 {
+		return f, errors.New("At most 10 tags are allowed")
+	}
+
+	authorStr := ctx.Query("authorId")
+	if authorStr == "" {
+		authorStr = ctx.Query("userId")
+	}
+	if authorStr != "" {
+		authorID, err := strconv.Atoi(authorStr)
+		if err != nil || authorID < 1 {
+			return f, errors.New("Invalid authorId")
+		}
+		f.AuthorID = uint(authorID)
+	}
+
+	if status := ctx.Query("status"); status != "" {
+		if !blogStatuses[status] {
+			return f, errors.New("Invalid status")
+		}
+		f.Status = status
+	}
+
+	if collaborating := ctx.Query("collaborating"); collaborating != "" {
+		enabled, err := strconv.ParseBool(collaborating)
+		if err != nil {
+			return f, errors.New("Invalid collaborating, expected true or false")
+		}
+		if enabled {
+			userIDRaw, _ := ctx.Get("userId")
+			if f.CollaboratorID, _ = userIDRaw.(uint); f.CollaboratorID == 0 {
+				return f, errors.New("collaborating requires a signed-in user")
+			}
+		}
+	}
+
+	f.Keyword = strings.TrimSpace(ctx.Query("keyword"))
+	if len([]rune(f.Keyword)) > 100 {
+		return f, errors.New("keyword is too long")
+	}
+
+	if sort := ctx.Query("sort"); sort != "" {
+		if !blogSorts[sort] {
+			return f, errors.New("Invalid sort, expected one of newest, oldest, most_viewed, most_commented")
+		}
+		f.Sort = sort
+	}
+
+	return f, nil
+}
+
+// parseBlogListParams 解析筛选条件与分页参数，参数非法时直接返回 400
+func parseBlogListParams(ctx *gin.Context, defaultLimit int) (BlogFilter, utils.Pagination, bool) {
+	f, err := parseBlogFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return f, utils.Pagination{}, false
+	}
+
+	p, err := utils.ParsePagination(ctx, defaultLimit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return f, p, false
+	}
+	if p.HasCursor && !f.cursorSortable() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "cursor is only supported when sorting by newest or oldest"})
+		return f, p, false
+	}
+	return f, p, true
+}
+
+// cursorSortable 只有按创建时间排序时才能使用 (created_at, id) 游标
+func (f BlogFilter) cursorSortable() bool {
+	return f.Sort == "newest" || f.Sort == "oldest"
+}
+
+// Apply 加上筛选条件（作用于 blog 表）
+func (f BlogFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.StartDate != nil {
+		db = db.Where("blog.created_at >= ?", *f.StartDate)
+	}
+	if f.EndDate != nil {
+		db = db.Where("blog.created_at < ?", f.EndDate.AddDate(0, 0, 1))
+	}
+	if f.Category != "" {
+		db = db.Where("blog.category = ?", f.Category)
+	}
+	// 标签以逗号分隔存储，去掉空格后按 ",tag," 精确匹配
+	for _, tag := range f.Tags {
+		db = db.Where("(',' || REPLACE(blog.tags, ' ', '') || ',') LIKE ? ESCAPE '\\'", "%,"+escapeLike(tag)+",%")
+	}
+	if f.AuthorID != 0 {
+		db = db.Where("blog.author_id = ?", f.AuthorID)
+	}
+	if f.Status != "" {
+		db = db.Where("blog.status = ?", f.Status)
+	}
+	if f.CollaboratorID != 0 {
+		db = db.Where("blog.id IN (SELECT blog_id FROM blog_collaborators WHERE user_id = ? AND role <> ?)",
+			f.CollaboratorID, models.CollaboratorOwner)
+	}
+	if f.Keyword != "" {
+		like := "%" + escapeLike(f.Keyword) + "%"
+		db = db.Where("(blog.title LIKE ? ESCAPE '\\' OR blog.content LIKE ? ESCAPE '\\')", like, like)
+	}
+	return db
+}
+
+// Paginate 按排序方式加上排序与分页
+func (f BlogFilter) Paginate(db *gorm.DB, p utils.Pagination) *gorm.DB {
+	switch f.Sort {
+	case "oldest":
+		return p.ApplyAscending(db, "blog.created_at", "blog.id")
+	case "most_viewed":
+		return p.ApplyOffset(db.Order("blog.view_count DESC").Order("blog.id DESC"))
+	case "most_commented":
+		return p.ApplyOffset(db.Order(blogCommentCountExpr + " DESC").Order("blog.id DESC"))
+	default:
+		return p.Apply(db, "blog.created_at", "blog.id")
+	}
+}
+
+// Order 不分页时的排序（目录等接口）
+func (f BlogFilter) Order(db *gorm.DB) *gorm.DB {
+	switch f.Sort {
+	case "oldest":
+		return db.Order("blog.created_at ASC").Order("blog.id ASC")
+	case "most_viewed":
+		return db.Order("blog.view_count DESC").Order("blog.id DESC")
+	case "most_commented":
+		return db.Order(blogCommentCountExpr + " DESC").Order("blog.id DESC")
+	default:
+		return db.Order("blog.created_at DESC").Order("blog.id DESC")
+	}
+}
+
+// pageBlogs 截掉为判断下一页而多取的一条；按创建时间排序时生成下一页游标
+func (f BlogFilter) pageBlogs(p utils.Pagination, blogs []BlogWithAuthor) ([]BlogWithAuthor, string) {
+	blogs, nextCursor := pageBlogs(p, blogs)
+	if !f.cursorSortable() {
+		nextCursor = ""
+	}
+	return blogs, nextCursor
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BlogTemplateController 定义博客模板的接口
+type BlogTemplateController interface {
+	CreateTemplate(ctx *gin.Context)          // 创建模板（管理员）
+	UpdateTemplate(ctx *gin.Context)          // 更新模板（管理员）
+	DeleteTemplate(ctx *gin.Context)          // 删除模板（管理员）
+	GetTemplate(ctx *gin.Context)             // 获取模板详情
+	ListTemplates(ctx *gin.Context)           // 模板列表
+	CreateDraftFromTemplate(ctx *gin.Context) // 用模板生成草稿
+}
+
+type blogTemplateController struct {
+	db *gorm.DB
+}
+
+// NewBlogTemplateController 创建一个新的 BlogTemplateController
+func NewBlogTemplateController(db *gorm.DB) BlogTemplateController {
+	return &blogTemplateController{db: db}
+}
+
+// TemplateDraftInput 用模板生成草稿的请求体；日期省略时按模板周期取最近一天/一周（截至昨天）
+type TemplateDraftInput struct {
+	StartDate string            `json:"start_date"` // YYYY-MM-DD
+	EndDate   string            `json:"end_date"`   // YYYY-MM-DD（含当天）
+	Variables map[string]string `json:"variables"`  // 自定义占位符的值，不能覆盖内置变量
+}
+
+// RevenueSummaryRow 模板中收益表格的一行（按广告平台汇总）
+type RevenueSummaryRow struct {
+	AdPlatform  string       `json:"ad_platform"`
+	Expenditure models.Money `json:"expenditure"`
+	Revenue     models.Money `json:"revenue"`
+	OrderCount  int64        `json:"order_count"`
+	AdCreation  int64        `json:"ad_creation_count"`
+	ROI         float64      `json:"roi"`
+	ProductCost models.Money `json:"product_cost"`
+	PlatformFee models.Money `json:"platform_fee"`
+	ShippingFee models.Money `json:"shipping_fee"`
+	// 毛利、毛利率、ROAS、保本 ROI
+	models.RevenueProfit
+}
+
+var templatePeriods = map[string]bool{"daily": true, "weekly": true}
+
+// validateTemplate 校验模板必填字段与统计周期
+func validateTemplate(template *models.BlogTemplate) string {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || strings.TrimSpace(template.Title) == "" || strings.TrimSpace(template.Content) == "" {
+		return "name, title and content are required"
+	}
+	if template.Period == "" {
+		template.Period = "daily"
+	}
+	if !templatePeriods[template.Period] {
+		return "Invalid period, expected daily or weekly"
+	}
+	return ""
+}
+
+// CreateTemplate 创建模板
+func (c *blogTemplateController) CreateTemplate(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var template models.BlogTemplate
+	if err := ctx.ShouldBindJSON(&template); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateTemplate(&template); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	template.ID = 0
+	template.CreatedBy = userID
+
+	var count int64
+	c.db.Model(&models.BlogTemplate{}).Where("name = ?", template.Name).Count(&count)
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Template name already exists"})
+		return
+	}
+
+	if err := c.db.Create(&template).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		return
+	}
+	ctx.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate 更新模板（整体替换可编辑字段）
+func (c *blogTemplateController) UpdateTemplate(ctx *gin.Context) {
+	var template models.BlogTemplate
+	if err := c.db.First(&template, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	var input models.BlogTemplate
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateTemplate(&input); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var count int64
+	c.db.Model(&models.BlogTemplate{}).Where("name = ? AND id <> ?", input.Name, template.ID).Count(&count)
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Template name already exists"})
+		return
+	}
+
+	template.Name = input.Name
+	template.Description = input.Description
+	template.Period = input.Period
+	template.Title = input.Title
+	template.Content = input.Content
+	template.Category = input.Category
+	template.Tags = input.Tags
+	if err := c.db.Save(&template).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		return
+	}
+	ctx.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate 删除模板（已生成的文章不受影响）
+func (c *blogTemplateController) DeleteTemplate(ctx *gin.Context) {
+	result := c.db.Delete(&models.BlogTemplate{}, ctx.Param("id"))
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// GetTemplate 获取模板详情
+func (c *blogTemplateController) GetTemplate(ctx *gin.Context) {
+	var template models.BlogTemplate
+	if err := c.db.First(&template, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, template)
+}
+
+// ListTemplates 模板列表（按名称排序）
+func (c *blogTemplateController) ListTemplates(ctx *gin.Context) {
+	templates := make([]models.BlogTemplate, 0)
+	if err := c.db.Order("name ASC").Find(&templates).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+// CreateDraftFromTemplate 用模板为当前用户生成一篇草稿，内置变量：
+// author、date、start_date、end_date、spend、revenue、roi、orders、new_products、
+// gross_profit、margin、roas、break_even_roi、currency、revenue_table（金额均为基础币种）
+func (c *blogTemplateController) CreateDraftFromTemplate(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var template models.BlogTemplate
+	if err := c.db.First(&template, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	var input TemplateDraftInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 收益记录按 revenue.timezone 的业务日期统计，“今天”“昨天”也按该时区计算
+	now := time.Now().In(utils.RevenueLocation())
+	startDate, endDate, err := templateDateRange(template.Period, input.StartDate, input.EndDate, now)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := loadRevenueSummary(c.db, userID, startDate, endDate)
+	if err != nil {
+		if respondMissingRate(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revenue data"})
+		return
+	}
+
+	var author models.Users
+	c.db.Select("nickname").First(&author, userID)
+
+	// 自定义变量在前，内置变量覆盖同名的自定义变量
+	vars := make(map[string]string, len(input.Variables)+10)
+	for name, value := range input.Variables {
+		vars[name] = value
+	}
+	total := sumRevenueRows(rows)
+	vars["author"] = author.Nickname
+	vars["date"] = now.Format("2006-01-02")
+	vars["start_date"] = startDate.Format("2006-01-02")
+	vars["end_date"] = endDate.Format("2006-01-02")
+	vars["spend"] = total.Expenditure.String()
+	vars["revenue"] = total.Revenue.String()
+	vars["roi"] = fmt.Sprintf("%.2f", total.ROI)
+	vars["orders"] = fmt.Sprintf("%d", total.OrderCount)
+	vars["new_products"] = fmt.Sprintf("%d", total.AdCreation)
+	vars["gross_profit"] = total.GrossProfit.String()
+	vars["margin"] = fmt.Sprintf("%.2f%%", total.Margin*100)
+	vars["roas"] = fmt.Sprintf("%.2f", total.ROAS)
+	vars["break_even_roi"] = fmt.Sprintf("%.2f", total.BreakEvenROI)
+	vars["currency"] = utils.RevenueBaseCurrency()
+	vars["revenue_table"] = renderRevenueTable(rows, total)
+
+	title, missingTitle := utils.RenderPlaceholders(template.Title, vars)
+	content, missingContent := utils.RenderPlaceholders(template.Content, vars)
+
+	blog := models.Blog{
+		UserID:   userID,
+		AuthorID: userID,
+		Title:    title,
+		Content:  content,
+		Category: template.Category,
+		Tags:     template.Tags,
+		Status:   models.BlogStatusDraft,
+	}
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		return createBlogWithOwner(tx, &blog, userID, gin.H{"title": blog.Title, "status": blog.Status, "template_id": template.ID})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create blog"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"blog":              blog,
+		"start_date":        vars["start_date"],
+		"end_date":          vars["end_date"],
+		"currency":          vars["currency"],
+		"revenue":           rows,
+		"missing_variables": mergeMissing(missingTitle, missingContent),
+	})
+}
+
+// templateDateRange 解析统计区间；都省略时 daily 取昨天，weekly 取截至昨天的 7 天（按 now 所在时区的日期）
+func templateDateRange(period, startStr, endStr string, now time.Time) (time.Time, time.Time, error) {
+	if startStr == "" && endStr == "" {
+		end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+		if period == "weekly" {
+			return end.AddDate(0, 0, -6), end, nil
+		}
+		return end, end, nil
+	}
+	if startStr == "" || endStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start_date and end_date must be provided together")
+	}
+	start, err := time.Parse("2006-01-02", startStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid start_date format, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", endStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid end_date format, expected YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date must not be before start_date")
+	}
+	if end.Sub(start) > 366*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("Date range must not exceed one year")
+	}
+	return start, end, nil
+}
+
+// loadRevenueSummary 按广告平台汇总用户在区间内（按业务日期，含结束当天）的收益记录，金额按记录当天汇率换算为基础币种
+func loadRevenueSummary(db *gorm.DB, userID uint, start, end time.Time) ([]RevenueSummaryRow, error) {
+	var daily []struct {
+		AdPlatform string
+		Currency   string
+		RecordDate string
+		RevenueAmounts
+		OrderCount int64
+		AdCreation int64
+	}
+	err := db.Model(&models.EmployeeRevenue{}).
+		Select("ad_platform, currency, record_date, SUM(expenditure) as expenditure, SUM(revenue) as revenue, "+
+			"SUM(order_count) as order_count, SUM(ad_creation_count) as ad_creation, "+
+			"SUM(product_cost) as product_cost, SUM(platform_fee) as platform_fee, SUM(shipping_fee) as shipping_fee").
+		Where("user_id = ? AND record_date BETWEEN ? AND ?", userID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Group("ad_platform, currency, record_date").
+		Scan(&daily).Error
+	if err != nil {
+		return nil, err
+	}
+	conv, err := newCurrencyConverter(db, utils.RevenueBaseCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]RevenueSummaryRow, 0)
+	index := make(map[string]int)
+	for _, d := range daily {
+		amounts, err := conv.convertAmounts(d.RevenueAmounts, d.Currency, d.RecordDate)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[d.AdPlatform]
+		if !ok {
+			i = len(rows)
+			index[d.AdPlatform] = i
+			rows = append(rows, RevenueSummaryRow{AdPlatform: d.AdPlatform})
+		}
+		row := &rows[i]
+		row.Expenditure += amounts.Expenditure
+		row.Revenue += amounts.Revenue
+		row.ProductCost += amounts.ProductCost
+		row.PlatformFee += amounts.PlatformFee
+		row.ShippingFee += amounts.ShippingFee
+		row.OrderCount += d.OrderCount
+		row.AdCreation += d.AdCreation
+	}
+	for i := range rows {
+		rows[i].ROI = revenueROI(rows[i].Revenue, rows[i].Expenditure)
+		rows[i].RevenueProfit = models.ComputeRevenueProfit(rows[i].Revenue, rows[i].Expenditure,
+			rows[i].ProductCost, rows[i].PlatformFee, rows[i].ShippingFee)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Revenue > rows[j].Revenue })
+	return rows, nil
+}
+
+// sumRevenueRows 计算合计行
+func sumRevenueRows(rows []RevenueSummaryRow) RevenueSummaryRow {
+	total := RevenueSummaryRow{AdPlatform: "合计"}
+	for _, row := range rows {
+		total.Expenditure += row.Expenditure
+		total.Revenue += row.Revenue
+		total.OrderCount += row.OrderCount
+		total.AdCreation += row.AdCreation
+		total.ProductCost += row.ProductCost
+		total.PlatformFee += row.PlatformFee
+		total.ShippingFee += row.ShippingFee
+	}
+	total.ROI = revenueROI(total.Revenue, total.Expenditure)
+	total.RevenueProfit = models.ComputeRevenueProfit(total.Revenue, total.Expenditure,
+		total.ProductCost, total.PlatformFee, total.ShippingFee)
+	return total
+}
+
+// revenueROI 销售额 / 广告费，保留两位小数；没有广告费时为 0
+func revenueROI(revenue, expenditure models.Money) float64 {
+	return models.Ratio(revenue, expenditure, 2)
+}
+
+// renderRevenueTable 将收益汇总渲染为 Markdown 表格
+func renderRevenueTable(rows []RevenueSummaryRow, total RevenueSummaryRow) string {
+	if len(rows) == 0 {
+		return "（该时间段内没有收益记录）"
+	}
+	var b strings.Builder
+	b.WriteString("| 平台 | 广告支出 | 销售额 | ROI | 毛利 | 毛利率 | 订单数 | 上新数 |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	writeRow := func(row RevenueSummaryRow) {
+		fmt.Fprintf(&b, "| %s | %s | %s | %.2f | %s | %.2f%% | %d | %d |\n",
+			row.AdPlatform, row.Expenditure, row.Revenue, row.ROI, row.GrossProfit, row.Margin*100, row.OrderCount, row.AdCreation)
+	}
+	for _, row := range rows {
+		writeRow(row)
+	}
+	writeRow(total)
+	return b.String()
+}
+
+// mergeMissing 合并标题与正文中未填充的占位符（去重）
+func mergeMissing(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	merged := make([]string, 0)
+	for _, list := range lists {
+		for _, name := range list {
+			if !seen[name] {
+				seen[name] = true
+				merged = append(merged, name)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认区间按 now 所在时区的日期计算
+func TestTemplateDateRangeDefaults(t *testing.T) {
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati") // UTC+14
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC).In(kiritimati) // 当地 3 月 11 日 02:00
+	for period, want := range map[string]string{"daily": "2025-03-10 2025-03-10", "weekly": "2025-03-04 2025-03-10"} {
+		start, end, err := templateDateRange(period, "", "", now)
+		if got := start.Format("2006-01-02") + " " + end.Format("2006-01-02"); err != nil || got != want {
+			t.Errorf("%s: got %s, %v; want %s", period, got, err, want)
+		}
+	}
+}
+
+// 生成草稿时 {{date}} 与默认统计区间使用 revenue.timezone 的日期
+func TestCreateDraftFromTemplateRevenueTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Pacific/Kiritimati"); err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	setRevenueTimezone(t, "Pacific/Kiritimati")
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	template := models.BlogTemplate{Name: "daily", Period: "daily", Title: "{{date}}", Content: "{{start_date}} {{end_date}}", Category: "Report", CreatedBy: user.ID}
+	db.Create(&template)
+
+	today := time.Now().In(utils.RevenueLocation())
+	yesterday := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1).Format("2006-01-02")
+	w := performRequest(NewBlogTemplateController(db).CreateDraftFromTemplate, http.MethodPost, "/", "", user.ID, utils.RoleMarketer,
+		gin.Param{Key: "id", Value: strconv.Itoa(int(template.ID))})
+	var resp struct {
+		Blog models.Blog `json:"blog"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusCreated || resp.Blog.Title != today.Format("2006-01-02") || resp.Blog.Content != yesterday+" "+yesterday {
+		t.Errorf("status %d, title %q, content %q; want date %s and range %s", w.Code, resp.Blog.Title, resp.Blog.Content,
+			today.Format("2006-01-02"), yesterday)
+	}
+}
@@ -0,0 +1,542 @@
+package controllers
+
+import (
+	"archive/zip"
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// BlogTransferController 定义博客批量导入导出的接口
+type BlogTransferController interface {
+	ExportBlogs(ctx *gin.Context) // 导出为 Markdown（zip）或 JSON
+	ImportBlogs(ctx *gin.Context) // 从 Markdown / zip / JSON 导入
+}
+
+type blogTransferController struct {
+	db *gorm.DB
+}
+
+// NewBlogTransferController 创建一个新的 BlogTransferController
+func NewBlogTransferController(db *gorm.DB) BlogTransferController {
+	return &blogTransferController{db: db}
+}
+
+const (
+	maxImportItems    = 5000
+	maxImportFileSize = 5 << 20  // 单个 Markdown 文件上限
+	maxImportUpload   = 50 << 20 // 上传文件总大小上限
+)
+
+// BlogTransferItem 导入导出的一篇博客；Markdown 格式下除 Content 外的字段写在 front matter 中
+type BlogTransferItem struct {
+	Title     string     `yaml:"title" json:"title"`
+	Slug      string     `yaml:"slug,omitempty" json:"slug,omitempty"`
+	Category  string     `yaml:"category" json:"category"`
+	Tags      blogTags   `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Status    string     `yaml:"status" json:"status"`
+	Author    string     `yaml:"author,omitempty" json:"author,omitempty"` // 作者用户名（导入时也可以是邮箱）
+	CreatedAt *time.Time `yaml:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt *time.Time `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+	Content   string     `yaml:"-" json:"content"`
+}
+
+// blogTags 标签列表，导入时同时接受数组和逗号分隔的字符串
+type blogTags []string
+
+func (t *blogTags) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = list
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*t = splitTags(text)
+	return nil
+}
+
+func (t *blogTags) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = splitTags(node.Value)
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// splitTags 拆分逗号分隔的标签并去掉空白
+func splitTags(text string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(text, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// BlogImportResult 导入报告中每一篇的处理结果
+type BlogImportResult struct {
+	Source   string `json:"source"` // 文件名或 JSON 数组下标
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Action   string `json:"action"` // create / skip / error
+	Reason   string `json:"reason,omitempty"`
+	AuthorID uint   `json:"author_id,omitempty"`
+	BlogID   uint   `json:"blog_id,omitempty"`
+	Warning  string `json:"warning,omitempty"`
+}
+
+// blogExportRow 导出查询的结果行
+type blogExportRow struct {
+	ID             uint
+	Title          string
+	Content        string
+	Category       string
+	Tags           string
+	Status         string
+	Slug           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	AuthorUsername string
+}
+
+// ExportBlogs 导出博客：format=markdown（默认，zip 内每篇一个 .md 文件）或 format=json；
+// 支持与博客列表相同的筛选参数，管理员可导出全部，其他用户只能导出自己的文章
+func (c *blogTransferController) ExportBlogs(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	format := ctx.DefaultQuery("format", "markdown")
+	if format != "markdown" && format != "json" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected markdown or json"})
+		return
+	}
+
+	f, err := parseBlogFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if role > utils.RoleAdmin {
+		f.AuthorID = userID
+	}
+
+	rows, err := f.Order(f.Apply(c.db.Model(&models.Blog{}).
+		Select("blog.id, blog.title, blog.content, blog.category, blog.tags, blog.status, blog.slug, " +
+			"blog.created_at, blog.updated_at, users.username as author_username").
+		Joins("LEFT JOIN users ON users.id = blog.author_id"))).
+		Rows()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
+		return
+	}
+	defer rows.Close()
+
+	stamp := time.Now().Format("20060102-150405")
+	if format == "json" {
+		ctx.Header("Content-Type", "application/json; charset=utf-8")
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="blogs-%s.json"`, stamp))
+	} else {
+		ctx.Header("Content-Type", "application/zip")
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="blogs-%s.zip"`, stamp))
+	}
+	ctx.Status(http.StatusOK)
+
+	// 逐行写出，避免一次性把所有文章读入内存
+	var zw *zip.Writer
+	if format == "markdown" {
+		zw = zip.NewWriter(ctx.Writer)
+		defer zw.Close()
+	} else {
+		ctx.Writer.WriteString("[")
+	}
+	usedNames := make(map[string]bool)
+	first := true
+	for rows.Next() {
+		var row blogExportRow
+		if err := c.db.ScanRows(rows, &row); err != nil {
+			ctx.Error(err)
+			return
+		}
+		item := exportItem(row)
+
+		if format == "json" {
+			data, _ := json.Marshal(item)
+			if !first {
+				ctx.Writer.WriteString(",")
+			}
+			ctx.Writer.Write(data)
+			first = false
+			continue
+		}
+
+		data, err := utils.MarshalFrontMatter(item, item.Content)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     exportFileName(row, item.Slug, usedNames),
+			Method:   zip.Deflate,
+			Modified: row.UpdatedAt,
+		})
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		w.Write(data)
+	}
+	if format == "json" {
+		ctx.Writer.WriteString("]")
+	}
+}
+
+// exportItem 将查询结果转换为导出格式；没有 slug 的文章按标题生成
+func exportItem(row blogExportRow) BlogTransferItem {
+	slug := row.Slug
+	if slug == "" {
+		slug = utils.Slugify(row.Title)
+	}
+	createdAt, updatedAt := row.CreatedAt.UTC(), row.UpdatedAt.UTC()
+	return BlogTransferItem{
+		Title:     row.Title,
+		Slug:      slug,
+		Category:  row.Category,
+		Tags:      splitTags(row.Tags),
+		Status:    row.Status,
+		Author:    row.AuthorUsername,
+		CreatedAt: &createdAt,
+		UpdatedAt: &updatedAt,
+		Content:   row.Content,
+	}
+}
+
+// exportFileName 生成 zip 内的文件名（日期-slug.md），重名时追加文章ID
+func exportFileName(row blogExportRow, slug string, used map[string]bool) string {
+	if slug == "" {
+		slug = "post-" + strconv.Itoa(int(row.ID))
+	}
+	name := row.CreatedAt.Format("2006-01-02") + "-" + slug + ".md"
+	if used[name] {
+		name = row.CreatedAt.Format("2006-01-02") + "-" + slug + "-" + strconv.Itoa(int(row.ID)) + ".md"
+	}
+	used[name] = true
+	return name
+}
+
+// ImportBlogs 导入博客（管理员）。来源可以是 JSON 数组请求体，或 multipart 上传的 file（.zip / .md / .json）。
+// 参数：dry_run=true 只返回报告不写库；dedup=slug（默认，无 slug 时按标题）/title/none；
+// author_map 为 JSON 对象（原作者 → 本站用户名）；default_author 为找不到作者时使用的用户名（默认导入人）
+func (c *blogTransferController) ImportBlogs(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dry_run", ctx.PostForm("dry_run")))
+	dedup := ctx.DefaultQuery("dedup", ctx.DefaultPostForm("dedup", "slug"))
+	if dedup != "slug" && dedup != "title" && dedup != "none" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dedup, expected slug, title or none"})
+		return
+	}
+
+	items, sources, err := readImportItems(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(items) > maxImportItems {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d posts can be imported at once", maxImportItems)})
+		return
+	}
+
+	authors, err := newAuthorResolver(c.db, userID, ctx.DefaultQuery("author_map", ctx.PostForm("author_map")),
+		ctx.DefaultQuery("default_author", ctx.PostForm("default_author")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]BlogImportResult, len(items))
+	summary := map[string]int{"create": 0, "skip": 0, "error": 0}
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[string]bool)
+		for i, item := range items {
+			result, blog := planImportItem(tx, item, dedup, seen, authors)
+			result.Source = sources[i]
+			if blog != nil && !dryRun {
+				if err := createBlogWithOwner(tx, blog, userID, gin.H{"title": blog.Title, "status": blog.Status, "source": "import"}); err != nil {
+					return err
+				}
+				result.BlogID = blog.ID
+			}
+			results[i] = result
+			summary[result.Action]++
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import blogs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"dry_run": dryRun,
+		"total":   len(items),
+		"created": summary["create"],
+		"skipped": summary["skip"],
+		"failed":  summary["error"],
+		"items":   results,
+	})
+}
+
+// readImportItems 读取请求中的待导入文章，返回文章及其来源说明
+func readImportItems(ctx *gin.Context) ([]BlogTransferItem, []string, error) {
+	if strings.HasPrefix(ctx.ContentType(), "application/json") {
+		data, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxImportUpload))
+		if err != nil {
+			return nil, nil, err
+		}
+		return parseImportJSON(data)
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, nil, errors.New("file is required (zip, md or json), or send a JSON array body")
+	}
+	if fileHeader.Size > maxImportUpload {
+		return nil, nil, errors.New("Uploaded file is too large")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch strings.ToLower(path.Ext(fileHeader.Filename)) {
+	case ".json":
+		return parseImportJSON(data)
+	case ".md", ".markdown":
+		item, err := parseImportMarkdown(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", fileHeader.Filename, err)
+		}
+		return []BlogTransferItem{item}, []string{fileHeader.Filename}, nil
+	case ".zip":
+		return parseImportZip(data)
+	default:
+		return nil, nil, errors.New("Unsupported file type, expected .zip, .md or .json")
+	}
+}
+
+// parseImportJSON 解析 JSON 数组
+func parseImportJSON(data []byte) ([]BlogTransferItem, []string, error) {
+	var items []BlogTransferItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, nil, fmt.Errorf("Invalid JSON: %v", err)
+	}
+	sources := make([]string, len(items))
+	for i := range items {
+		sources[i] = "[" + strconv.Itoa(i) + "]"
+	}
+	return items, sources, nil
+}
+
+// parseImportMarkdown 解析带 front matter 的 Markdown 文件
+func parseImportMarkdown(data []byte) (BlogTransferItem, error) {
+	var item BlogTransferItem
+	body, err := utils.ParseFrontMatter(data, &item)
+	if err != nil {
+		return item, err
+	}
+	item.Content = body
+	return item, nil
+}
+
+// parseImportZip 解析 zip 中的所有 Markdown 文件（按文件名排序，忽略其他文件和目录）
+func parseImportZip(data []byte) ([]BlogTransferItem, []string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid zip file: %v", err)
+	}
+
+	items := make([]BlogTransferItem, 0)
+	sources := make([]string, 0)
+	for _, file := range zr.File {
+		ext := strings.ToLower(path.Ext(file.Name))
+		if file.FileInfo().IsDir() || (ext != ".md" && ext != ".markdown") || strings.HasPrefix(path.Base(file.Name), ".") {
+			continue
+		}
+		if len(items) >= maxImportItems {
+			return nil, nil, fmt.Errorf("At most %d posts can be imported at once", maxImportItems)
+		}
+		if file.UncompressedSize64 > maxImportFileSize {
+			return nil, nil, fmt.Errorf("%s: file is too large", file.Name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxImportFileSize))
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", file.Name, err)
+		}
+		item, err := parseImportMarkdown(content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", file.Name, err)
+		}
+		items = append(items, item)
+		sources = append(sources, file.Name)
+	}
+	return items, sources, nil
+}
+
+// planImportItem 校验一篇待导入文章并决定创建、跳过还是报错；需要创建时返回构造好的 Blog
+func planImportItem(tx *gorm.DB, item BlogTransferItem, dedup string, seen map[string]bool, authors *authorResolver) (BlogImportResult, *models.Blog) {
+	item.Title = strings.TrimSpace(item.Title)
+	result := BlogImportResult{Title: item.Title, Slug: strings.TrimSpace(item.Slug)}
+	if result.Slug == "" {
+		result.Slug = utils.Slugify(item.Title)
+	}
+
+	if item.Title == "" {
+		result.Action, result.Reason = "error", "title is required"
+		return result, nil
+	}
+	if item.Status == "" {
+		item.Status = models.BlogStatusDraft
+	}
+	if !blogStatuses[item.Status] {
+		result.Action, result.Reason = "error", "invalid status "+item.Status
+		return result, nil
+	}
+
+	// 去重：同一批次内以及与已有文章比较
+	if dedup != "none" {
+		// 按 slug 去重时，没有 slug 的已有文章按标题比较
+		key, column := "title:"+item.Title, "title"
+		query := tx.Where("title = ?", item.Title)
+		if dedup == "slug" && result.Slug != "" {
+			key, column = "slug:"+result.Slug, "slug"
+			query = tx.Where("slug = ? OR ((slug IS NULL OR slug = '') AND title = ?)", result.Slug, item.Title)
+		}
+		if seen[key] {
+			result.Action, result.Reason = "skip", "duplicate "+column+" in import"
+			return result, nil
+		}
+		seen[key] = true
+
+		var existing models.Blog
+		err := query.Select("id").First(&existing).Error
+		if err == nil {
+			result.Action, result.Reason, result.BlogID = "skip", column+" already exists", existing.ID
+			return result, nil
+		}
+	}
+
+	authorID, warning := authors.resolve(tx, item.Author)
+	result.AuthorID, result.Warning = authorID, warning
+
+	blog := &models.Blog{
+		UserID:   authorID,
+		AuthorID: authorID,
+		Title:    item.Title,
+		Content:  item.Content,
+		Category: strings.TrimSpace(item.Category),
+		Tags:     strings.Join(item.Tags, ","),
+		Status:   item.Status,
+		Slug:     result.Slug,
+	}
+	// 保留原始时间（gorm 只在零值时自动填充）
+	if item.CreatedAt != nil {
+		blog.CreatedAt = *item.CreatedAt
+	}
+	if item.UpdatedAt != nil {
+		blog.UpdatedAt = *item.UpdatedAt
+	} else if item.CreatedAt != nil {
+		blog.UpdatedAt = *item.CreatedAt
+	}
+	result.Action = "create"
+	return result, blog
+}
+
+// authorResolver 将导入数据中的作者映射为本站用户
+type authorResolver struct {
+	mapping   map[string]string
+	defaultID uint
+	cache     map[string]uint
+}
+
+// newAuthorResolver 解析 author_map 与 default_author；default_author 为空时使用导入人
+func newAuthorResolver(db *gorm.DB, importerID uint, mappingJSON, defaultAuthor string) (*authorResolver, error) {
+	r := &authorResolver{mapping: map[string]string{}, defaultID: importerID, cache: map[string]uint{}}
+	if mappingJSON != "" {
+		if err := json.Unmarshal([]byte(mappingJSON), &r.mapping); err != nil {
+			return nil, errors.New("Invalid author_map, expected a JSON object")
+		}
+	}
+	if defaultAuthor != "" {
+		id, ok := r.lookup(db, defaultAuthor)
+		if !ok {
+			return nil, fmt.Errorf("default_author %q not found", defaultAuthor)
+		}
+		r.defaultID = id
+	}
+	return r, nil
+}
+
+// lookup 按用户名或邮箱查找用户
+func (r *authorResolver) lookup(db *gorm.DB, name string) (uint, bool) {
+	if id, ok := r.cache[name]; ok {
+		return id, id != 0
+	}
+	var user models.Users
+	err := db.Select("id").Where("username = ? OR email = ?", name, name).First(&user).Error
+	r.cache[name] = user.ID
+	return user.ID, err == nil
+}
+
+// resolve 返回作者对应的用户ID；找不到时使用默认作者并给出提示
+func (r *authorResolver) resolve(db *gorm.DB, author string) (uint, string) {
+	author = strings.TrimSpace(author)
+	if mapped, ok := r.mapping[author]; ok {
+		author = mapped
+	}
+	if author == "" {
+		return r.defaultID, ""
+	}
+	if id, ok := r.lookup(db, author); ok {
+		return id, ""
+	}
+	return r.defaultID, fmt.Sprintf("author %q not found, assigned to default author", author)
+}
@@ -3,10 +3,17 @@ package controllers
 import (
 	"blog/config"
 	"blog/models"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
+	"blog/utils"
+	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 // Blog 博客表
@@ -37,3 +44,123 @@ func TestInsertBlogs(t *testing.T) {
 		}
 	}
 }
+
+// 未发布的博客只有作者、协作者和管理员可以查看，列表中也只返回这些博客
+func TestBlogVisibility(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	viewer := createTestUser(t, db, "viewer", utils.RoleUser)
+	stranger := createTestUser(t, db, "stranger", utils.RoleUser)
+	published := createTestBlog(t, db, author.ID, "published")
+	draft := createTestBlog(t, db, author.ID, "draft")
+	db.Model(&draft).UpdateColumn("status", models.BlogStatusDraft)
+	inReview := createTestBlog(t, db, stranger.ID, "in review")
+	db.Model(&inReview).UpdateColumn("status", models.BlogStatusInReview)
+	db.Create(&models.BlogCollaborator{BlogID: draft.ID, UserID: viewer.ID, Role: models.CollaboratorViewer})
+	c := NewBlogController(db, nil)
+
+	get := func(blog models.Blog, user models.Users) int {
+		return performRequest(c.GetBlogByID, http.MethodGet, "/", "", user.ID, user.Role,
+			gin.Param{Key: "id", Value: strconv.Itoa(int(blog.ID))}).Code
+	}
+	for _, tc := range []struct {
+		blog models.Blog
+		user models.Users
+		want int
+	}{
+		{published, stranger, http.StatusOK},
+		{draft, stranger, http.StatusNotFound},
+		{draft, author, http.StatusOK},
+		{draft, viewer, http.StatusOK},
+		{draft, admin, http.StatusOK},
+		{inReview, author, http.StatusNotFound},
+	} {
+		if code := get(tc.blog, tc.user); code != tc.want {
+			t.Errorf("%s reading %q: status %d, want %d", tc.user.Username, tc.blog.Title, code, tc.want)
+		}
+	}
+
+	list := func(query string, user models.Users) []string {
+		w := performRequest(c.GetBlogsPaginated, http.MethodGet, "/?sort=oldest&"+query, "", user.ID, user.Role)
+		var resp struct {
+			Total int64            `json:"total"`
+			Data  []BlogWithAuthor `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		titles := make([]string, 0, len(resp.Data))
+		for _, blog := range resp.Data {
+			titles = append(titles, blog.Title)
+		}
+		if int(resp.Total) != len(titles) {
+			t.Errorf("%s listing %q: total %d, got %d blogs", user.Username, query, resp.Total, len(titles))
+		}
+		return titles
+	}
+	for _, tc := range []struct {
+		query string
+		user  models.Users
+		want  string
+	}{
+		{"", stranger, "[published in review]"},
+		{"status=draft", stranger, "[]"},
+		{"", viewer, "[published draft]"},
+		{"status=draft", author, "[draft]"},
+		{"", admin, "[published draft in review]"},
+	} {
+		if got := fmt.Sprint(list(tc.query, tc.user)); got != tc.want {
+			t.Errorf("%s listing %q: got %s, want %s", tc.user.Username, tc.query, got, tc.want)
+		}
+	}
+}
+
+// 只有首次发布时通知关注者：撤回后再次发布、审核后发布都不重复通知
+func TestNotifyFollowersOnFirstPublish(t *testing.T) {
+	setReviewCategories(t, "Finance")
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	follower := createTestUser(t, db, "follower", utils.RoleUser)
+	db.Create(&models.UserFollow{FollowerID: follower.ID, FolloweeID: author.ID})
+	c := NewBlogController(db, nil)
+
+	notices := func() int64 {
+		var n int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", follower.ID, "new_post").Count(&n)
+		return n
+	}
+	setStatus := func(blog models.Blog, status string) {
+		t.Helper()
+		if code, stored := updateBlog(t, c, blog, `{"status":"`+status+`"}`); code != http.StatusOK || stored.Status != status {
+			t.Fatalf("set status %s: status %d, blog status %s", status, code, stored.Status)
+		}
+	}
+
+	draft := createTestBlog(t, db, author.ID, "draft")
+	db.Model(&draft).UpdateColumn("status", models.BlogStatusDraft)
+	setStatus(draft, models.BlogStatusPublished)
+	var stored models.Blog
+	db.First(&stored, draft.ID)
+	if notices() != 1 || stored.PublishedAt == nil {
+		t.Fatalf("first publish: %d notifications, published_at %v; want 1 and set", notices(), stored.PublishedAt)
+	}
+	firstPublishedAt := *stored.PublishedAt
+	setStatus(draft, models.BlogStatusDraft)
+	setStatus(draft, models.BlogStatusPublished)
+	db.First(&stored, draft.ID)
+	if notices() != 1 || !stored.PublishedAt.Equal(firstPublishedAt) {
+		t.Errorf("republish: %d notifications, published_at %v; want 1 and %v", notices(), stored.PublishedAt, firstPublishedAt)
+	}
+
+	// 审核分类：发布后修改内容回到审核，审核通过后再次发布不重复通知
+	reviewed := createTestBlog(t, db, author.ID, "reviewed")
+	db.Model(&reviewed).UpdateColumns(map[string]interface{}{"status": models.BlogStatusApproved, "category": "Finance"})
+	setStatus(reviewed, models.BlogStatusPublished)
+	if code, _ := updateBlog(t, c, reviewed, `{"content":"edited"}`); code != http.StatusOK {
+		t.Fatalf("edit: status %d", code)
+	}
+	db.Model(&reviewed).UpdateColumn("status", models.BlogStatusApproved)
+	setStatus(reviewed, models.BlogStatusPublished)
+	if notices() != 2 {
+		t.Errorf("publish after review cycle: %d notifications, want 2", notices())
+	}
+}
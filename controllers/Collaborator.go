@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CollaboratorController 定义博客协作者与修改记录的接口
+type CollaboratorController interface {
+	ListCollaborators(ctx *gin.Context)  // 博客的协作者列表
+	SetCollaborator(ctx *gin.Context)    // 添加协作者或修改其角色
+	RemoveCollaborator(ctx *gin.Context) // 移除协作者
+	ListBlogAudit(ctx *gin.Context)      // 博客的修改记录
+}
+
+type collaboratorController struct {
+	db *gorm.DB
+}
+
+// NewCollaboratorController 创建一个新的 CollaboratorController
+func NewCollaboratorController(db *gorm.DB) CollaboratorController {
+	return &collaboratorController{db: db}
+}
+
+// CollaboratorInput 设置协作者角色的请求体
+type CollaboratorInput struct {
+	Role string `json:"role" binding:"required"` // editor 或 viewer
+}
+
+// CollaboratorInfo 协作者列表中的用户信息
+type CollaboratorInfo struct {
+	UserID   uint   `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Role     string `json:"role"`
+	AddedAt  string `json:"added_at"`
+}
+
+// BlogAuditEntry 修改记录及操作人昵称
+type BlogAuditEntry struct {
+	ID        uint            `json:"id"`
+	BlogID    uint            `json:"blog_id"`
+	UserID    uint            `json:"user_id"`
+	Nickname  string          `json:"nickname"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes" gorm:"-"`
+	CreatedAt string          `json:"created_at"`
+
+	RawChanges string    `json:"-" gorm:"column:changes"`
+	CursorTime time.Time `json:"-" gorm:"column:cursor_time"`
+}
+
+// blogRoleRank 协作者角色的权限高低，用于比较
+var blogRoleRank = map[string]int{
+	models.CollaboratorViewer: 1,
+	models.CollaboratorEditor: 2,
+	models.CollaboratorOwner:  3,
+}
+
+// blogCollaboratorRole 返回用户在博客上的有效角色：管理员视同所有者，非协作者返回空字符串
+func blogCollaboratorRole(db *gorm.DB, blog *models.Blog, userID uint, role int) (string, error) {
+	if role <= utils.RoleAdmin || blog.AuthorID == userID {
+		return models.CollaboratorOwner, nil
+	}
+	var collaborator models.BlogCollaborator
+	err := db.Where("blog_id = ? AND user_id = ?", blog.ID, userID).First(&collaborator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return collaborator.Role, err
+}
+
+// canViewBlog 博客对用户是否可见：已发布的所有人可见，其他状态只有作者、协作者和管理员可见
+func canViewBlog(db *gorm.DB, blog *models.Blog, userID uint, role int) (bool, error) {
+	if blog.Status == "published" {
+		return true, nil
+	}
+	blogRole, err := blogCollaboratorRole(db, blog, userID, role)
+	return blogRole != "", err
+}
+
+// visibleBlogs 限定为用户可见的博客（与 canViewBlog 一致），作用于 blog 表
+func visibleBlogs(db *gorm.DB, userID uint, role int) *gorm.DB {
+	if role <= utils.RoleAdmin {
+		return db
+	}
+	return db.Where("(blog.status = ? OR blog.author_id = ? OR blog.id IN (SELECT blog_id FROM blog_collaborators WHERE user_id = ?))",
+		models.BlogStatusPublished, userID, userID)
+}
+
+// authorizeBlog 查找博客并校验当前用户至少拥有 minRole 角色，失败时直接写入响应
+func authorizeBlog(ctx *gin.Context, db *gorm.DB, minRole string) (*models.Blog, uint, string, bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, 0, "", false
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	var blog models.Blog
+	if err := db.First(&blog, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return nil, 0, "", false
+	}
+
+	blogRole, err := blogCollaboratorRole(db, &blog, userID, role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission"})
+		return nil, 0, "", false
+	}
+	if blogRoleRank[blogRole] < blogRoleRank[minRole] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, 0, "", false
+	}
+	return &blog, userID, blogRole, true
+}
+
+// recordBlogAudit 写入一条修改记录，changes 序列化为 JSON
+func recordBlogAudit(tx *gorm.DB, blogID, userID uint, action string, changes interface{}) error {
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.BlogAuditLog{
+		BlogID:  blogID,
+		UserID:  userID,
+		Action:  action,
+		Changes: string(raw),
+	}).Error
+}
+
+// blogChanges 对比更新前后的可编辑字段（Updates 忽略零值，所以只比较非空字段）；正文只记录长度
+func blogChanges(old *models.Blog, update *models.Blog) map[string]interface{} {
+	changes := make(map[string]interface{})
+	diff := func(field, from, to string) {
+		if to != "" && to != from {
+			changes[field] = gin.H{"from": from, "to": to}
+		}
+	}
+	diff("title", old.Title, update.Title)
+	diff("category", old.Category, update.Category)
+	diff("tags", old.Tags, update.Tags)
+	diff("status", old.Status, update.Status)
+	if update.Content != "" && update.Content != old.Content {
+		changes["content"] = gin.H{"from_length": len([]rune(old.Content)), "to_length": len([]rune(update.Content))}
+	}
+	return changes
+}
+
+// ListCollaborators 获取博客的协作者（任一协作者或管理员可查看）
+func (c *collaboratorController) ListCollaborators(ctx *gin.Context) {
+	blog, _, _, ok := authorizeBlog(ctx, c.db, models.CollaboratorViewer)
+	if !ok {
+		return
+	}
+
+	collaborators := make([]CollaboratorInfo, 0)
+	if err := c.db.Table("blog_collaborators").
+		Select("blog_collaborators.user_id, users.nickname, users.avatar, blog_collaborators.role, blog_collaborators.created_at as added_at").
+		Joins("LEFT JOIN users ON users.id = blog_collaborators.user_id").
+		Where("blog_collaborators.blog_id = ?", blog.ID).
+		Order("blog_collaborators.created_at ASC").
+		Scan(&collaborators).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collaborators"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": collaborators})
+}
+
+// SetCollaborator 添加协作者或修改其角色（仅所有者或管理员）；所有者本人的角色不能修改
+func (c *collaboratorController) SetCollaborator(ctx *gin.Context) {
+	blog, operatorID, _, ok := authorizeBlog(ctx, c.db, models.CollaboratorOwner)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil || targetID < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if uint(targetID) == blog.AuthorID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change the owner's role"})
+		return
+	}
+
+	var input CollaboratorInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Role != models.CollaboratorEditor && input.Role != models.CollaboratorViewer {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role, expected editor or viewer"})
+		return
+	}
+
+	var user models.Users
+	if err := c.db.First(&user, targetID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	collaborator := models.BlogCollaborator{BlogID: blog.ID, UserID: user.ID, Role: input.Role}
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "blog_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).Create(&collaborator).Error; err != nil {
+			return err
+		}
+		return recordBlogAudit(tx, blog.ID, operatorID, "collaborator_set", gin.H{"user_id": user.ID, "role": input.Role})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set collaborator"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Collaborator saved", "user_id": user.ID, "role": input.Role})
+}
+
+// RemoveCollaborator 移除协作者（所有者或管理员可移除任何人，协作者也可以退出）
+func (c *collaboratorController) RemoveCollaborator(ctx *gin.Context) {
+	blog, operatorID, blogRole, ok := authorizeBlog(ctx, c.db, models.CollaboratorViewer)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil || targetID < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if uint(targetID) == blog.AuthorID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove the owner"})
+		return
+	}
+	if blogRole != models.CollaboratorOwner && uint(targetID) != operatorID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("blog_id = ? AND user_id = ?", blog.ID, targetID).Delete(&models.BlogCollaborator{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return recordBlogAudit(tx, blog.ID, operatorID, "collaborator_remove", gin.H{"user_id": targetID})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove collaborator"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Collaborator removed"})
+}
+
+// ListBlogAudit 获取博客的修改记录（所有者或管理员），按时间倒序分页
+func (c *collaboratorController) ListBlogAudit(ctx *gin.Context) {
+	blog, _, _, ok := authorizeBlog(ctx, c.db, models.CollaboratorOwner)
+	if !ok {
+		return
+	}
+
+	p, err := utils.ParsePagination(ctx, 20)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	query := c.db.Table("blog_audit_logs").Where("blog_audit_logs.blog_id = ?", blog.ID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit logs"})
+		return
+	}
+
+	var entries []BlogAuditEntry
+	if err := p.Apply(query.
+		Select("blog_audit_logs.id, blog_audit_logs.blog_id, blog_audit_logs.user_id, users.nickname, blog_audit_logs.action, "+
+			"blog_audit_logs.changes, blog_audit_logs.created_at, blog_audit_logs.created_at as cursor_time").
+		Joins("LEFT JOIN users ON users.id = blog_audit_logs.user_id"),
+		"blog_audit_logs.created_at", "blog_audit_logs.id").
+		Scan(&entries).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	nextCursor := ""
+	if len(entries) > p.Limit {
+		entries = entries[:p.Limit]
+		last := entries[len(entries)-1]
+		nextCursor = utils.EncodeCursor(last.CursorTime, last.ID)
+	}
+	if entries == nil {
+		entries = []BlogAuditEntry{}
+	}
+	for i := range entries {
+		entries[i].Changes = json.RawMessage(entries[i].RawChanges)
+	}
+
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = entries
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -49,9 +50,30 @@ func (c *commentController) CreateComment(ctx *gin.Context) {
 	}
 	// 使用 token 中的 user id，而不是请求体传入的
 	comment.UserID = userID
+	comment.ReactionCount = 0
 
-	// 创建留言记录
-	if err := c.db.Create(&comment).Error; err != nil {
+	// 创建留言记录，并在同一事务中更新博客的评论数与最近评论时间
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Blog{}).Where("id = ?", comment.BlogID).UpdateColumns(map[string]interface{}{
+			"comment_count":   gorm.Expr("comment_count + 1"),
+			"last_comment_at": comment.CreatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
@@ -131,14 +153,28 @@ func (c *commentController) DeleteComment(ctx *gin.Context) {
 		}
 	}
 
-	// 允许删除
-	if err := c.db.Delete(&models.Comment{}, id).Error; err != nil {
+	// 允许删除，同一事务中重新计算博客的评论数与最近评论时间
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Comment{}, comment.ID).Error; err != nil {
+			return err
+		}
+		return syncBlogCommentStats(tx.Where("id = ?", comment.BlogID))
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
 
+// syncBlogCommentStats 按 comments 表重新计算博客的评论数与最近评论时间（db 上的条件用于限定博客范围）
+func syncBlogCommentStats(db *gorm.DB) error {
+	return db.Model(&models.Blog{}).UpdateColumns(map[string]interface{}{
+		"comment_count":   gorm.Expr("(SELECT COUNT(*) FROM comments WHERE comments.blog_id = blog.id AND comments.deleted_at IS NULL)"),
+		"last_comment_at": gorm.Expr("(SELECT MAX(comments.created_at) FROM comments WHERE comments.blog_id = blog.id AND comments.deleted_at IS NULL)"),
+	}).Error
+}
+
 // ListComments 分页查询留言（全表查询）
 func (c *commentController) ListComments(ctx *gin.Context) {
 	pageStr := ctx.DefaultQuery("page", "1")
@@ -164,7 +200,8 @@ func (c *commentController) ListComments(ctx *gin.Context) {
 // 定义一个新的结构体，用于返回评论和昵称
 type CommentWithUser struct {
 	models.Comment
-	Nickname string `json:"nickname"`
+	Nickname  string           `json:"nickname"`
+	Reactions map[string]int64 `json:"reactions" gorm:"-"` // 按表情分组的回应数
 }
 
 // ListCommentsByBlog 根据博客ID查询留言，支持分页查询，返回评论及对应的用户昵称
@@ -200,5 +237,22 @@ func (c *commentController) ListCommentsByBlog(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments for the blog"})
 		return
 	}
+
+	// 一次查询补齐本页评论的表情回应分布
+	commentIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+	}
+	reactions, err := loadReactionCounts(c.db, commentIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment reactions"})
+		return
+	}
+	for i := range comments {
+		comments[i].Reactions = reactions[comments[i].ID]
+		if comments[i].Reactions == nil {
+			comments[i].Reactions = map[string]int64{}
+		}
+	}
 	ctx.JSON(http.StatusOK, comments)
 }
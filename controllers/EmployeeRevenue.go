@@ -2,9 +2,9 @@ package controllers
 
 import (
 	"blog/models"
+	"blog/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -19,6 +19,17 @@ type EmployeeRevenueController interface {
 	DeleteEmployeeRevenue(ctx *gin.Context)
 	ListEmployeeRevenue(ctx *gin.Context)
 	GetUserEmployeeRevenueList(ctx *gin.Context)
+	GetRevenueTimeseries(ctx *gin.Context)
+	GetRevenueReport(ctx *gin.Context)
+	ExportEmployeeRevenue(ctx *gin.Context)
+	ExportUserEmployeeRevenue(ctx *gin.Context)
+	ExportRevenueReport(ctx *gin.Context)
+	ImportEmployeeRevenue(ctx *gin.Context)
+	UpsertEmployeeRevenue(ctx *gin.Context)
+	ListRevenueDuplicates(ctx *gin.Context)
+	MergeRevenueDuplicates(ctx *gin.Context)
+	DeleteRevenueDuplicates(ctx *gin.Context)
+	GetRevenueLeaderboard(ctx *gin.Context)
 }
 
 type employeeRevenueController struct {
@@ -32,16 +43,28 @@ func NewEmployeeRevenueController(db *gorm.DB) EmployeeRevenueController {
 
 // EmployeeRevenueInput 用于接收前端传入的 JSON 数据
 type EmployeeRevenueInput struct {
-	AdPlatform        string  `json:"ad_platform"`
-	ProductCategories string  `json:"product_categories"`
-	AdType            string  `json:"ad_type"`
-	Region            string  `json:"region"`
-	Expenditure       float64 `json:"expenditure"`
-	OrderCount        int     `json:"order_count"`
-	AdCreationCount   int     `json:"ad_creation_count"`
-	Revenue           float64 `json:"revenue"`
-	RecordTimeStr     string  `json:"record_time"`
-	Remark            string  `json:"remark"`
+	AdPlatform        string       `json:"ad_platform"`
+	ProductCategories string       `json:"product_categories"`
+	AdType            string       `json:"ad_type"`
+	Region            string       `json:"region"`
+	Expenditure       models.Money `json:"expenditure"`
+	OrderCount        int          `json:"order_count"`
+	AdCreationCount   int          `json:"ad_creation_count"`
+	Revenue           models.Money `json:"revenue"`
+	ProductCost       models.Money `json:"product_cost"`
+	PlatformFee       models.Money `json:"platform_fee"`
+	ShippingFee       models.Money `json:"shipping_fee"`
+	Currency          string       `json:"currency"` // 金额的币种，新建时默认基础币种，修改时默认不变
+	RecordTimeStr     string       `json:"record_time"`
+	Remark            string       `json:"remark"`
+}
+
+// revenueInputCurrency 规范化请求中的币种，为空时使用 fallback
+func revenueInputCurrency(value, fallback string) (string, bool) {
+	if strings.TrimSpace(value) == "" {
+		return fallback, true
+	}
+	return models.NormalizeCurrency(value)
 }
 
 // CreateEmployeeRevenue ✅ CreateEmployeeRevenue 创建员工收益统计记录
@@ -62,8 +85,16 @@ func (c *employeeRevenueController) CreateEmployeeRevenue(ctx *gin.Context) {
 		OrderCount:        input.OrderCount,
 		AdCreationCount:   input.AdCreationCount,
 		Revenue:           input.Revenue,
+		ProductCost:       input.ProductCost,
+		PlatformFee:       input.PlatformFee,
+		ShippingFee:       input.ShippingFee,
 		Remark:            input.Remark,
 	}
+	var ok bool
+	if revenue.Currency, ok = revenueInputCurrency(input.Currency, utils.RevenueBaseCurrency()); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected an ISO 4217 code such as USD"})
+		return
+	}
 
 	// 处理时间格式
 	if input.RecordTimeStr != "" {
@@ -89,19 +120,36 @@ func (c *employeeRevenueController) CreateEmployeeRevenue(ctx *gin.Context) {
 		return
 	}
 	revenue.UserID = userID
+	revenue.RecordDate = revenueRecordDate(revenue.RecordTime)
 
 	// 计算 ROI（销售额 / 广告费）
-	if revenue.Expenditure != 0 {
-		revenue.ROI = revenue.Revenue / revenue.Expenditure
-	} else {
-		revenue.ROI = 0
+	revenue.ROI = revenueROI(revenue.Revenue, revenue.Expenditure)
+
+	// 同一员工、日期、平台、广告类型、地区只能有一条记录：检查与插入在同一事务中完成，
+	// 并发写入同一唯一键时由唯一索引拒绝，同样返回 409
+	var existingIDs []uint
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		ids, err := findRevenueByNaturalKey(tx, &revenue, 0)
+		if err != nil || len(ids) > 0 {
+			existingIDs = ids
+			return err
+		}
+		return tx.Create(&revenue).Error
+	})
+	if err != nil && isUniqueViolation(err) {
+		existingIDs, err = findRevenueByNaturalKey(c.db, &revenue, 0)
 	}
-
-	// 插入记录到数据库
-	if err := c.db.Create(&revenue).Error; err != nil {
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create record"})
 		return
 	}
+	if len(existingIDs) > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":       "A record for this date, platform, ad type and region already exists, use PUT to update it",
+			"existing_id": existingIDs[0],
+		})
+		return
+	}
 
 	ctx.JSON(http.StatusCreated, revenue)
 }
@@ -157,7 +205,15 @@ func (c *employeeRevenueController) UpdateEmployeeRevenue(ctx *gin.Context) {
 	revenue.OrderCount = input.OrderCount
 	revenue.AdCreationCount = input.AdCreationCount
 	revenue.Revenue = input.Revenue
+	revenue.ProductCost = input.ProductCost
+	revenue.PlatformFee = input.PlatformFee
+	revenue.ShippingFee = input.ShippingFee
 	revenue.Remark = input.Remark
+	var ok bool
+	if revenue.Currency, ok = revenueInputCurrency(input.Currency, revenue.Currency); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected an ISO 4217 code such as USD"})
+		return
+	}
 
 	// 处理时间字段：先解析前端传入的 RecordTimeStr（字段名为 "record_time"）
 	if input.RecordTimeStr != "" {
@@ -168,14 +224,23 @@ func (c *employeeRevenueController) UpdateEmployeeRevenue(ctx *gin.Context) {
 		}
 		revenue.RecordTime = parsedTime
 	}
+	revenue.RecordDate = revenueRecordDate(revenue.RecordTime)
 
-	// 重新计算 ROI（销售额 / 广告费）
-	if revenue.Expenditure != 0 {
-		revenue.ROI = revenue.Revenue / revenue.Expenditure
-	} else {
-		revenue.ROI = 0
+	// 修改后不能与其他记录的唯一键重复
+	if ids, err := findRevenueByNaturalKey(c.db, &revenue, revenue.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record"})
+		return
+	} else if len(ids) > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":       "Another record for this date, platform, ad type and region already exists",
+			"existing_id": ids[0],
+		})
+		return
 	}
 
+	// 重新计算 ROI（销售额 / 广告费）
+	revenue.ROI = revenueROI(revenue.Revenue, revenue.Expenditure)
+
 	// 更新数据库记录
 	if err := c.db.Save(&revenue).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record"})
@@ -250,7 +315,7 @@ func (c *employeeRevenueController) GetUserEmployeeRevenueList(ctx *gin.Context)
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate format"})
 			return
 		}
-		query = query.Where("record_time BETWEEN ? AND ?", startDate, endDate)
+		query = query.Where("record_date BETWEEN ? AND ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	}
 
 	// 获取数据
@@ -264,11 +329,9 @@ func (c *employeeRevenueController) GetUserEmployeeRevenueList(ctx *gin.Context)
 	var totalCount int64
 	query.Count(&totalCount)
 
-	// 对返回的数值字段进行四舍五入（保留两位小数）
+	// 金额以分存储无需取整；ROI 按金额重新计算（保留两位小数）
 	for i := range revenues {
-		revenues[i].Revenue = math.Round(revenues[i].Revenue*100) / 100
-		revenues[i].Expenditure = math.Round(revenues[i].Expenditure*100) / 100
-		revenues[i].ROI = math.Round(revenues[i].ROI*100) / 100
+		revenues[i].ROI = revenueROI(revenues[i].Revenue, revenues[i].Expenditure)
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -280,45 +343,28 @@ func (c *employeeRevenueController) GetUserEmployeeRevenueList(ctx *gin.Context)
 	})
 }
 
+// parseFlexibleTime 解析前端传入的记录时间。带 Z 或时区偏移（如 +08:00）的按其时区解析；
+// 只有日期或不带时区的时间按 revenue.timezone 解释，避免业务日期被记到前一天或后一天
 func parseFlexibleTime(input string) (time.Time, error) {
-	var parsedTime time.Time
-	var err error
+	// ✅ 兼容 T 和空格
+	input = strings.Replace(strings.TrimSpace(input), "T", " ", 1)
 
-	// 定义支持的时间格式
-	formats := []string{
-		time.RFC3339,               // "2006-01-02T15:04:05Z07:00"
-		"2006-01-02T15:04:05Z",     // "2025-03-10T16:00:00Z"
-		"2006-01-02T15:04:05.000Z", // "2025-03-10T16:00:00.000Z"
-		"2006-01-02T15:04",         // "2025-03-10T16:08"
-		"2006-01-02 15:04:05",      // "2025-03-10 16:08:00"
-		"2006-01-02 15:04",         // "2025-03-10 16:08"
-		"2006-01-02",               // "2025-03-10"
-	}
-
-	// ✅ 兼容 T 和空格的替换
-	if strings.Contains(input, "T") {
-		input = strings.Replace(input, "T", " ", 1)
-	}
-
-	// ✅ 去除 `Z` 标识符（表示 UTC）
-	input = strings.TrimSuffix(input, "Z")
-
-	// ✅ 自动补全时间格式（如果是日期就加时间）
-	if len(input) == 10 { // 格式为 `2025-03-10`
-		input += " 00:00:00"
-	} else if len(input) == 16 { // 格式为 `2025-03-10 16:08`
-		input += ":00"
+	// 带时区："2025-03-10 16:00:00Z"、"2025-03-10 16:00:00.000+08:00"、"2025-03-10 16:08Z"
+	for _, layout := range []string{"2006-01-02 15:04:05Z07:00", "2006-01-02 15:04Z07:00"} {
+		if t, err := time.Parse(layout, input); err == nil {
+			return t, nil
+		}
 	}
 
-	// 尝试按不同格式解析
-	for _, format := range formats {
-		parsedTime, err = time.Parse(format, input)
-		if err == nil {
-			return parsedTime, nil
+	// 不带时区："2025-03-10 16:08:00"、"2025-03-10 16:08"、"2025-03-10"
+	var err error
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, input, utils.RevenueLocation()); err == nil {
+			return t, nil
 		}
 	}
-
-	return parsedTime, err
+	return time.Time{}, err
 }
 func (c *employeeRevenueController) ListEmployeeRevenue(ctx *gin.Context) {
 	// 获取查询参数
@@ -348,57 +394,110 @@ func (c *employeeRevenueController) ListEmployeeRevenue(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate format"})
 			return
 		}
-		// 调整结束日期到当天最后一刻
-		endDate = endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
-		query = query.Where("employee_revenue.record_time BETWEEN ? AND ?", startDate, endDate)
+		// 按业务日期筛选（含结束当天）
+		query = query.Where("employee_revenue.record_date BETWEEN ? AND ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	}
 
 	// 定义聚合结果结构（每个员工一条记录）
 	type AggregatedResult struct {
-		UserID               int     `json:"user_id"`
-		Nickname             string  `json:"nickname"`
-		Avatar               string  `json:"avatar"`
-		TotalRevenue         float64 `json:"total_revenue"`
-		TotalExpenditure     float64 `json:"total_expenditure"`
-		TotalOrderCount      int64   `json:"total_order_count"`
-		TotalAdCreationCount int64   `json:"total_ad_creation_count"`
-		AverageROI           float64 `json:"average_roi"`
+		UserID               int          `json:"user_id"`
+		Nickname             string       `json:"nickname"`
+		Avatar               string       `json:"avatar"`
+		TotalRevenue         models.Money `json:"total_revenue"`
+		TotalExpenditure     models.Money `json:"total_expenditure"`
+		TotalOrderCount      int64        `json:"total_order_count"`
+		TotalAdCreationCount int64        `json:"total_ad_creation_count"`
+		AverageROI           float64      `json:"average_roi"`
+		TotalProductCost     models.Money `json:"total_product_cost"`
+		TotalPlatformFee     models.Money `json:"total_platform_fee"`
+		TotalShippingFee     models.Money `json:"total_shipping_fee"`
+		// 毛利、毛利率、ROAS、保本 ROI（按合计值计算）
+		models.RevenueProfit
+	}
+
+	currency, err := parseReportCurrency(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conv, err := newCurrencyConverter(c.db, currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch aggregated data"})
+		return
 	}
 
-	var results []AggregatedResult
-
-	// 使用聚合函数并按 user_id 分组，并通过左联接获取用户的 nickname 和 avatar
-	err := query.
+	// 先按 员工 + 币种 + 日期 汇总，换算为报表币种（currency 参数，默认基础币种）后再按员工累加
+	var daily []struct {
+		RevenueAmounts
+		UserID          int
+		Nickname        string
+		Avatar          string
+		Currency        string
+		RecordDate      string
+		OrderCount      int64
+		AdCreationCount int64
+	}
+	err = query.
 		Joins("left join users on users.id = employee_revenue.user_id").
 		Select(
 			"employee_revenue.user_id, " +
 				"users.nickname as nickname, " +
 				"users.avatar as avatar, " +
-				"SUM(employee_revenue.revenue) as total_revenue, " +
-				"SUM(employee_revenue.expenditure) as total_expenditure, " +
-				"SUM(employee_revenue.order_count) as total_order_count, " +
-				"SUM(employee_revenue.ad_creation_count) as total_ad_creation_count, " +
-				"CASE WHEN SUM(employee_revenue.expenditure) <> 0 THEN SUM(employee_revenue.revenue)/SUM(employee_revenue.expenditure) ELSE 0 END as average_roi",
+				"employee_revenue.currency, employee_revenue.record_date, " +
+				"SUM(employee_revenue.revenue) as revenue, " +
+				"SUM(employee_revenue.expenditure) as expenditure, " +
+				"SUM(employee_revenue.order_count) as order_count, " +
+				"SUM(employee_revenue.ad_creation_count) as ad_creation_count, " +
+				"SUM(employee_revenue.product_cost) as product_cost, " +
+				"SUM(employee_revenue.platform_fee) as platform_fee, " +
+				"SUM(employee_revenue.shipping_fee) as shipping_fee",
 		).
-		Group("employee_revenue.user_id").
-		Scan(&results).Error
+		Group("employee_revenue.user_id, employee_revenue.currency, employee_revenue.record_date").
+		Order("employee_revenue.user_id").
+		Scan(&daily).Error
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch aggregated data"})
 		return
 	}
 
-	// 对返回的 float 数值保留两位小数
+	results := make([]AggregatedResult, 0)
+	totals := make([]RevenueAmounts, 0)
+	for _, row := range daily {
+		amounts, err := conv.convertAmounts(row.RevenueAmounts, row.Currency, row.RecordDate)
+		if err != nil {
+			if !respondMissingRate(ctx, err) {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch aggregated data"})
+			}
+			return
+		}
+		if n := len(results); n == 0 || results[n-1].UserID != row.UserID {
+			results = append(results, AggregatedResult{UserID: row.UserID, Nickname: row.Nickname, Avatar: row.Avatar})
+			totals = append(totals, RevenueAmounts{})
+		}
+		result := &results[len(results)-1]
+		result.TotalOrderCount += row.OrderCount
+		result.TotalAdCreationCount += row.AdCreationCount
+		totals[len(totals)-1].add(amounts)
+	}
+
+	// 金额合计是精确的整数（分），比率按合计值计算后保留两位小数
 	for i := range results {
-		results[i].TotalRevenue = math.Round(results[i].TotalRevenue*100) / 100
-		results[i].TotalExpenditure = math.Round(results[i].TotalExpenditure*100) / 100
-		results[i].AverageROI = math.Round(results[i].AverageROI*100) / 100
+		t := totals[i]
+		results[i].TotalRevenue = t.Revenue
+		results[i].TotalExpenditure = t.Expenditure
+		results[i].TotalProductCost = t.ProductCost
+		results[i].TotalPlatformFee = t.PlatformFee
+		results[i].TotalShippingFee = t.ShippingFee
+		results[i].AverageROI = revenueROI(t.Revenue, t.Expenditure)
+		results[i].RevenueProfit = t.profit()
 	}
 
 	// 返回所有员工的聚合数据
 	// 当请求中传入 userId 参数时，返回数组中只包含该员工的一条记录，
 	// 否则返回所有员工的统计数据，由前端进行总数据的统计计算
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": results,
+		"currency": currency,
+		"data":     results,
 	})
 }
@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EngagementController 定义点赞、收藏和评论表情回应的接口
+type EngagementController interface {
+	LikeBlog(ctx *gin.Context)              // 点赞博客
+	UnlikeBlog(ctx *gin.Context)            // 取消点赞
+	BookmarkBlog(ctx *gin.Context)          // 收藏博客
+	UnbookmarkBlog(ctx *gin.Context)        // 取消收藏
+	ListMyBookmarks(ctx *gin.Context)       // 我的收藏（分页）
+	AddCommentReaction(ctx *gin.Context)    // 添加评论表情回应
+	RemoveCommentReaction(ctx *gin.Context) // 取消评论表情回应
+}
+
+type engagementController struct {
+	db *gorm.DB
+}
+
+// NewEngagementController 创建一个新的 EngagementController
+func NewEngagementController(db *gorm.DB) EngagementController {
+	return &engagementController{db: db}
+}
+
+// allowedReactions 允许的评论表情
+var allowedReactions = map[string]bool{
+	"👍":  true,
+	"❤️": true,
+	"😂":  true,
+	"😮":  true,
+	"😢":  true,
+	"🎉":  true,
+}
+
+// currentUserID 从 token 中获取当前用户 ID
+func currentUserID(ctx *gin.Context) (uint, bool) {
+	userIDRaw, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+	userID, ok := userIDRaw.(uint)
+	if !ok || userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// toggleBlogRelation 在同一事务中新增/删除点赞或收藏记录，并维护博客上的计数字段
+func (c *engagementController) toggleBlogRelation(ctx *gin.Context, record interface{}, counter string, add bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var blog models.Blog
+	if err := c.db.First(&blog, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return
+	}
+	// 看不到的博客按不存在处理；博客变为不可见之前已点赞/收藏的用户仍可以取消
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	visible, err := canViewBlog(c.db, &blog, userID, role)
+	if err == nil && !visible && !add {
+		var existing int64
+		err = c.db.Model(record).Where("blog_id = ? AND user_id = ?", blog.ID, userID).Count(&existing).Error
+		visible = existing > 0
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission"})
+		return
+	}
+	if !visible {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		switch r := record.(type) {
+		case *models.BlogLike:
+			r.BlogID, r.UserID = blog.ID, userID
+		case *models.BlogBookmark:
+			r.BlogID, r.UserID = blog.ID, userID
+		}
+
+		var result *gorm.DB
+		if add {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		} else {
+			result = tx.Where("blog_id = ? AND user_id = ?", blog.ID, userID).Delete(record)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		// 重复点赞/取消不会改变计数
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		delta := 1
+		if !add {
+			delta = -1
+		}
+		return tx.Model(&models.Blog{}).Where("id = ?", blog.ID).
+			UpdateColumn(counter, gorm.Expr(counter+" + ?", delta)).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update blog"})
+		return
+	}
+
+	var counts struct {
+		LikeCount     int64
+		BookmarkCount int64
+	}
+	c.db.Model(&models.Blog{}).Select("like_count, bookmark_count").Where("id = ?", blog.ID).Scan(&counts)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"blog_id":        blog.ID,
+		"like_count":     counts.LikeCount,
+		"bookmark_count": counts.BookmarkCount,
+	})
+}
+
+// LikeBlog 点赞博客（重复点赞不报错）
+func (c *engagementController) LikeBlog(ctx *gin.Context) {
+	c.toggleBlogRelation(ctx, &models.BlogLike{}, "like_count", true)
+}
+
+// UnlikeBlog 取消点赞
+func (c *engagementController) UnlikeBlog(ctx *gin.Context) {
+	c.toggleBlogRelation(ctx, &models.BlogLike{}, "like_count", false)
+}
+
+// BookmarkBlog 收藏博客（重复收藏不报错）
+func (c *engagementController) BookmarkBlog(ctx *gin.Context) {
+	c.toggleBlogRelation(ctx, &models.BlogBookmark{}, "bookmark_count", true)
+}
+
+// UnbookmarkBlog 取消收藏
+func (c *engagementController) UnbookmarkBlog(ctx *gin.Context) {
+	c.toggleBlogRelation(ctx, &models.BlogBookmark{}, "bookmark_count", false)
+}
+
+// ListMyBookmarks 获取当前用户收藏的博客（按收藏时间倒序，支持页码或游标分页）
+func (c *engagementController) ListMyBookmarks(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	p, err := utils.ParsePagination(ctx, 10)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	// 收藏后又撤回为草稿或进入审核的博客，对当前用户不可见时不返回
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	query := visibleBlogs(c.db.Table("blog_bookmarks").
+		Joins("JOIN blog ON blog.id = blog_bookmarks.blog_id AND blog.deleted_at IS NULL").
+		Where("blog_bookmarks.user_id = ?", userID), userID, role).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bookmarks"})
+		return
+	}
+
+	// 游标基于收藏记录的 (created_at, id)
+	var blogs []BlogWithAuthor
+	dataQuery := query.
+		Select(blogListColumns + ", blog.content, blog_bookmarks.created_at as cursor_time, blog_bookmarks.id as cursor_id").
+		Joins("LEFT JOIN users ON users.id = blog.user_id")
+	if err := p.Apply(dataQuery, "blog_bookmarks.created_at", "blog_bookmarks.id").Scan(&blogs).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+		return
+	}
+
+	blogs, nextCursor := pageBlogs(p, blogs)
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// reactionInput 评论表情回应请求体
+type reactionInput struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// toggleCommentReaction 在同一事务中新增/删除表情回应，并维护评论上的回应总数
+func (c *engagementController) toggleCommentReaction(ctx *gin.Context, emoji string, add bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	if !allowedReactions[emoji] {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported reaction"})
+		return
+	}
+
+	var comment models.Comment
+	if err := c.db.First(&comment, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	// 与点赞相同：所在博客不可见时按评论不存在处理，已有的表情回应仍可以取消
+	var blog models.Blog
+	if err := c.db.First(&blog, comment.BlogID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	visible, err := canViewBlog(c.db, &blog, userID, role)
+	if err == nil && !visible && !add {
+		var existing int64
+		err = c.db.Model(&models.CommentReaction{}).Where("comment_id = ? AND user_id = ? AND emoji = ?", comment.ID, userID, emoji).
+			Count(&existing).Error
+		visible = existing > 0
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission"})
+		return
+	}
+	if !visible {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		reaction := models.CommentReaction{CommentID: comment.ID, UserID: userID, Emoji: emoji}
+		var result *gorm.DB
+		if add {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		} else {
+			result = tx.Where("comment_id = ? AND user_id = ? AND emoji = ?", comment.ID, userID, emoji).
+				Delete(&models.CommentReaction{})
+		}
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		delta := 1
+		if !add {
+			delta = -1
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", comment.ID).
+			UpdateColumn("reaction_count", gorm.Expr("reaction_count + ?", delta)).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reaction"})
+		return
+	}
+
+	reactions, err := loadReactionCounts(c.db, []uint{comment.ID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
+		return
+	}
+
+	var total int64
+	for _, n := range reactions[comment.ID] {
+		total += n
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"comment_id":     comment.ID,
+		"reaction_count": total,
+		"reactions":      reactions[comment.ID],
+	})
+}
+
+// AddCommentReaction 添加评论表情回应，请求体 {"emoji": "👍"}
+func (c *engagementController) AddCommentReaction(ctx *gin.Context) {
+	var input reactionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.toggleCommentReaction(ctx, input.Emoji, true)
+}
+
+// RemoveCommentReaction 取消评论表情回应，表情通过 ?emoji= 传入
+func (c *engagementController) RemoveCommentReaction(ctx *gin.Context) {
+	c.toggleCommentReaction(ctx, ctx.Query("emoji"), false)
+}
+
+// loadReactionCounts 一次查询获取多条评论按表情分组的回应数
+func loadReactionCounts(db *gorm.DB, commentIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CommentID uint
+		Emoji     string
+		Total     int64
+	}
+	if err := db.Model(&models.CommentReaction{}).
+		Select("comment_id, emoji, COUNT(*) as total").
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id, emoji").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.CommentID] == nil {
+			counts[row.CommentID] = make(map[string]int64)
+		}
+		counts[row.CommentID][row.Emoji] = row.Total
+	}
+	return counts, nil
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 未发布的博客只有作者、协作者和管理员可以点赞、收藏；已点赞/收藏的用户在博客变为不可见后仍可以取消
+func TestToggleBlogRelationVisibility(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	viewer := createTestUser(t, db, "viewer", utils.RoleUser)
+	stranger := createTestUser(t, db, "stranger", utils.RoleUser)
+	other := createTestUser(t, db, "other", utils.RoleUser)
+	draft := createTestBlog(t, db, author.ID, "draft")
+	db.Model(&draft).UpdateColumn("status", "draft")
+	db.Create(&models.BlogCollaborator{BlogID: draft.ID, UserID: viewer.ID, Role: models.CollaboratorViewer})
+	c := NewEngagementController(db)
+
+	call := func(handler gin.HandlerFunc, blogID uint, user models.Users) int {
+		return performRequest(handler, http.MethodPost, "/", "", user.ID, user.Role,
+			gin.Param{Key: "id", Value: strconv.Itoa(int(blogID))}).Code
+	}
+
+	if code := call(c.LikeBlog, draft.ID, stranger); code != http.StatusNotFound {
+		t.Errorf("stranger liking a draft: status %d, want 404", code)
+	}
+	if code := call(c.UnlikeBlog, draft.ID, stranger); code != http.StatusNotFound {
+		t.Errorf("stranger unliking a draft: status %d, want 404", code)
+	}
+	if code := call(c.BookmarkBlog, draft.ID, stranger); code != http.StatusNotFound {
+		t.Errorf("stranger bookmarking a draft: status %d, want 404", code)
+	}
+	for _, user := range []models.Users{author, viewer, admin} {
+		if code := call(c.LikeBlog, draft.ID, user); code != http.StatusOK {
+			t.Errorf("%s liking a draft: status %d, want 200", user.Username, code)
+		}
+	}
+
+	// 发布后所有人可以收藏；改回草稿后，已收藏的用户仍可以取消，其他人看不到
+	db.Model(&draft).UpdateColumn("status", "published")
+	if code := call(c.BookmarkBlog, draft.ID, stranger); code != http.StatusOK {
+		t.Errorf("stranger bookmarking a published blog: status %d, want 200", code)
+	}
+	db.Model(&draft).UpdateColumn("status", "draft")
+	if code := call(c.UnbookmarkBlog, draft.ID, other); code != http.StatusNotFound {
+		t.Errorf("other user unbookmarking a draft: status %d, want 404", code)
+	}
+	if code := call(c.UnbookmarkBlog, draft.ID, stranger); code != http.StatusOK {
+		t.Errorf("stranger removing an existing bookmark: status %d, want 200", code)
+	}
+
+	var stored models.Blog
+	db.First(&stored, draft.ID)
+	if stored.LikeCount != 3 || stored.BookmarkCount != 0 {
+		t.Errorf("like_count %d, bookmark_count %d; want 3 and 0", stored.LikeCount, stored.BookmarkCount)
+	}
+}
+
+// 未发布博客下的评论不能被其他人添加表情回应
+func TestCommentReactionVisibility(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	stranger := createTestUser(t, db, "stranger", utils.RoleUser)
+	blog := createTestBlog(t, db, author.ID, "blog")
+	comment := createTestComment(t, db, blog.ID, author.ID, "comment")
+	c := NewEngagementController(db)
+	param := gin.Param{Key: "id", Value: strconv.Itoa(int(comment.ID))}
+	emoji := "👍"
+
+	add := func(user models.Users) int {
+		return performRequest(c.AddCommentReaction, http.MethodPost, "/", `{"emoji":"`+emoji+`"}`, user.ID, user.Role, param).Code
+	}
+	remove := func(user models.Users) int {
+		return performRequest(c.RemoveCommentReaction, http.MethodDelete, "/?emoji="+url.QueryEscape(emoji), "", user.ID, user.Role, param).Code
+	}
+
+	if code := add(stranger); code != http.StatusOK {
+		t.Fatalf("reaction on a published blog: status %d, want 200", code)
+	}
+	db.Model(&blog).UpdateColumn("status", "draft")
+	if code := add(author); code != http.StatusOK {
+		t.Errorf("author reacting on a draft: status %d, want 200", code)
+	}
+	emoji = "🎉"
+	if code := add(stranger); code != http.StatusNotFound {
+		t.Errorf("stranger reacting on a draft: status %d, want 404", code)
+	}
+	if code := remove(stranger); code != http.StatusNotFound {
+		t.Errorf("stranger removing a missing reaction on a draft: status %d, want 404", code)
+	}
+	emoji = "👍"
+	if code := remove(stranger); code != http.StatusOK {
+		t.Errorf("stranger removing an existing reaction: status %d, want 200", code)
+	}
+
+	var stored models.Comment
+	db.First(&stored, comment.ID)
+	if stored.ReactionCount != 1 {
+		t.Errorf("reaction_count = %d, want 1", stored.ReactionCount)
+	}
+}
+
+// 收藏的博客改回草稿后，不再出现在其他用户的收藏列表中
+func TestListMyBookmarksVisibility(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	reader := createTestUser(t, db, "reader", utils.RoleUser)
+	kept := createTestBlog(t, db, author.ID, "kept")
+	withdrawn := createTestBlog(t, db, author.ID, "withdrawn")
+	c := NewEngagementController(db)
+
+	for _, blog := range []models.Blog{kept, withdrawn} {
+		for _, user := range []models.Users{author, reader} {
+			if code := performRequest(c.BookmarkBlog, http.MethodPost, "/", "", user.ID, user.Role,
+				gin.Param{Key: "id", Value: strconv.Itoa(int(blog.ID))}).Code; code != http.StatusOK {
+				t.Fatalf("bookmark: status %d", code)
+			}
+		}
+	}
+	db.Model(&withdrawn).UpdateColumn("status", models.BlogStatusDraft)
+
+	list := func(user models.Users) (int64, []string) {
+		w := performRequest(c.ListMyBookmarks, http.MethodGet, "/", "", user.ID, user.Role)
+		var resp struct {
+			Total int64            `json:"total"`
+			Data  []BlogWithAuthor `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		titles := make([]string, 0, len(resp.Data))
+		for _, blog := range resp.Data {
+			titles = append(titles, blog.Title)
+		}
+		sort.Strings(titles)
+		return resp.Total, titles
+	}
+	if total, titles := list(reader); total != 1 || fmt.Sprint(titles) != "[kept]" {
+		t.Errorf("reader bookmarks: total %d, %v; want only kept", total, titles)
+	}
+	if total, titles := list(author); total != 2 || fmt.Sprint(titles) != "[kept withdrawn]" {
+		t.Errorf("author bookmarks: total %d, %v; want both", total, titles)
+	}
+}
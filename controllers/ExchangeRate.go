@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExchangeRateController 定义汇率表的接口
+type ExchangeRateController interface {
+	ListExchangeRates(ctx *gin.Context)  // 汇率列表
+	CreateExchangeRate(ctx *gin.Context) // 新增汇率（管理员）
+	UpdateExchangeRate(ctx *gin.Context) // 修改汇率（管理员）
+	DeleteExchangeRate(ctx *gin.Context) // 删除汇率（管理员）
+}
+
+type exchangeRateController struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateController 创建一个新的 ExchangeRateController
+func NewExchangeRateController(db *gorm.DB) ExchangeRateController {
+	return &exchangeRateController{db: db}
+}
+
+// ExchangeRateInput 新增/修改汇率的请求体
+type ExchangeRateInput struct {
+	Date     string  `json:"date"`     // YYYY-MM-DD
+	Currency string  `json:"currency"` // 外币代码，不能是基础币种
+	Rate     float64 `json:"rate"`     // 1 单位外币折合多少基础币种
+	Remark   string  `json:"remark"`
+}
+
+// validateExchangeRate 校验并规范化请求体，返回错误信息
+func validateExchangeRate(input *ExchangeRateInput) string {
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return "Invalid date, expected YYYY-MM-DD"
+	}
+	currency, ok := models.NormalizeCurrency(input.Currency)
+	if !ok {
+		return "Invalid currency, expected an ISO 4217 code such as EUR"
+	}
+	if currency == utils.RevenueBaseCurrency() {
+		return "The base currency " + currency + " does not need an exchange rate"
+	}
+	input.Currency = currency
+	if input.Rate <= 0 || math.IsInf(input.Rate, 0) || math.IsNaN(input.Rate) {
+		return "rate must be greater than 0"
+	}
+	return ""
+}
+
+// ListExchangeRates 汇率列表，可按 currency、startDate、endDate 筛选，按日期倒序
+func (c *exchangeRateController) ListExchangeRates(ctx *gin.Context) {
+	query := c.db.Model(&models.ExchangeRate{})
+	if currency := ctx.Query("currency"); currency != "" {
+		code, ok := models.NormalizeCurrency(currency)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+			return
+		}
+		query = query.Where("currency = ?", code)
+	}
+	for param, cond := range map[string]string{"startDate": "date >= ?", "endDate": "date <= ?"} {
+		if value := ctx.Query(param); value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " format"})
+				return
+			}
+			query = query.Where(cond, value)
+		}
+	}
+
+	rates := make([]models.ExchangeRate, 0)
+	if err := query.Order("date DESC, currency").Find(&rates).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"base_currency": utils.RevenueBaseCurrency(), "data": rates})
+}
+
+// CreateExchangeRate 新增汇率，同一币种同一天只能有一条
+func (c *exchangeRateController) CreateExchangeRate(ctx *gin.Context) {
+	var input ExchangeRateInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateExchangeRate(&input); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var existing models.ExchangeRate
+	if err := c.db.Where("date = ? AND currency = ?", input.Date, input.Currency).First(&existing).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An exchange rate for this currency and date already exists", "existing_id": existing.ID})
+		return
+	}
+
+	rate := models.ExchangeRate{Date: input.Date, Currency: input.Currency, Rate: input.Rate, Remark: input.Remark}
+	if err := c.db.Create(&rate).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exchange rate"})
+		return
+	}
+	ctx.JSON(http.StatusCreated, rate)
+}
+
+// UpdateExchangeRate 修改汇率（整体替换日期、币种、汇率和备注）
+func (c *exchangeRateController) UpdateExchangeRate(ctx *gin.Context) {
+	var rate models.ExchangeRate
+	if err := c.db.First(&rate, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+		return
+	}
+
+	var input ExchangeRateInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateExchangeRate(&input); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var count int64
+	c.db.Model(&models.ExchangeRate{}).Where("date = ? AND currency = ? AND id <> ?", input.Date, input.Currency, rate.ID).Count(&count)
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An exchange rate for this currency and date already exists"})
+		return
+	}
+
+	rate.Date = input.Date
+	rate.Currency = input.Currency
+	rate.Rate = input.Rate
+	rate.Remark = input.Remark
+	if err := c.db.Save(&rate).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rate"})
+		return
+	}
+	ctx.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate 删除汇率
+func (c *exchangeRateController) DeleteExchangeRate(ctx *gin.Context) {
+	result := c.db.Delete(&models.ExchangeRate{}, ctx.Param("id"))
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exchange rate"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
+
+// missingRateError 某个币种在指定日期及之前没有汇率
+type missingRateError struct {
+	currency string
+	date     string
+}
+
+func (e *missingRateError) Error() string {
+	return fmt.Sprintf("No exchange rate for %s on or before %s", e.currency, e.date)
+}
+
+// respondMissingRate 缺少汇率时返回 422 并返回 true
+func respondMissingRate(ctx *gin.Context, err error) bool {
+	var rateErr *missingRateError
+	if !errors.As(err, &rateErr) {
+		return false
+	}
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": rateErr.Error(), "currency": rateErr.currency, "date": rateErr.date})
+	return true
+}
+
+// parseReportCurrency 解析报表币种参数 currency，默认基础币种
+func parseReportCurrency(ctx *gin.Context) (string, error) {
+	value := ctx.Query("currency")
+	if value == "" {
+		return utils.RevenueBaseCurrency(), nil
+	}
+	code, ok := models.NormalizeCurrency(value)
+	if !ok {
+		return "", errors.New("Invalid currency, expected an ISO 4217 code such as USD")
+	}
+	return code, nil
+}
+
+// currencyConverter 把金额换算为报表币种：使用记录日期当天的汇率，当天没有时用之前最近一天的汇率
+type currencyConverter struct {
+	base   string
+	target string
+	rates  map[string][]models.ExchangeRate // 按日期升序
+}
+
+// newCurrencyConverter 加载汇率表，target 为换算目标币种
+func newCurrencyConverter(db *gorm.DB, target string) (*currencyConverter, error) {
+	var rates []models.ExchangeRate
+	if err := db.Order("currency, date").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	c := &currencyConverter{base: utils.RevenueBaseCurrency(), target: target, rates: make(map[string][]models.ExchangeRate)}
+	for _, rate := range rates {
+		c.rates[rate.Currency] = append(c.rates[rate.Currency], rate)
+	}
+	return c, nil
+}
+
+// rate 1 单位 currency 在 date 折合多少基础币种
+func (c *currencyConverter) rate(currency, date string) (float64, error) {
+	if currency == c.base {
+		return 1, nil
+	}
+	list := c.rates[currency]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Date > date })
+	if i == 0 {
+		return 0, &missingRateError{currency: currency, date: date}
+	}
+	return list[i-1].Rate, nil
+}
+
+// convert 把 date 当天以 currency 计价的金额换算为目标币种，四舍五入到分
+func (c *currencyConverter) convert(amount models.Money, currency, date string) (models.Money, error) {
+	if currency == "" {
+		currency = c.base
+	}
+	if currency == c.target || amount == 0 {
+		return amount, nil
+	}
+	from, err := c.rate(currency, date)
+	if err != nil {
+		return 0, err
+	}
+	to, err := c.rate(c.target, date)
+	if err != nil {
+		return 0, err
+	}
+	return models.Money(math.Round(float64(amount) * from / to)), nil
+}
+
+// convertAmounts 换算一条记录的全部金额
+func (c *currencyConverter) convertAmounts(a RevenueAmounts, currency, date string) (RevenueAmounts, error) {
+	var err error
+	for _, field := range []*models.Money{&a.Expenditure, &a.Revenue, &a.ProductCost, &a.PlatformFee, &a.ShippingFee} {
+		if *field, err = c.convert(*field, currency, date); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// ensureRates 检查 query（employee_revenue 上的筛选）范围内的记录都能换算为目标币种：
+// 每个币种最早一条记录的日期已有汇率，之后的日期也一定有。流式导出开始写响应前调用
+func (c *currencyConverter) ensureRates(query *gorm.DB) error {
+	var firsts []struct {
+		Currency   string
+		RecordDate string
+	}
+	if err := query.Select("employee_revenue.currency, MIN(employee_revenue.record_date) AS record_date").
+		Where("employee_revenue.currency <> ?", c.target).
+		Group("employee_revenue.currency").
+		Scan(&firsts).Error; err != nil {
+		return err
+	}
+	for _, first := range firsts {
+		if _, err := c.convert(1, first.Currency, first.RecordDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// 当天没有汇率时使用之前最近一天的汇率；目标币种不是基础币种时通过基础币种交叉换算
+func TestCurrencyConverter(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&[]models.ExchangeRate{
+		{Date: "2025-03-01", Currency: "EUR", Rate: 1.1},
+		{Date: "2025-03-10", Currency: "EUR", Rate: 1.2},
+		{Date: "2025-03-05", Currency: "GBP", Rate: 1.25},
+	})
+
+	usd, err := newCurrencyConverter(db, "USD")
+	if err != nil {
+		t.Fatalf("newCurrencyConverter: %v", err)
+	}
+	gbp, err := newCurrencyConverter(db, "GBP")
+	if err != nil {
+		t.Fatalf("newCurrencyConverter: %v", err)
+	}
+	for _, c := range []struct {
+		conv     *currencyConverter
+		amount   models.Money
+		currency string
+		date     string
+		want     models.Money
+	}{
+		{usd, 1000, "EUR", "2025-03-01", 1100},
+		{usd, 1000, "EUR", "2025-03-09", 1100}, // 使用 3 月 1 日的汇率
+		{usd, 1000, "EUR", "2025-03-10", 1200},
+		{usd, 1000, "EUR", "2025-12-31", 1200},
+		{usd, 1000, "", "2020-01-01", 1000}, // 未填币种按基础币种
+		{usd, 0, "JPY", "2025-03-10", 0},
+		{gbp, 1000, "USD", "2025-03-05", 800},
+		{gbp, 1000, "EUR", "2025-03-06", 880},
+		{gbp, 1000, "EUR", "2025-03-10", 960},
+		{gbp, 1000, "GBP", "2020-01-01", 1000}, // 与目标币种相同时不需要汇率
+		{gbp, 333, "USD", "2025-03-05", 266},   // 四舍五入到分
+	} {
+		got, err := c.conv.convert(c.amount, c.currency, c.date)
+		if err != nil || got != c.want {
+			t.Errorf("convert %d %s on %s to %s = %d, %v; want %d", c.amount, c.currency, c.date, c.conv.target, got, err, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		conv         *currencyConverter
+		currency     string
+		date         string
+		wantCurrency string
+	}{
+		{usd, "EUR", "2025-02-28", "EUR"},
+		{usd, "JPY", "2025-03-10", "JPY"},
+		{gbp, "USD", "2025-03-04", "GBP"}, // 目标币种当天还没有汇率
+	} {
+		_, err := c.conv.convert(1000, c.currency, c.date)
+		var rateErr *missingRateError
+		if !errors.As(err, &rateErr) || rateErr.currency != c.wantCurrency || rateErr.date != c.date {
+			t.Errorf("convert %s on %s to %s: got error %v, want missing %s rate", c.currency, c.date, c.conv.target, err, c.wantCurrency)
+		}
+	}
+}
+
+// 报表中有记录缺少汇率时返回 422，并指出缺少的币种与日期
+func TestRevenueReportMissingRate(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+	db.Create(&models.ExchangeRate{Date: "2025-03-05", Currency: "EUR", Rate: 1.1})
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 1000, Currency: "EUR"}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 1000, Currency: "EUR", AdType: "search"}, "2025-03-01")
+
+	report := func(query string) (int, map[string]interface{}) {
+		w := performRequest(c.GetRevenueReport, http.MethodGet, "/?dimensions=platform&metrics=revenue&"+query, "", admin.ID, utils.RoleAdmin)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	code, resp := report("")
+	if code != http.StatusUnprocessableEntity || resp["currency"] != "EUR" || resp["date"] != "2025-03-01" {
+		t.Errorf("missing rate: status %d, response %v; want 422 for EUR on 2025-03-01", code, resp)
+	}
+	code, resp = report("startDate=2025-03-05")
+	if totals, _ := resp["totals"].(map[string]interface{}); code != http.StatusOK || totals["revenue"] != 11.0 {
+		t.Errorf("with rate: status %d, response %v; want 200 and revenue 11", code, resp)
+	}
+}
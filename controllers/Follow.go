@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"blog/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FollowController 定义关注作者相关的接口
+type FollowController interface {
+	FollowUser(ctx *gin.Context)    // 关注作者
+	UnfollowUser(ctx *gin.Context)  // 取消关注
+	ListFollowing(ctx *gin.Context) // 我关注的作者
+	ListFollowers(ctx *gin.Context) // 关注我的用户
+}
+
+type followController struct {
+	db *gorm.DB
+}
+
+// NewFollowController 创建一个新的 FollowController
+func NewFollowController(db *gorm.DB) FollowController {
+	return &followController{db: db}
+}
+
+// FollowUserInfo 关注列表中的用户信息
+type FollowUserInfo struct {
+	ID         uint   `json:"id"`
+	Nickname   string `json:"nickname"`
+	Avatar     string `json:"avatar"`
+	FollowedAt string `json:"followed_at"`
+}
+
+// FollowUser 关注作者（重复关注不报错）
+func (c *followController) FollowUser(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	followeeID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || followeeID < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if uint(followeeID) == userID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cannot follow yourself"})
+		return
+	}
+
+	var followee models.Users
+	if err := c.db.First(&followee, followeeID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	follow := models.UserFollow{FollowerID: userID, FolloweeID: followee.ID}
+	if err := c.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Followed successfully"})
+}
+
+// UnfollowUser 取消关注
+func (c *followController) UnfollowUser(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	followeeID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || followeeID < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.db.Where("follower_id = ? AND followee_id = ?", userID, followeeID).
+		Delete(&models.UserFollow{}).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Unfollowed successfully"})
+}
+
+// ListFollowing 获取当前用户关注的作者
+func (c *followController) ListFollowing(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var users []FollowUserInfo
+	if err := c.db.Table("user_follows").
+		Select("users.id, users.nickname, users.avatar, user_follows.created_at as followed_at").
+		Joins("JOIN users ON users.id = user_follows.followee_id AND users.deleted_at IS NULL").
+		Where("user_follows.follower_id = ?", userID).
+		Order("user_follows.created_at DESC").
+		Scan(&users).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch following"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+// ListFollowers 获取关注当前用户的用户
+func (c *followController) ListFollowers(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var users []FollowUserInfo
+	if err := c.db.Table("user_follows").
+		Select("users.id, users.nickname, users.avatar, user_follows.created_at as followed_at").
+		Joins("JOIN users ON users.id = user_follows.follower_id AND users.deleted_at IS NULL").
+		Where("user_follows.followee_id = ?", userID).
+		Order("user_follows.created_at DESC").
+		Scan(&users).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followers"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+// notifyFollowers 作者发布新文章时，为每个关注者创建一条通知
+func notifyFollowers(db *gorm.DB, blog *models.Blog) {
+	var followerIDs []uint
+	if err := db.Model(&models.UserFollow{}).
+		Where("followee_id = ?", blog.AuthorID).
+		Pluck("follower_id", &followerIDs).Error; err != nil {
+		log.Printf("Failed to load followers of user %d: %v", blog.AuthorID, err)
+		return
+	}
+	if len(followerIDs) == 0 {
+		return
+	}
+
+	var author models.Users
+	db.Select("nickname").First(&author, blog.AuthorID)
+
+	notifications := make([]models.Notification, 0, len(followerIDs))
+	for _, followerID := range followerIDs {
+		notifications = append(notifications, models.Notification{
+			UserID:  followerID,
+			Type:    "new_post",
+			Content: fmt.Sprintf("%s 发布了新文章《%s》", author.Nickname, blog.Title),
+			Status:  "unread",
+		})
+	}
+	if err := db.CreateInBatches(&notifications, 100).Error; err != nil {
+		log.Printf("Failed to notify followers of blog %d: %v", blog.ID, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"blog/models"
+	"blog/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -33,6 +34,11 @@ func (c *rechargeTransactionController) CreateRechargeTransaction(ctx *gin.Conte
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var ok bool
+	if rt.Currency, ok = revenueInputCurrency(rt.Currency, utils.RevenueBaseCurrency()); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected an ISO 4217 code such as USD"})
+		return
+	}
 	if err := c.db.Create(&rt).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recharge transaction"})
 		return
@@ -65,6 +71,12 @@ func (c *rechargeTransactionController) UpdateRechargeTransaction(ctx *gin.Conte
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// 币种为空时不修改（Updates 忽略零值）
+	var ok bool
+	if updateData.Currency, ok = revenueInputCurrency(updateData.Currency, ""); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected an ISO 4217 code such as USD"})
+		return
+	}
 
 	if err := c.db.Model(&rt).Updates(updateData).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recharge transaction"})
@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDuplicateGroups 重复记录报表一次最多返回的分组数
+const maxDuplicateGroups = 500
+
+var (
+	errRevenueNotFound    = errors.New("record not found")
+	errRevenueKeyMismatch = errors.New("records do not share the same user, date, platform, ad type and region")
+)
+
+// revenueNotDuplicateError 要删除的记录没有其他同键记录（删除后该组一条都不剩）
+type revenueNotDuplicateError struct{ id uint }
+
+func (e *revenueNotDuplicateError) Error() string {
+	return fmt.Sprintf("Record %d is not a duplicate, at least one record per group must be kept", e.id)
+}
+
+// revenueRecordDate 记录时间对应的业务日期（按 revenue.timezone 时区）
+func revenueRecordDate(t time.Time) string {
+	return t.In(utils.RevenueLocation()).Format("2006-01-02")
+}
+
+// findRevenueByNaturalKey 查找与 record 唯一键（员工 + 业务日期 + 广告平台 + 广告类型 + 地区）相同的记录，排除 excludeID
+func findRevenueByNaturalKey(db *gorm.DB, record *models.EmployeeRevenue, excludeID uint) ([]uint, error) {
+	var ids []uint
+	query := db.Model(&models.EmployeeRevenue{}).
+		Where("user_id = ? AND record_date = ? AND ad_platform = ? AND ad_type = ? AND region = ?",
+			record.UserID, record.RecordDate, record.AdPlatform, record.AdType, record.Region)
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Order("id").Limit(5).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// isUniqueViolation 写入被唯一索引拒绝（并发写入同一唯一键）
+func isUniqueViolation(err error) bool {
+	return errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// sameRevenueKey 两条记录的唯一键是否相同
+func sameRevenueKey(a, b *models.EmployeeRevenue) bool {
+	return a.UserID == b.UserID && a.RecordDate == b.RecordDate && a.AdPlatform == b.AdPlatform &&
+		a.AdType == b.AdType && a.Region == b.Region
+}
+
+// mergeRevenueRecords 把 duplicates 的支出、销售额、订单数、上新数及各项成本累加到 keep 上（备注去重后拼接），
+// 币种不同的金额按当天汇率换算为 keep 的币种，重新计算 ROI，然后彻底删除 duplicates
+func mergeRevenueRecords(tx *gorm.DB, keep *models.EmployeeRevenue, duplicates []models.EmployeeRevenue) error {
+	if len(duplicates) == 0 {
+		return nil
+	}
+	remarks := []string{}
+	if keep.Remark != "" {
+		remarks = append(remarks, keep.Remark)
+	}
+	ids := make([]uint, 0, len(duplicates))
+	var conv *currencyConverter
+	for _, dup := range duplicates {
+		amounts := RevenueAmounts{Expenditure: dup.Expenditure, Revenue: dup.Revenue,
+			ProductCost: dup.ProductCost, PlatformFee: dup.PlatformFee, ShippingFee: dup.ShippingFee}
+		if dup.Currency != keep.Currency {
+			var err error
+			if conv == nil {
+				if conv, err = newCurrencyConverter(tx, keep.Currency); err != nil {
+					return err
+				}
+			}
+			if amounts, err = conv.convertAmounts(amounts, dup.Currency, dup.RecordDate); err != nil {
+				return err
+			}
+		}
+		keep.Expenditure += amounts.Expenditure
+		keep.Revenue += amounts.Revenue
+		keep.ProductCost += amounts.ProductCost
+		keep.PlatformFee += amounts.PlatformFee
+		keep.ShippingFee += amounts.ShippingFee
+		keep.OrderCount += dup.OrderCount
+		keep.AdCreationCount += dup.AdCreationCount
+		if dup.Remark != "" && !containsString(remarks, dup.Remark) {
+			remarks = append(remarks, dup.Remark)
+		}
+		ids = append(ids, dup.ID)
+	}
+	keep.Remark = strings.Join(remarks, "; ")
+	keep.ROI = revenueROI(keep.Revenue, keep.Expenditure)
+	keep.RevenueProfit = models.ComputeRevenueProfit(keep.Revenue, keep.Expenditure, keep.ProductCost, keep.PlatformFee, keep.ShippingFee)
+
+	// 先删除再更新，唯一索引存在时也不会冲突
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.EmployeeRevenue{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.EmployeeRevenue{}).Where("id = ?", keep.ID).Updates(map[string]interface{}{
+		"expenditure":       keep.Expenditure,
+		"revenue":           keep.Revenue,
+		"order_count":       keep.OrderCount,
+		"ad_creation_count": keep.AdCreationCount,
+		"roi":               keep.ROI,
+		"product_cost":      keep.ProductCost,
+		"platform_fee":      keep.PlatformFee,
+		"shipping_fee":      keep.ShippingFee,
+		"remark":            keep.Remark,
+	}).Error
+}
+
+// EmployeeRevenueUpsertInput PUT 按唯一键新建或覆盖记录；user_id 为空时为当前用户，管理员和财务可以指定其他员工
+type EmployeeRevenueUpsertInput struct {
+	EmployeeRevenueInput
+	UserID uint `json:"user_id"`
+}
+
+// UpsertEmployeeRevenue 按 员工 + 业务日期 + 广告平台 + 广告类型 + 地区 新建或覆盖收益记录（record_time 必填）。
+// 新建返回 201，覆盖已有记录返回 200
+func (c *employeeRevenueController) UpsertEmployeeRevenue(ctx *gin.Context) {
+	operatorID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var input EmployeeRevenueUpsertInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.RecordTimeStr == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "record_time is required"})
+		return
+	}
+	recordTime, err := parseFlexibleTime(input.RecordTimeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record_time format"})
+		return
+	}
+
+	userID := operatorID
+	if input.UserID != 0 && input.UserID != operatorID {
+		roleRaw, _ := ctx.Get("role")
+		if role, _ := roleRaw.(int); role > utils.RoleFinance {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins and finance can write records for other users"})
+			return
+		}
+		var count int64
+		if err := c.db.Model(&models.Users{}).Where("id = ?", input.UserID).Count(&count).Error; err != nil || count == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+			return
+		}
+		userID = input.UserID
+	}
+
+	revenue := models.EmployeeRevenue{
+		UserID:            userID,
+		AdPlatform:        input.AdPlatform,
+		ProductCategories: input.ProductCategories,
+		AdType:            input.AdType,
+		Region:            input.Region,
+		Expenditure:       input.Expenditure,
+		OrderCount:        input.OrderCount,
+		AdCreationCount:   input.AdCreationCount,
+		Revenue:           input.Revenue,
+		ProductCost:       input.ProductCost,
+		PlatformFee:       input.PlatformFee,
+		ShippingFee:       input.ShippingFee,
+		Remark:            input.Remark,
+		RecordTime:        recordTime,
+		RecordDate:        revenueRecordDate(recordTime),
+	}
+	revenue.ROI = revenueROI(revenue.Revenue, revenue.Expenditure)
+	// 币种为空时：新建用基础币种，覆盖时保留原记录的币种
+	if revenue.Currency, ok = revenueInputCurrency(input.Currency, ""); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected an ISO 4217 code such as USD"})
+		return
+	}
+
+	created := false
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		ids, err := findRevenueByNaturalKey(tx, &revenue, 0)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			created = true
+			if revenue.Currency == "" {
+				revenue.Currency = utils.RevenueBaseCurrency()
+			}
+			return tx.Create(&revenue).Error
+		}
+
+		// 历史数据中可能仍有重复记录：覆盖最早的一条，其余保持不变，由管理员在重复记录报表中处理
+		var existing models.EmployeeRevenue
+		if err := tx.First(&existing, ids[0]).Error; err != nil {
+			return err
+		}
+		revenue.ID = existing.ID
+		revenue.CreatedAt = existing.CreatedAt
+		if revenue.Currency == "" {
+			revenue.Currency = existing.Currency
+		}
+		return tx.Save(&revenue).Error
+	})
+	// 并发写入同一唯一键时，后插入的一方被唯一索引拒绝
+	if err != nil && created && isUniqueViolation(err) {
+		if ids, findErr := findRevenueByNaturalKey(c.db, &revenue, 0); findErr == nil && len(ids) > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":       "A record for this date, platform, ad type and region was created concurrently, retry the request",
+				"existing_id": ids[0],
+			})
+			return
+		}
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save record"})
+		return
+	}
+
+	if created {
+		ctx.JSON(http.StatusCreated, gin.H{"action": "create", "data": revenue})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"action": "update", "data": revenue})
+}
+
+// RevenueDuplicateGroup 一组唯一键相同的记录
+type RevenueDuplicateGroup struct {
+	UserID      uint                     `json:"user_id"`
+	Nickname    string                   `json:"nickname"`
+	RecordDate  string                   `json:"record_date"`
+	AdPlatform  string                   `json:"ad_platform"`
+	AdType      string                   `json:"ad_type"`
+	Region      string                   `json:"region"`
+	Count       int64                    `json:"count"`
+	Currency    string                   `json:"currency" gorm:"-"` // 合计金额的币种（基础币种）
+	Expenditure models.Money             `json:"expenditure"`
+	Revenue     models.Money             `json:"revenue"`
+	Records     []models.EmployeeRevenue `json:"records" gorm:"-"`
+}
+
+// ListRevenueDuplicates 重复记录报表（管理员）：列出唯一键相同的记录分组，以及唯一索引是否已建立
+func (c *employeeRevenueController) ListRevenueDuplicates(ctx *gin.Context) {
+	groups := make([]RevenueDuplicateGroup, 0)
+	err := c.db.Model(&models.EmployeeRevenue{}).
+		Joins("LEFT JOIN users ON users.id = employee_revenue.user_id").
+		Select("employee_revenue.user_id, users.nickname, employee_revenue.record_date, employee_revenue.ad_platform, " +
+			"employee_revenue.ad_type, employee_revenue.region, COUNT(*) AS count, " +
+			"SUM(employee_revenue.expenditure) AS expenditure, SUM(employee_revenue.revenue) AS revenue").
+		Group("employee_revenue.user_id, employee_revenue.record_date, employee_revenue.ad_platform, " +
+			"employee_revenue.ad_type, employee_revenue.region").
+		Having("COUNT(*) > 1").
+		Order("employee_revenue.record_date DESC, employee_revenue.user_id").
+		Limit(maxDuplicateGroups).
+		Scan(&groups).Error
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicates"})
+		return
+	}
+
+	conv, err := newCurrencyConverter(c.db, utils.RevenueBaseCurrency())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicates"})
+		return
+	}
+	for i := range groups {
+		g := &groups[i]
+		if err := c.db.Where("user_id = ? AND record_date = ? AND ad_platform = ? AND ad_type = ? AND region = ?",
+			g.UserID, g.RecordDate, g.AdPlatform, g.AdType, g.Region).
+			Order("id").Find(&g.Records).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicates"})
+			return
+		}
+		// 同组记录可能币种不同，合计按基础币种重新计算
+		g.Currency, g.Expenditure, g.Revenue = conv.target, 0, 0
+		for _, record := range g.Records {
+			amounts, err := conv.convertAmounts(RevenueAmounts{Expenditure: record.Expenditure, Revenue: record.Revenue}, record.Currency, g.RecordDate)
+			if err != nil {
+				if !respondMissingRate(ctx, err) {
+					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicates"})
+				}
+				return
+			}
+			g.Expenditure += amounts.Expenditure
+			g.Revenue += amounts.Revenue
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"unique_index": c.db.Migrator().HasIndex(&models.EmployeeRevenue{}, models.RevenueNaturalKeyIndex),
+		"data":         groups,
+	})
+}
+
+// RevenueMergeInput 合并重复记录：ids 中的记录合并到 keep_id
+type RevenueMergeInput struct {
+	KeepID uint   `json:"keep_id" binding:"required"`
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+}
+
+// MergeRevenueDuplicates 合并重复记录（管理员）：数值累加到保留的记录上，其余记录彻底删除。
+// 处理完全部重复数据后自动建立唯一索引
+func (c *employeeRevenueController) MergeRevenueDuplicates(ctx *gin.Context) {
+	var input RevenueMergeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keep models.EmployeeRevenue
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&keep, input.KeepID).Error; err != nil {
+			return errRevenueNotFound
+		}
+		var duplicates []models.EmployeeRevenue
+		if err := tx.Where("id IN ? AND id <> ?", input.IDs, keep.ID).Find(&duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) != len(uniqueIDs(input.IDs, keep.ID)) {
+			return errRevenueNotFound
+		}
+		for i := range duplicates {
+			if !sameRevenueKey(&keep, &duplicates[i]) {
+				return errRevenueKeyMismatch
+			}
+		}
+		return mergeRevenueRecords(tx, &keep, duplicates)
+	})
+	if !c.respondDuplicateError(ctx, err) {
+		return
+	}
+
+	created, _ := models.EnsureRevenueNaturalKey(c.db)
+	ctx.JSON(http.StatusOK, gin.H{"data": keep, "unique_index": created})
+}
+
+// RevenueDeleteDuplicatesInput 删除重复记录
+type RevenueDeleteDuplicatesInput struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// DeleteRevenueDuplicates 删除重复记录（管理员，移入回收站）：只能删除仍有其他同键记录的记录，每组至少保留一条。
+// 处理完全部重复数据后自动建立唯一索引
+func (c *employeeRevenueController) DeleteRevenueDuplicates(ctx *gin.Context) {
+	var input RevenueDeleteDuplicatesInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids := uniqueIDs(input.IDs, 0)
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var records []models.EmployeeRevenue
+		if err := tx.Where("id IN ?", ids).Find(&records).Error; err != nil {
+			return err
+		}
+		if len(records) != len(ids) {
+			return errRevenueNotFound
+		}
+		for i := range records {
+			others, err := findRevenueByNaturalKey(tx.Where("id NOT IN ?", ids), &records[i], records[i].ID)
+			if err != nil {
+				return err
+			}
+			if len(others) == 0 {
+				return &revenueNotDuplicateError{records[i].ID}
+			}
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.EmployeeRevenue{}).Error
+	})
+	if !c.respondDuplicateError(ctx, err) {
+		return
+	}
+
+	created, _ := models.EnsureRevenueNaturalKey(c.db)
+	ctx.JSON(http.StatusOK, gin.H{"deleted": len(ids), "unique_index": created})
+}
+
+// respondDuplicateError 处理合并/删除的错误，返回是否成功
+func (c *employeeRevenueController) respondDuplicateError(ctx *gin.Context, err error) bool {
+	var notDuplicate *revenueNotDuplicateError
+	switch {
+	case err == nil:
+		return true
+	case respondMissingRate(ctx, err):
+	case errors.Is(err, errRevenueNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+	case errors.Is(err, errRevenueKeyMismatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Records do not share the same user, date, platform, ad type and region"})
+	case errors.As(err, &notDuplicate):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": notDuplicate.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update records"})
+	}
+	return false
+}
+
+// uniqueIDs 去重并排除 exclude
+func uniqueIDs(ids []uint, exclude uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != exclude && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
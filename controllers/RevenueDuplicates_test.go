@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// setRevenueTimezone 测试期间修改 revenue.timezone
+func setRevenueTimezone(t *testing.T, name string) {
+	t.Helper()
+	old := utils.AppConfig.Revenue.Timezone
+	utils.AppConfig.Revenue.Timezone = name
+	t.Cleanup(func() { utils.AppConfig.Revenue.Timezone = old })
+}
+
+// revenue.timezone 在 UTC 以西时，只有日期的输入仍然记到当天
+func TestParseFlexibleTimeRevenueTimezone(t *testing.T) {
+	setRevenueTimezone(t, "America/New_York")
+
+	for input, want := range map[string]string{
+		"2025-03-10":                "2025-03-10",
+		"2025-03-10 23:30":          "2025-03-10",
+		"2025-03-10T00:15:00":       "2025-03-10",
+		"2025-03-10T03:00:00Z":      "2025-03-09", // 纽约时间 3 月 9 日 23:00
+		"2025-03-10T03:00:00.000Z":  "2025-03-09",
+		"2025-03-10T10:00:00+08:00": "2025-03-09",
+	} {
+		parsed, err := parseFlexibleTime(input)
+		if err != nil {
+			t.Errorf("parseFlexibleTime(%q): %v", input, err)
+			continue
+		}
+		if got := revenueRecordDate(parsed); got != want {
+			t.Errorf("parseFlexibleTime(%q) record date = %s, want %s", input, got, want)
+		}
+	}
+	if _, err := parseFlexibleTime("10/03/2025"); err == nil {
+		t.Error("parseFlexibleTime(10/03/2025) expected error")
+	}
+}
+
+func TestUpsertEmployeeRevenueRevenueTimezone(t *testing.T) {
+	setRevenueTimezone(t, "America/New_York")
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+
+	upsert := func(recordTime string, revenue string) (int, string) {
+		w := performRequest(c.UpsertEmployeeRevenue, http.MethodPut, "/", `{"ad_platform":"FB","ad_type":"feed","region":"US",
+			"expenditure":10,"revenue":`+revenue+`,"record_time":"`+recordTime+`"}`, user.ID, utils.RoleMarketer)
+		var resp struct {
+			Action string                 `json:"action"`
+			Data   models.EmployeeRevenue `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data.RecordDate
+	}
+
+	if code, date := upsert("2025-03-10", "30"); code != http.StatusCreated || date != "2025-03-10" {
+		t.Fatalf("first upsert: status %d, record_date %s; want 201, 2025-03-10", code, date)
+	}
+	// 同一业务日期的另一个时间覆盖原记录
+	if code, date := upsert("2025-03-10 21:00", "50"); code != http.StatusOK || date != "2025-03-10" {
+		t.Fatalf("second upsert: status %d, record_date %s; want 200, 2025-03-10", code, date)
+	}
+
+	var records []models.EmployeeRevenue
+	db.Find(&records)
+	if len(records) != 1 || records[0].Revenue != 5000 {
+		t.Fatalf("got %d records (revenue %v), want 1 record with revenue 50.00", len(records), records)
+	}
+	if _, offset := records[0].RecordTime.Zone(); offset != 0 {
+		t.Errorf("record_time stored with offset %d, want UTC", offset)
+	}
+	if want := time.Date(2025, 3, 11, 1, 0, 0, 0, time.UTC); !records[0].RecordTime.Equal(want) {
+		t.Errorf("record_time = %s, want %s", records[0].RecordTime, want)
+	}
+}
+
+// hasRevenueNaturalKey 唯一索引是否已建立
+func hasRevenueNaturalKey(c EmployeeRevenueController) bool {
+	return c.(*employeeRevenueController).db.Migrator().HasIndex(&models.EmployeeRevenue{}, models.RevenueNaturalKeyIndex)
+}
+
+// 合并时金额与计数累加到保留的记录上，其他币种按当天汇率换算；全部重复数据处理完后才建立唯一索引
+func TestMergeRevenueDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+	db.Create(&models.ExchangeRate{Date: "2025-03-01", Currency: "EUR", Rate: 1.1})
+
+	keep := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Expenditure: 1000, Revenue: 2000,
+		OrderCount: 2, ProductCost: 100, Remark: "manual"}, "2025-03-10")
+	usd := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Expenditure: 500, Revenue: 500,
+		OrderCount: 1, AdCreationCount: 3, Remark: "manual"}, "2025-03-10")
+	eur := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Expenditure: 1000, Revenue: 500,
+		OrderCount: 4, ShippingFee: 200, Currency: "EUR", Remark: "import"}, "2025-03-10")
+	// 另一组重复记录：合并第一组后仍不能建立唯一索引
+	other := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 100}, "2025-03-11")
+	otherDup := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 200}, "2025-03-11")
+	gbp := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 300, Currency: "GBP"}, "2025-03-11")
+
+	merge := func(keepID uint, ids ...uint) (int, map[string]interface{}) {
+		body, _ := json.Marshal(RevenueMergeInput{KeepID: keepID, IDs: ids})
+		w := performRequest(c.MergeRevenueDuplicates, http.MethodPost, "/", string(body), admin.ID, utils.RoleAdmin)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	if code, _ := merge(keep.ID, other.ID); code != http.StatusBadRequest {
+		t.Errorf("merging records with different keys: status %d, want 400", code)
+	}
+	// 没有 GBP 汇率时整个合并不生效
+	if code, _ := merge(other.ID, otherDup.ID, gbp.ID); code != http.StatusUnprocessableEntity {
+		t.Errorf("merging without exchange rate: status %d, want 422", code)
+	}
+	var count int64
+	db.Model(&models.EmployeeRevenue{}).Count(&count)
+	if count != 6 {
+		t.Fatalf("got %d records after failed merges, want 6", count)
+	}
+
+	code, resp := merge(keep.ID, usd.ID, eur.ID, usd.ID)
+	if code != http.StatusOK || resp["unique_index"] != false {
+		t.Fatalf("merge: status %d, response %v; want 200 without unique index", code, resp)
+	}
+	var merged models.EmployeeRevenue
+	db.First(&merged, keep.ID)
+	if merged.Currency != "USD" || merged.Expenditure != 2600 || merged.Revenue != 3050 || merged.OrderCount != 7 ||
+		merged.AdCreationCount != 3 || merged.ProductCost != 100 || merged.ShippingFee != 220 ||
+		merged.Remark != "manual; import" || merged.ROI != 1.17 {
+		t.Errorf("unexpected merged record %+v", merged)
+	}
+	for _, id := range []uint{usd.ID, eur.ID} {
+		if exists(db, &models.EmployeeRevenue{}, id) {
+			t.Errorf("merged record %d should be deleted", id)
+		}
+	}
+	if hasRevenueNaturalKey(c) {
+		t.Fatal("unique index created while duplicates remain")
+	}
+
+	db.Create(&models.ExchangeRate{Date: "2025-03-01", Currency: "GBP", Rate: 1.25})
+	code, resp = merge(other.ID, otherDup.ID, gbp.ID)
+	if code != http.StatusOK || resp["unique_index"] != true || !hasRevenueNaturalKey(c) {
+		t.Fatalf("merge: status %d, response %v; want 200 with unique index", code, resp)
+	}
+	var mergedOther models.EmployeeRevenue
+	db.First(&mergedOther, other.ID)
+	if mergedOther.Revenue != 675 {
+		t.Errorf("revenue = %v, want 6.75", mergedOther.Revenue)
+	}
+}
+
+// 删除重复记录时每组至少保留一条；删除后没有重复数据时建立唯一索引
+func TestDeleteRevenueDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+
+	first := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 100}, "2025-03-10")
+	second := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 200}, "2025-03-10")
+	single := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 300}, "2025-03-11")
+
+	remove := func(ids ...uint) (int, map[string]interface{}) {
+		body, _ := json.Marshal(RevenueDeleteDuplicatesInput{IDs: ids})
+		w := performRequest(c.DeleteRevenueDuplicates, http.MethodPost, "/", string(body), admin.ID, utils.RoleAdmin)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	for _, ids := range [][]uint{{first.ID, second.ID}, {single.ID}, {second.ID, single.ID}} {
+		if code, resp := remove(ids...); code != http.StatusBadRequest {
+			t.Errorf("delete %v: status %d, response %v; want 400", ids, code, resp)
+		}
+	}
+	if code, _ := remove(second.ID, 999); code != http.StatusNotFound {
+		t.Errorf("delete missing record: status %d, want 404", code)
+	}
+	var count int64
+	db.Model(&models.EmployeeRevenue{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("got %d records after rejected deletes, want 3", count)
+	}
+
+	code, resp := remove(second.ID)
+	if code != http.StatusOK || fmt.Sprint(resp["deleted"]) != "1" || resp["unique_index"] != true {
+		t.Fatalf("delete: status %d, response %v; want 200 with unique index", code, resp)
+	}
+	if !trashed(t, db, &models.EmployeeRevenue{}, second.ID) || trashed(t, db, &models.EmployeeRevenue{}, first.ID) {
+		t.Error("the deleted duplicate should be in the trash and the other record kept")
+	}
+	if !hasRevenueNaturalKey(c) {
+		t.Fatal("unique index not created")
+	}
+	// 建立唯一索引后不能再写入重复记录
+	if err := db.Create(&models.EmployeeRevenue{UserID: user.ID, AdPlatform: "FB", AdType: "feed", Region: "US",
+		Currency: "USD", RecordTime: first.RecordTime, RecordDate: first.RecordDate}).Error; err == nil {
+		t.Error("unique index should reject a new duplicate")
+	}
+}
+
+// 新建记录时唯一键已存在返回 409 和已有记录 ID；唯一索引拒绝的写入按重复处理
+func TestCreateEmployeeRevenueDuplicate(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+	if created, err := models.EnsureRevenueNaturalKey(db); err != nil || !created {
+		t.Fatalf("EnsureRevenueNaturalKey: %v, %v", created, err)
+	}
+
+	create := func() (int, map[string]interface{}) {
+		w := performRequest(c.CreateEmployeeRevenue, http.MethodPost, "/", `{"ad_platform":"FB","ad_type":"feed","region":"US",
+			"expenditure":10,"revenue":30,"record_time":"2025-03-10"}`, user.ID, utils.RoleMarketer)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := create()
+	if code != http.StatusCreated {
+		t.Fatalf("first create: status %d, response %v; want 201", code, resp)
+	}
+	id := fmt.Sprint(resp["id"])
+	if code, resp = create(); code != http.StatusConflict || fmt.Sprint(resp["existing_id"]) != id {
+		t.Errorf("duplicate create: status %d, response %v; want 409 with existing_id %s", code, resp, id)
+	}
+
+	var existing models.EmployeeRevenue
+	db.First(&existing)
+	existing.ID = 0
+	err := db.Create(&existing).Error
+	if err == nil || !isUniqueViolation(err) {
+		t.Errorf("insert bypassing the check: error %v, want a unique violation", err)
+	}
+	var count int64
+	db.Model(&models.EmployeeRevenue{}).Count(&count)
+	if count != 1 {
+		t.Errorf("got %d records, want 1", count)
+	}
+}
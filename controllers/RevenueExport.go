@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushRows CSV 每写出多少行刷新一次，数据边查边写，不在内存中缓存整个结果
+const exportFlushRows = 500
+
+// revenueDimensionLabels / revenueMetricLabels 导出表头
+var revenueDimensionLabels = map[string]string{
+	"platform": "广告平台",
+	"category": "商品分类",
+	"ad_type":  "广告类型",
+	"region":   "地区",
+	"time":     "时间",
+}
+
+var revenueMetricLabels = map[string]string{
+	"expenditure":       "广告支出",
+	"revenue":           "销售额",
+	"order_count":       "订单数",
+	"ad_creation_count": "上新数",
+	"roi":               "ROI",
+	"records":           "记录数",
+	"product_cost":      "商品成本",
+	"platform_fee":      "平台手续费",
+	"shipping_fee":      "运费",
+	"gross_profit":      "毛利",
+	"margin":            "毛利率",
+	"roas":              "ROAS",
+	"break_even_roi":    "保本 ROI",
+}
+
+// revenueCurrencyMetrics 以金额格式导出的指标
+var revenueCurrencyMetrics = map[string]bool{
+	"expenditure": true, "revenue": true, "product_cost": true,
+	"platform_fee": true, "shipping_fee": true, "gross_profit": true,
+}
+
+// exportWriter 导出文件的逐行写入器（CSV 或 XLSX）
+type exportWriter interface {
+	WriteRow(cells ...utils.XLSXCell) error
+	Close() error
+}
+
+// csvExportWriter CSV 导出：UTF-8 带 BOM，Excel 打开中文不乱码；金额固定两位小数
+type csvExportWriter struct {
+	ctx  *gin.Context
+	w    *csv.Writer
+	rows int
+}
+
+func (w *csvExportWriter) WriteRow(cells ...utils.XLSXCell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.Value.(type) {
+		case nil:
+		case float64:
+			if cell.Currency {
+				record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+			} else {
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		case string:
+			record[i] = csvSafeCell(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	if err := w.w.Write(record); err != nil {
+		return err
+	}
+	if w.rows++; w.rows%exportFlushRows == 0 {
+		w.w.Flush()
+		w.ctx.Writer.Flush()
+	}
+	return w.w.Error()
+}
+
+// csvSafeCell 防止 CSV 注入：以 = + - @（以及制表符、回车）开头的文本会被 Excel 当作公式执行，前面加 ' 按文本显示。
+// 只处理字符串单元格，数值单元格（包括负数）原样输出
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (w *csvExportWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// newExportWriter 按 format（csv/xlsx，默认 csv）设置下载响应头并返回写入器；format 无效时返回 400
+func newExportWriter(ctx *gin.Context, filename string) (exportWriter, bool) {
+	format := ctx.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected csv or xlsx"})
+		return nil, false
+	}
+
+	filename = fmt.Sprintf("%s-%s.%s", filename, time.Now().Format("20060102"), format)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "xlsx" {
+		ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		ctx.Status(http.StatusOK)
+		w, err := utils.NewXLSXWriter(ctx.Writer, "Sheet1")
+		if err != nil {
+			log.Printf("Failed to start xlsx export: %v", err)
+			return nil, false
+		}
+		return w, true
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.WriteString("\xEF\xBB\xBF")
+	return &csvExportWriter{ctx: ctx, w: csv.NewWriter(ctx.Writer)}, true
+}
+
+// headerCells 加粗的表头行
+func headerCells(labels ...string) []utils.XLSXCell {
+	cells := make([]utils.XLSXCell, len(labels))
+	for i, label := range labels {
+		cells[i] = utils.XLSXCell{Value: label, Bold: true}
+	}
+	return cells
+}
+
+// ExportEmployeeRevenue 导出收益明细（管理端，筛选条件同 parseRevenueFilter），format=csv/xlsx，末行为合计
+func (c *employeeRevenueController) ExportEmployeeRevenue(ctx *gin.Context) {
+	f, err := parseRevenueFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.exportRevenueRecords(ctx, f, "revenue-records")
+}
+
+// ExportUserEmployeeRevenue 导出当前用户自己的收益明细（筛选条件同上，userId 固定为当前用户）
+func (c *employeeRevenueController) ExportUserEmployeeRevenue(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	f, err := parseRevenueFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	f.UserID = userID
+	c.exportRevenueRecords(ctx, f, "my-revenue-records")
+}
+
+// exportRevenueRecords 按记录时间倒序逐行导出收益明细，金额换算为报表币种
+func (c *employeeRevenueController) exportRevenueRecords(ctx *gin.Context, f RevenueFilter, filename string) {
+	conv, err := newCurrencyConverter(c.db, f.Currency)
+	if err == nil {
+		// 开始输出文件之前确认汇率齐全，缺少时仍能返回 JSON 错误
+		err = conv.ensureRates(f.Apply(c.db.Model(&models.EmployeeRevenue{})))
+	}
+	if err != nil {
+		if !respondMissingRate(ctx, err) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		}
+		return
+	}
+	rows, err := f.Apply(c.db.Model(&models.EmployeeRevenue{})).
+		Joins("LEFT JOIN users ON users.id = employee_revenue.user_id").
+		Select("employee_revenue.id, users.nickname, employee_revenue.remark, " + revenueRecordColumns).
+		Order("employee_revenue.record_time DESC, employee_revenue.id DESC").
+		Rows()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+	defer rows.Close()
+
+	w, ok := newExportWriter(ctx, filename)
+	if !ok {
+		return
+	}
+	// 响应已开始，之后出错只能记录日志并中断输出
+	fail := func(err error) {
+		log.Printf("Failed to export revenue records: %v", err)
+		ctx.Abort()
+	}
+
+	money := func(label string) string { return label + " (" + f.Currency + ")" }
+	if err := w.WriteRow(headerCells("ID", "员工ID", "员工", "记录时间", "广告平台", "商品分类", "广告类型", "地区", "原币种",
+		money("广告支出"), money("销售额"), "订单数", "上新数", "ROI",
+		money("商品成本"), money("平台手续费"), money("运费"), money("毛利"), "毛利率", "ROAS", "保本 ROI", "备注")...); err != nil {
+		fail(err)
+		return
+	}
+
+	var totals revenueAggregate
+	for rows.Next() {
+		record, err := scanRevenueRecord(c.db, rows, conv)
+		if err != nil {
+			fail(err)
+			return
+		}
+		totals.add(record.RevenueAmounts)
+		totals.OrderCount += record.OrderCount
+		totals.AdCreation += record.AdCreationCount
+		profit := record.profit()
+
+		if err := w.WriteRow(
+			utils.XLSXCell{Value: record.ID},
+			utils.XLSXCell{Value: record.UserID},
+			utils.XLSXCell{Value: record.Nickname},
+			utils.XLSXCell{Value: record.RecordTime.In(f.Location).Format("2006-01-02 15:04")},
+			utils.XLSXCell{Value: record.AdPlatform},
+			utils.XLSXCell{Value: record.ProductCategories},
+			utils.XLSXCell{Value: record.AdType},
+			utils.XLSXCell{Value: record.Region},
+			utils.XLSXCell{Value: record.Currency},
+			utils.XLSXCell{Value: record.Expenditure.Float(), Currency: true},
+			utils.XLSXCell{Value: record.Revenue.Float(), Currency: true},
+			utils.XLSXCell{Value: record.OrderCount},
+			utils.XLSXCell{Value: record.AdCreationCount},
+			utils.XLSXCell{Value: revenueROI(record.Revenue, record.Expenditure)},
+			utils.XLSXCell{Value: record.ProductCost.Float(), Currency: true},
+			utils.XLSXCell{Value: record.PlatformFee.Float(), Currency: true},
+			utils.XLSXCell{Value: record.ShippingFee.Float(), Currency: true},
+			utils.XLSXCell{Value: profit.GrossProfit.Float(), Currency: true},
+			utils.XLSXCell{Value: profit.Margin},
+			utils.XLSXCell{Value: profit.ROAS},
+			utils.XLSXCell{Value: profit.BreakEvenROI},
+			utils.XLSXCell{Value: record.Remark},
+		); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		fail(err)
+		return
+	}
+
+	if err := w.WriteRow(
+		utils.XLSXCell{Value: "合计", Bold: true},
+		utils.XLSXCell{}, utils.XLSXCell{}, utils.XLSXCell{}, utils.XLSXCell{},
+		utils.XLSXCell{}, utils.XLSXCell{}, utils.XLSXCell{}, utils.XLSXCell{},
+		utils.XLSXCell{Value: totals.metric("expenditure"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.metric("revenue"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.OrderCount, Bold: true},
+		utils.XLSXCell{Value: totals.AdCreation, Bold: true},
+		utils.XLSXCell{Value: totals.metric("roi"), Bold: true},
+		utils.XLSXCell{Value: totals.metric("product_cost"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.metric("platform_fee"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.metric("shipping_fee"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.metric("gross_profit"), Currency: true, Bold: true},
+		utils.XLSXCell{Value: totals.metric("margin"), Bold: true},
+		utils.XLSXCell{Value: totals.metric("roas"), Bold: true},
+		utils.XLSXCell{Value: totals.metric("break_even_roi"), Bold: true},
+		utils.XLSXCell{},
+	); err != nil {
+		fail(err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		fail(err)
+	}
+}
+
+// ExportRevenueReport 导出透视报表（参数同 GetRevenueReport），末行为全部分组的合计
+func (c *employeeRevenueController) ExportRevenueReport(ctx *gin.Context) {
+	report, err := c.buildRevenueReport(ctx)
+	if err != nil {
+		respondReportError(ctx, err)
+		return
+	}
+
+	w, ok := newExportWriter(ctx, "revenue-report")
+	if !ok {
+		return
+	}
+
+	labels := make([]string, 0, len(report.Dimensions)+len(report.Metrics)+1)
+	for _, dim := range report.Dimensions {
+		if dim == "user" {
+			labels = append(labels, "员工ID", "员工")
+			continue
+		}
+		labels = append(labels, revenueDimensionLabels[dim])
+	}
+	for _, m := range report.Metrics {
+		label := revenueMetricLabels[m]
+		if revenueCurrencyMetrics[m] {
+			label += " (" + report.Currency + ")"
+		}
+		labels = append(labels, label)
+	}
+	rows := [][]utils.XLSXCell{headerCells(labels...)}
+
+	metricCells := func(agg *revenueAggregate, bold bool) []utils.XLSXCell {
+		cells := make([]utils.XLSXCell, 0, len(report.Metrics))
+		for _, m := range report.Metrics {
+			cells = append(cells, utils.XLSXCell{
+				Value:    agg.metric(m),
+				Currency: revenueCurrencyMetrics[m],
+				Bold:     bold,
+			})
+		}
+		return cells
+	}
+	for _, group := range report.Groups {
+		cells := make([]utils.XLSXCell, 0, len(labels))
+		for _, dim := range report.Dimensions {
+			if dim == "user" {
+				cells = append(cells, utils.XLSXCell{Value: group.UserID}, utils.XLSXCell{Value: report.Nicknames[group.UserID]})
+				continue
+			}
+			cells = append(cells, utils.XLSXCell{Value: group.Values[dim]})
+		}
+		rows = append(rows, append(cells, metricCells(group, false)...))
+	}
+
+	totalRow := make([]utils.XLSXCell, len(labels)-len(report.Metrics))
+	totalRow[0] = utils.XLSXCell{Value: "合计", Bold: true}
+	rows = append(rows, append(totalRow, metricCells(&report.Totals, true)...))
+
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			log.Printf("Failed to export revenue report: %v", err)
+			ctx.Abort()
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("Failed to export revenue report: %v", err)
+		ctx.Abort()
+	}
+}
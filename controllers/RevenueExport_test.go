@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"blog/utils"
+	"encoding/csv"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 以公式字符开头的文本单元格加 ' 前缀，数值单元格不受影响
+func TestCSVExportWriterEscapesFormulas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	writer := &csvExportWriter{ctx: ctx, w: csv.NewWriter(w)}
+
+	if err := writer.WriteRow(
+		utils.XLSXCell{Value: "=HYPERLINK(\"http://example.com\",\"x\")"},
+		utils.XLSXCell{Value: "+1"},
+		utils.XLSXCell{Value: "-2+3"},
+		utils.XLSXCell{Value: "@SUM(A1)"},
+		utils.XLSXCell{Value: "\t=1"},
+		utils.XLSXCell{Value: "Facebook"},
+		utils.XLSXCell{Value: "a=b"},
+		utils.XLSXCell{Value: ""},
+		utils.XLSXCell{Value: -12.5, Currency: true},
+		utils.XLSXCell{Value: -3.0},
+		utils.XLSXCell{Value: nil},
+	); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	record, err := csv.NewReader(w.Body).Read()
+	if err != nil {
+		t.Fatalf("Failed to read csv: %v", err)
+	}
+	want := []string{
+		"'=HYPERLINK(\"http://example.com\",\"x\")", "'+1", "'-2+3", "'@SUM(A1)", "'\t=1",
+		"Facebook", "a=b", "", "-12.50", "-3", "",
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %q, want %q", record, want)
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"blog/utils"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RevenueFilter 收益统计的公共筛选条件（报表、导出等接口共用）
+type RevenueFilter struct {
+	StartDate *time.Time // 记录日期起（含），为所在时区的 00:00
+	EndDate   *time.Time // 记录日期止（含当天）
+	UserID    uint
+	Location  *time.Location // 日期与分桶使用的时区
+	Currency  string         // 报表币种：金额按记录日期的汇率换算为该币种后再汇总（不参与筛选）
+
+	AdPlatform        string
+	AdType            string
+	Region            string
+	ProductCategories string
+}
+
+// parseRevenueFilter 解析筛选参数：startDate/endDate（YYYY-MM-DD，按 tz 时区解释）、userId、
+// adPlatform、adType、region、productCategories、tz（IANA 时区名，默认为 revenue.timezone）、
+// currency（报表币种，默认为 revenue.currency）
+func parseRevenueFilter(ctx *gin.Context) (RevenueFilter, error) {
+	f := RevenueFilter{Location: utils.RevenueLocation()}
+	var err error
+	if f.Currency, err = parseReportCurrency(ctx); err != nil {
+		return f, err
+	}
+	if tz := ctx.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return f, errors.New("Invalid tz, expected an IANA time zone such as Asia/Shanghai")
+		}
+		f.Location = loc
+	}
+
+	parseDate := func(name string) (*time.Time, error) {
+		value := ctx.Query(name)
+		if value == "" {
+			return nil, nil
+		}
+		t, err := time.ParseInLocation("2006-01-02", value, f.Location)
+		if err != nil {
+			return nil, errors.New("Invalid " + name + " format")
+		}
+		return &t, nil
+	}
+	if f.StartDate, err = parseDate("startDate"); err != nil {
+		return f, err
+	}
+	if f.EndDate, err = parseDate("endDate"); err != nil {
+		return f, err
+	}
+	if f.StartDate != nil && f.EndDate != nil && f.EndDate.Before(*f.StartDate) {
+		return f, errors.New("endDate must not be before startDate")
+	}
+
+	if userIDStr := ctx.Query("userId"); userIDStr != "" {
+		id, err := strconv.Atoi(userIDStr)
+		if err != nil || id < 1 {
+			return f, errors.New("Invalid userId")
+		}
+		f.UserID = uint(id)
+	}
+
+	f.AdPlatform = strings.TrimSpace(ctx.Query("adPlatform"))
+	f.AdType = strings.TrimSpace(ctx.Query("adType"))
+	f.Region = strings.TrimSpace(ctx.Query("region"))
+	f.ProductCategories = strings.TrimSpace(ctx.Query("productCategories"))
+	return f, nil
+}
+
+// byRecordDate tz 与 revenue.timezone 相同时按业务日期 record_date 筛选和分桶，
+// 与唯一键、目标完成情况使用同一个日期；否则按 record_time（统一以 UTC 存储）换算到 tz
+func (f RevenueFilter) byRecordDate() bool {
+	return f.Location.String() == utils.RevenueLocation().String()
+}
+
+// recordDay 记录在 tz 时区所属的日期（零点），用于分桶
+func (f RevenueFilter) recordDay(recordTime time.Time, recordDate string) time.Time {
+	if f.byRecordDate() {
+		if day, err := time.ParseInLocation("2006-01-02", recordDate, f.Location); err == nil {
+			return day
+		}
+	}
+	return recordTime.In(f.Location)
+}
+
+// Apply 加上筛选条件（作用于 employee_revenue 表）
+func (f RevenueFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.byRecordDate() {
+		if f.StartDate != nil {
+			db = db.Where("employee_revenue.record_date >= ?", f.StartDate.Format("2006-01-02"))
+		}
+		if f.EndDate != nil {
+			db = db.Where("employee_revenue.record_date <= ?", f.EndDate.Format("2006-01-02"))
+		}
+	} else {
+		if f.StartDate != nil {
+			db = db.Where("employee_revenue.record_time >= ?", f.StartDate.UTC())
+		}
+		if f.EndDate != nil {
+			db = db.Where("employee_revenue.record_time < ?", f.EndDate.AddDate(0, 0, 1).UTC())
+		}
+	}
+	if f.UserID != 0 {
+		db = db.Where("employee_revenue.user_id = ?", f.UserID)
+	}
+	if f.AdPlatform != "" {
+		db = db.Where("employee_revenue.ad_platform = ?", f.AdPlatform)
+	}
+	if f.AdType != "" {
+		db = db.Where("employee_revenue.ad_type = ?", f.AdType)
+	}
+	if f.Region != "" {
+		db = db.Where("employee_revenue.region = ?", f.Region)
+	}
+	if f.ProductCategories != "" {
+		db = db.Where("employee_revenue.product_categories = ?", f.ProductCategories)
+	}
+	return db
+}
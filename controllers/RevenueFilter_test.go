@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 记录时间带有不同的时区偏移时，按日期筛选仍然要找到当天的记录
+func TestRevenueFilterDateRange(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", 3)
+
+	// 2025-03-09T18:00Z 以 +08:00 的偏移写入（历史数据），业务日期（UTC）为 2025-03-09
+	if err := db.Exec(`INSERT INTO employee_revenue (created_at, updated_at, user_id, ad_platform, product_categories, ad_type, region,
+		expenditure, revenue, currency, record_time, record_date) VALUES (?, ?, ?, 'FB', '', 'feed', 'US', 100, 300, 'USD',
+		'2025-03-10 02:00:00+08:00', '2025-03-09')`, time.Now(), time.Now(), user.ID).Error; err != nil {
+		t.Fatalf("Failed to insert record: %v", err)
+	}
+	// 新写入的记录统一以 UTC 保存
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	record := models.EmployeeRevenue{UserID: user.ID, AdPlatform: "FB", AdType: "search", Region: "US", Currency: "USD",
+		RecordTime: time.Date(2025, 3, 10, 7, 30, 0, 0, shanghai), RecordDate: "2025-03-09"}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatalf("Failed to create record: %v", err)
+	}
+
+	count := func(query string) int64 {
+		gin.SetMode(gin.TestMode)
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/?"+query, nil)
+		f, err := parseRevenueFilter(ctx)
+		if err != nil {
+			t.Fatalf("parseRevenueFilter(%s): %v", query, err)
+		}
+		var n int64
+		f.Apply(db.Model(&models.EmployeeRevenue{})).Count(&n)
+		return n
+	}
+
+	if n := count("startDate=2025-03-09&endDate=2025-03-09&tz=UTC"); n != 2 {
+		t.Errorf("tz=UTC 2025-03-09: got %d records, want 2", n)
+	}
+	if n := count("startDate=2025-03-10&endDate=2025-03-10&tz=UTC"); n != 0 {
+		t.Errorf("tz=UTC 2025-03-10: got %d records, want 0", n)
+	}
+	// 非 revenue.timezone 的 tz 按 record_time 筛选：两条记录在上海都是 3 月 10 日
+	if n := count("startDate=2025-03-10&endDate=2025-03-10&tz=Asia/Shanghai"); n != 2 {
+		t.Errorf("tz=Asia/Shanghai 2025-03-10: got %d records, want 2", n)
+	}
+	if n := count("startDate=2025-03-09&endDate=2025-03-09&tz=Asia/Shanghai"); n != 0 {
+		t.Errorf("tz=Asia/Shanghai 2025-03-09: got %d records, want 0", n)
+	}
+
+	var stored models.EmployeeRevenue
+	db.First(&stored, record.ID)
+	if _, offset := stored.RecordTime.Zone(); offset != 0 {
+		t.Errorf("record_time stored with offset %d, want UTC", offset)
+	}
+}
+
+// 区间超过时间桶上限时返回 400，且最多只生成 limit+1 个时间桶
+func TestRevenueTimeseriesBucketLimit(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	c := NewEmployeeRevenueController(db)
+
+	start, end := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	if points, index := emptyRevenuePoints(start, end, "day", maxTimeseriesBuckets); len(points) != maxTimeseriesBuckets+1 || len(index) != len(points) {
+		t.Errorf("emptyRevenuePoints generated %d buckets, want %d", len(points), maxTimeseriesBuckets+1)
+	}
+
+	w := performRequest(c.GetRevenueTimeseries, http.MethodGet, "/?startDate=0001-01-01&endDate=9999-12-31&interval=day&tz=UTC",
+		"", admin.ID, utils.RoleAdmin)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("huge range: status %d, want 400", w.Code)
+	}
+	w = performRequest(c.GetRevenueTimeseries, http.MethodGet, "/?startDate=2025-03-01&endDate=2025-03-03&interval=day&tz=UTC",
+		"", admin.ID, utils.RoleAdmin)
+	var resp struct {
+		Data []RevenuePoint `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Data) != 3 {
+		t.Errorf("three days: status %d, %d buckets; want 200 and 3", w.Code, len(resp.Data))
+	}
+}
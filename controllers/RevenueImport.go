@@ -0,0 +1,583 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRevenueImportRows 单次导入的数据行上限
+const maxRevenueImportRows = 10000
+
+// revenueImportFields 可导入的字段，以及未配置 mapping 时自动匹配的表头（不区分大小写，含导出文件的中文表头）
+var revenueImportFields = map[string][]string{
+	"user":               {"user", "user_id", "username", "员工ID"},
+	"record_time":        {"record_time", "date", "day", "日期", "记录时间"},
+	"ad_platform":        {"ad_platform", "platform", "广告平台"},
+	"product_categories": {"product_categories", "category", "商品分类"},
+	"ad_type":            {"ad_type", "广告类型"},
+	"region":             {"region", "地区"},
+	"expenditure":        {"expenditure", "spend", "cost", "广告支出"},
+	"revenue":            {"revenue", "sales", "销售额"},
+	"order_count":        {"order_count", "orders", "订单数"},
+	"ad_creation_count":  {"ad_creation_count", "上新数"},
+	"product_cost":       {"product_cost", "cogs", "商品成本"},
+	"platform_fee":       {"platform_fee", "fees", "平台手续费"},
+	"shipping_fee":       {"shipping_fee", "shipping", "shipping_cost", "运费"},
+	"currency":           {"currency", "币种", "原币种"},
+	"remark":             {"remark", "备注"},
+}
+
+// revenueImportRequired 去重键中的字段，必须来自某一列或 defaults
+var revenueImportRequired = []string{"record_time", "ad_platform", "ad_type", "region"}
+
+// RevenueImportRow 导入报告中每一行的处理结果
+type RevenueImportRow struct {
+	Line     int      `json:"line"`   // 文件中的行号（从 1 开始，含表头）
+	Action   string   `json:"action"` // create / update / error
+	RecordID uint     `json:"record_id,omitempty"`
+	UserID   uint     `json:"user_id,omitempty"`
+	Date     string   `json:"date,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// revenueImportOptions 导入参数
+type revenueImportOptions struct {
+	Columns     map[string]int    // 字段 -> 列下标
+	Defaults    map[string]string // 文件中没有的列使用的固定值
+	Location    *time.Location    // 日期所在时区
+	OperatorID  uint
+	CanAssign   bool // 是否允许导入其他员工的数据（管理员、财务）
+	userCache   map[string]uint
+	userMissing map[string]bool
+}
+
+// ImportEmployeeRevenue 批量导入每日广告数据（multipart 上传 file，.csv 或 .xlsx 的第一个工作表）。
+// 参数（query 或表单）：mapping 为 JSON 对象（字段 -> 表头名，未配置的字段按常见表头自动匹配）；
+// defaults 为 JSON 对象（字段 -> 固定值，如某平台导出的报表没有平台列）；header_row 表头所在行（默认 1）；
+// tz 日期所在时区（默认为 revenue.timezone）；dry_run=true 只预览；skip_invalid=true 时跳过有错误的行，否则有任何错误都不写入。
+// 以 员工 + 日期 + 广告平台 + 广告类型 + 地区 为键，已有记录则更新，否则新建，全部在一个事务中完成。
+// 只有管理员和财务可以通过 user 列导入其他员工的数据，其他人只能导入自己的
+func (c *employeeRevenueController) ImportEmployeeRevenue(ctx *gin.Context) {
+	operatorID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	param := func(name string) string { return ctx.DefaultQuery(name, ctx.PostForm(name)) }
+	dryRun, _ := strconv.ParseBool(param("dry_run"))
+	skipInvalid, _ := strconv.ParseBool(param("skip_invalid"))
+
+	opts := revenueImportOptions{
+		OperatorID:  operatorID,
+		CanAssign:   role <= utils.RoleFinance,
+		Location:    utils.RevenueLocation(),
+		userCache:   make(map[string]uint),
+		userMissing: make(map[string]bool),
+	}
+	if tz := param("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tz, expected an IANA time zone such as Asia/Shanghai"})
+			return
+		}
+		opts.Location = loc
+	}
+	headerRow := 1
+	if value := param("header_row"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid header_row"})
+			return
+		}
+		headerRow = n
+	}
+	mapping, err := parseImportFieldMap(param("mapping"), "mapping")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Defaults, err = parseImportFieldMap(param("defaults"), "defaults"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := readRevenueImportFile(ctx, headerRow)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) < headerRow {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Header row not found"})
+		return
+	}
+	if opts.Columns, err = mapImportColumns(rows[headerRow-1].Cells, mapping, opts.Defaults); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rows = rows[headerRow:]
+	if len(rows) > maxRevenueImportRows {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d rows can be imported at once", maxRevenueImportRows)})
+		return
+	}
+
+	results := make([]RevenueImportRow, 0, len(rows))
+	summary := map[string]int{"create": 0, "update": 0, "error": 0}
+	committed := false
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		records := make([]*models.EmployeeRevenue, 0, len(rows))
+		seen := make(map[string]int) // 去重键 -> 首次出现的行号
+		for _, row := range rows {
+			if isBlankRow(row.Cells) {
+				continue
+			}
+			result := RevenueImportRow{Line: row.Line}
+			record, errs := parseRevenueImportRow(tx, row.Cells, &opts)
+			if record != nil {
+				result.UserID = record.UserID
+				result.Date = record.RecordDate
+				key := strings.Join([]string{strconv.FormatUint(uint64(record.UserID), 10), result.Date,
+					record.AdPlatform, record.AdType, record.Region}, "\x00")
+				if line, ok := seen[key]; ok {
+					errs = append(errs, fmt.Sprintf("duplicate of line %d (same user, date, platform, ad type and region)", line))
+				} else {
+					seen[key] = row.Line
+				}
+			}
+
+			if len(errs) == 0 {
+				existingID, err := findRevenueByKey(tx, record)
+				if err != nil {
+					var conflict *revenueKeyConflict
+					if !errors.As(err, &conflict) {
+						return err
+					}
+					errs = append(errs, conflict.Error())
+				} else if existingID != 0 {
+					record.ID = existingID
+					result.Action = "update"
+					result.RecordID = existingID
+				} else {
+					result.Action = "create"
+				}
+			}
+			if len(errs) > 0 {
+				result.Action = "error"
+				result.Errors = errs
+				record = nil
+			}
+			results = append(results, result)
+			summary[result.Action]++
+			records = append(records, record)
+		}
+
+		if dryRun || (summary["error"] > 0 && !skipInvalid) {
+			return nil
+		}
+		for i, record := range records {
+			if record == nil {
+				continue
+			}
+			if err := saveImportedRevenue(tx, record, &opts); err != nil {
+				return err
+			}
+			results[i].RecordID = record.ID
+		}
+		committed = true
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import records"})
+		return
+	}
+
+	resp := gin.H{
+		"dry_run":   dryRun,
+		"committed": committed,
+		"total":     len(results),
+		"created":   summary["create"],
+		"updated":   summary["update"],
+		"failed":    summary["error"],
+		"rows":      results,
+	}
+	if !dryRun && !committed {
+		resp["error"] = fmt.Sprintf("%d rows are invalid, nothing was imported (use skip_invalid=true to import the valid rows)", summary["error"])
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// parseImportFieldMap 解析 字段 -> 值 的 JSON 对象，字段必须是可导入的字段
+func parseImportFieldMap(value, param string) (map[string]string, error) {
+	result := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, fmt.Errorf("Invalid %s, expected a JSON object", param)
+	}
+	for field := range result {
+		if _, ok := revenueImportFields[field]; !ok {
+			return nil, fmt.Errorf("Invalid %s: unknown field %s", param, field)
+		}
+	}
+	return result, nil
+}
+
+// readRevenueImportFile 读取上传的 CSV（根据表头行自动识别逗号、分号、制表符分隔，去掉 BOM）或 XLSX 文件
+func readRevenueImportFile(ctx *gin.Context, headerRow int) ([]utils.XLSXRow, error) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, errors.New("file is required (csv or xlsx)")
+	}
+	if fileHeader.Size > maxImportUpload {
+		return nil, errors.New("Uploaded file is too large")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(path.Ext(fileHeader.Filename)) {
+	case ".xlsx":
+		// 多读一行，超出上限时由调用方报错
+		return utils.ReadXLSXRows(data, headerRow+maxRevenueImportRows+1)
+	case ".csv", ".txt":
+		return readImportCSV(data, headerRow)
+	default:
+		return nil, errors.New("Unsupported file type, expected .csv or .xlsx")
+	}
+}
+
+func readImportCSV(data []byte, headerRow int) ([]utils.XLSXRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	// 按表头行识别分隔符（表头之前可能有标题等说明行）
+	lines := bytes.SplitN(data, []byte("\n"), headerRow+1)
+	headerLine := lines[len(lines)-1]
+	if len(lines) > headerRow {
+		headerLine = lines[headerRow-1]
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	best := 0
+	for _, sep := range []rune{',', ';', '\t'} {
+		if n := bytes.Count(headerLine, []byte(string(sep))); n > best {
+			best, r.Comma = n, sep
+		}
+	}
+
+	var rows []utils.XLSXRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, utils.XLSXRow{Line: line, Cells: record})
+	}
+}
+
+// mapImportColumns 根据表头确定每个字段所在的列：优先使用 mapping 中配置的表头，其次按默认表头匹配
+func mapImportColumns(header []string, mapping, defaults map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[name]; !ok && name != "" {
+			index[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range revenueImportFields {
+		if name, ok := mapping[field]; ok {
+			i, found := index[strings.ToLower(strings.TrimSpace(name))]
+			if !found {
+				return nil, fmt.Errorf("Column %q for %s not found in the header", name, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, alias := range aliases {
+			if i, found := index[strings.ToLower(alias)]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	for _, field := range revenueImportRequired {
+		if _, ok := columns[field]; !ok {
+			if _, ok := defaults[field]; !ok {
+				return nil, fmt.Errorf("No column for %s, map it with mapping or set it in defaults", field)
+			}
+		}
+	}
+	return columns, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRevenueImportRow 校验并转换一行数据，返回全部错误（而不是遇到第一个就停止）
+func parseRevenueImportRow(db *gorm.DB, cells []string, opts *revenueImportOptions) (*models.EmployeeRevenue, []string) {
+	value := func(field string) string {
+		if i, ok := opts.Columns[field]; ok && i < len(cells) {
+			if v := strings.TrimSpace(cells[i]); v != "" {
+				return v
+			}
+		}
+		return strings.TrimSpace(opts.Defaults[field])
+	}
+
+	var errs []string
+	record := &models.EmployeeRevenue{
+		UserID:            opts.OperatorID,
+		AdPlatform:        value("ad_platform"),
+		ProductCategories: value("product_categories"),
+		AdType:            value("ad_type"),
+		Region:            value("region"),
+		Remark:            value("remark"),
+	}
+	for _, field := range []string{"ad_platform", "ad_type", "region"} {
+		if value(field) == "" {
+			errs = append(errs, field+" is required")
+		}
+	}
+
+	if raw := value("user"); raw != "" {
+		userID, err := opts.resolveUser(db, raw)
+		switch {
+		case err != nil:
+			errs = append(errs, err.Error())
+		case userID != opts.OperatorID && !opts.CanAssign:
+			errs = append(errs, "only admins and finance can import records for other users")
+		default:
+			record.UserID = userID
+		}
+	}
+
+	if raw := value("record_time"); raw == "" {
+		errs = append(errs, "record_time is required")
+	} else if day, err := parseImportDate(raw, opts.Location); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid record_time %q", raw))
+	} else {
+		record.RecordTime = day.UTC()
+		record.RecordDate = day.Format("2006-01-02")
+	}
+
+	parseMoney := func(field string) models.Money {
+		raw := value(field)
+		if raw == "" {
+			return 0
+		}
+		v, err := parseImportMoney(raw)
+		if err != nil || v < 0 {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", field, raw))
+			return 0
+		}
+		return v
+	}
+	parseCount := func(field string) int {
+		raw := value(field)
+		if raw == "" {
+			return 0
+		}
+		v, err := parseImportNumber(raw)
+		if err != nil || v < 0 || v != math.Trunc(v) {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", field, raw))
+			return 0
+		}
+		return int(v)
+	}
+	record.Expenditure = parseMoney("expenditure")
+	record.Revenue = parseMoney("revenue")
+	record.OrderCount = parseCount("order_count")
+	record.AdCreationCount = parseCount("ad_creation_count")
+	record.ProductCost = parseMoney("product_cost")
+	record.PlatformFee = parseMoney("platform_fee")
+	record.ShippingFee = parseMoney("shipping_fee")
+
+	// 币种列为空时使用基础币种
+	record.Currency = utils.RevenueBaseCurrency()
+	if raw := value("currency"); raw != "" {
+		if code, ok := models.NormalizeCurrency(raw); ok {
+			record.Currency = code
+		} else {
+			errs = append(errs, fmt.Sprintf("invalid currency %q", raw))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return record, nil
+}
+
+// resolveUser user 列可以是用户ID或用户名
+func (o *revenueImportOptions) resolveUser(db *gorm.DB, raw string) (uint, error) {
+	if id, ok := o.userCache[raw]; ok {
+		return id, nil
+	}
+	if o.userMissing[raw] {
+		return 0, fmt.Errorf("user %q not found", raw)
+	}
+	var user models.Users
+	query := db.Select("id")
+	if id, err := strconv.ParseUint(raw, 10, 64); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("username = ?", raw)
+	}
+	if err := query.First(&user).Error; err != nil {
+		o.userMissing[raw] = true
+		return 0, fmt.Errorf("user %q not found", raw)
+	}
+	o.userCache[raw] = user.ID
+	return user.ID, nil
+}
+
+// parseImportDate 解析日期（取日期部分，按 loc 时区的 00:00），支持常见格式与 Excel 日期序列号
+func parseImportDate(raw string, loc *time.Location) (time.Time, error) {
+	var t time.Time
+	var err error
+	if len(raw) == 8 && strings.Trim(raw, "0123456789") == "" {
+		t, err = time.ParseInLocation("20060102", raw, loc)
+	} else if serial, numErr := strconv.ParseFloat(raw, 64); numErr == nil {
+		// Excel 序列号：1900 日期系统，以 1899-12-30 为 0
+		if serial < 1 || serial > 2958465 {
+			return time.Time{}, errors.New("date serial out of range")
+		}
+		t = time.Date(1899, 12, 30, 0, 0, 0, 0, loc).AddDate(0, 0, int(serial))
+	} else {
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02",
+			"2006/01/02 15:04:05", "2006/01/02 15:04", "2006/01/02", "2006-1-2", "2006/1/2", "2006.01.02", "2006年1月2日"} {
+			if t, err = time.ParseInLocation(layout, raw, loc); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// parseImportNumber 解析数字，允许千分位逗号和常见货币符号
+func parseImportNumber(raw string) (float64, error) {
+	return strconv.ParseFloat(importNumberCleaner.Replace(raw), 64)
+}
+
+// importNumberCleaner 去掉千分位、货币符号和空格
+var importNumberCleaner = strings.NewReplacer(",", "", "$", "", "¥", "", "￥", "", "€", "", " ", "")
+
+// parseImportMoney 按十进制精确解析金额（精确到分）；Excel 导出的科学计数法等写法按浮点数解析后取整到分
+func parseImportMoney(raw string) (models.Money, error) {
+	raw = importNumberCleaner.Replace(raw)
+	if m, err := models.ParseMoney(raw); err == nil {
+		return m, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return models.MoneyFromFloat(v), nil
+}
+
+// revenueKeyConflict 数据库中已有多条记录对应同一个去重键
+type revenueKeyConflict struct{ ids []uint }
+
+func (e *revenueKeyConflict) Error() string {
+	return fmt.Sprintf("%d existing records (%v) share the same user, date, platform, ad type and region, merge them first", len(e.ids), e.ids)
+}
+
+// findRevenueByKey 查找与 record 唯一键相同的已有记录，没有时返回 0
+func findRevenueByKey(tx *gorm.DB, record *models.EmployeeRevenue) (uint, error) {
+	ids, err := findRevenueByNaturalKey(tx, record, 0)
+	if err != nil {
+		return 0, err
+	}
+	switch len(ids) {
+	case 0:
+		return 0, nil
+	case 1:
+		return ids[0], nil
+	}
+	return 0, &revenueKeyConflict{ids: ids}
+}
+
+// hasField 文件中有该列或 defaults 中配置了该字段
+func (o *revenueImportOptions) hasField(field string) bool {
+	if _, ok := o.Columns[field]; ok {
+		return true
+	}
+	_, ok := o.Defaults[field]
+	return ok
+}
+
+// saveImportedRevenue 新建或更新（record.ID 非 0）导入的记录，并计算 ROI。
+// 更新时只覆盖文件中有（或 defaults 配置了）的字段，其余保留原值
+func saveImportedRevenue(tx *gorm.DB, record *models.EmployeeRevenue, opts *revenueImportOptions) error {
+	if record.ID == 0 {
+		record.ROI = revenueROI(record.Revenue, record.Expenditure)
+		return tx.Create(record).Error
+	}
+
+	values := map[string]interface{}{
+		"product_categories": record.ProductCategories,
+		"expenditure":        record.Expenditure,
+		"revenue":            record.Revenue,
+		"order_count":        record.OrderCount,
+		"ad_creation_count":  record.AdCreationCount,
+		"product_cost":       record.ProductCost,
+		"platform_fee":       record.PlatformFee,
+		"shipping_fee":       record.ShippingFee,
+		"currency":           record.Currency,
+		"remark":             record.Remark,
+	}
+	updates := make(map[string]interface{})
+	for field, value := range values {
+		if opts.hasField(field) {
+			updates[field] = value
+		}
+	}
+	existing := tx.Model(&models.EmployeeRevenue{}).Where("id = ?", record.ID)
+	if len(updates) > 0 {
+		if err := existing.Session(&gorm.Session{}).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	// 销售额或广告支出可能只更新了其中一个，按更新后的值重新计算 ROI（金额为整数，先转成浮点数再相除）
+	return existing.UpdateColumn("roi", gorm.Expr("CASE WHEN expenditure <> 0 THEN ROUND(CAST(revenue AS REAL) / expenditure, 2) ELSE 0 END")).Error
+}
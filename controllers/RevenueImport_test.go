@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revenueImportResponse 导入接口的响应
+type revenueImportResponse struct {
+	DryRun    bool               `json:"dry_run"`
+	Committed bool               `json:"committed"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Failed    int                `json:"failed"`
+	Rows      []RevenueImportRow `json:"rows"`
+}
+
+// importRevenue 以 userID、role 的身份上传 content 作为 filename 导入，query 为额外的查询参数
+func importRevenue(t *testing.T, c EmployeeRevenueController, userID uint, role int, filename, content, query string) (int, revenueImportResponse) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", filename)
+	part.Write([]byte(content))
+	form.Close()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/import?"+query, &body)
+	ctx.Request.Header.Set("Content-Type", form.FormDataContentType())
+	ctx.Set("userId", userID)
+	ctx.Set("role", role)
+	c.ImportEmployeeRevenue(ctx)
+
+	var resp revenueImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response %s: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+// 带 BOM、以分号分隔的 CSV（欧洲地区 Excel 的默认格式）
+func TestImportRevenueCSVWithBOMAndSemicolons(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+
+	code, resp := importRevenue(t, c, user.ID, utils.RoleMarketer, "report.csv",
+		"\xEF\xBB\xBFdate;platform;ad_type;region;spend;revenue;orders\n"+
+			"2025-03-10;FB;feed;US;\"1,234.50\";3000;12\n"+
+			"\n"+
+			"2025-03-11;FB;feed;US;100;250.25;3\n", "")
+	if code != http.StatusOK || !resp.Committed || resp.Created != 2 {
+		t.Fatalf("status %d, response %+v; want 200 with 2 created", code, resp)
+	}
+
+	var records []models.EmployeeRevenue
+	db.Order("record_date").Find(&records)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	first := records[0]
+	if first.UserID != user.ID || first.RecordDate != "2025-03-10" || first.AdPlatform != "FB" ||
+		first.Expenditure != 123450 || first.Revenue != 300000 || first.OrderCount != 12 || first.ROI != 2.43 {
+		t.Errorf("unexpected first record %+v", first)
+	}
+	if records[1].Revenue != 25025 || resp.Rows[1].Line != 4 {
+		t.Errorf("second record revenue %v at line %d, want 250.25 at line 4", records[1].Revenue, resp.Rows[1].Line)
+	}
+}
+
+// 文件内重复的去重键：默认整体不写入；skip_invalid=true 时只导入第一次出现的行
+func TestImportRevenueDuplicateLine(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+	content := "date,platform,ad_type,region,revenue\n" +
+		"2025-03-10,FB,feed,US,10\n" +
+		"2025-03-10,FB,feed,US,20\n"
+
+	code, resp := importRevenue(t, c, user.ID, utils.RoleMarketer, "report.csv", content, "")
+	if code != http.StatusBadRequest || resp.Committed || resp.Failed != 1 {
+		t.Fatalf("status %d, response %+v; want 400 with 1 failed", code, resp)
+	}
+	if row := resp.Rows[1]; row.Action != "error" || len(row.Errors) != 1 || !strings.Contains(row.Errors[0], "duplicate of line 2") {
+		t.Errorf("unexpected duplicate row %+v", row)
+	}
+	var count int64
+	db.Model(&models.EmployeeRevenue{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("got %d records after rejected import, want 0", count)
+	}
+
+	code, resp = importRevenue(t, c, user.ID, utils.RoleMarketer, "report.csv", content, "skip_invalid=true")
+	if code != http.StatusOK || resp.Created != 1 || resp.Failed != 1 {
+		t.Fatalf("skip_invalid: status %d, response %+v; want 200 with 1 created and 1 failed", code, resp)
+	}
+	var record models.EmployeeRevenue
+	db.First(&record)
+	if record.Revenue != 1000 {
+		t.Errorf("revenue = %v, want 10.00 from the first line", record.Revenue)
+	}
+}
+
+// dry_run 只返回预览，不写入也不修改任何记录
+func TestImportRevenueDryRun(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	existing := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 1000}, "2025-03-10")
+	c := NewEmployeeRevenueController(db)
+
+	code, resp := importRevenue(t, c, user.ID, utils.RoleMarketer, "report.csv",
+		"date,platform,ad_type,region,revenue\n"+
+			"2025-03-10,FB,feed,US,99\n"+
+			"2025-03-11,FB,feed,US,20\n", "dry_run=true")
+	if code != http.StatusOK || !resp.DryRun || resp.Committed || resp.Created != 1 || resp.Updated != 1 {
+		t.Fatalf("status %d, response %+v; want 200 dry run with 1 created and 1 updated", code, resp)
+	}
+	if resp.Rows[0].RecordID != existing.ID {
+		t.Errorf("update row record_id = %d, want %d", resp.Rows[0].RecordID, existing.ID)
+	}
+
+	var records []models.EmployeeRevenue
+	db.Find(&records)
+	if len(records) != 1 || records[0].Revenue != 1000 {
+		t.Errorf("dry run changed the records: %+v", records)
+	}
+}
+
+// 更新已有记录时只覆盖文件中有的列，其余字段保留原值，ROI 按更新后的值重新计算
+func TestImportRevenuePartialUpdate(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	existing := createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, ProductCategories: "Shoes",
+		Expenditure: 1000, Revenue: 2000, OrderCount: 5, ProductCost: 300, Remark: "manual", ROI: 2}, "2025-03-10")
+	c := NewEmployeeRevenueController(db)
+
+	code, resp := importRevenue(t, c, user.ID, utils.RoleMarketer, "report.csv",
+		"date,platform,ad_type,region,revenue\n2025-03-10,FB,feed,US,45\n", "")
+	if code != http.StatusOK || resp.Updated != 1 {
+		t.Fatalf("status %d, response %+v; want 200 with 1 updated", code, resp)
+	}
+
+	var record models.EmployeeRevenue
+	db.First(&record, existing.ID)
+	if record.Revenue != 4500 || record.ROI != 4.5 {
+		t.Errorf("revenue %v, roi %v; want 45.00 and 4.5", record.Revenue, record.ROI)
+	}
+	if record.ProductCategories != "Shoes" || record.Expenditure != 1000 || record.OrderCount != 5 ||
+		record.ProductCost != 300 || record.Remark != "manual" || record.Currency != existing.Currency {
+		t.Errorf("fields missing from the file were overwritten: %+v", record)
+	}
+}
+
+// 投手只能导入自己的数据；管理员可以通过 user 列导入其他员工的数据
+func TestImportRevenueForOtherUser(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	marketer := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	other := createTestUser(t, db, "other", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+	content := "user,date,platform,ad_type,region,revenue\nother,2025-03-10,FB,feed,US,10\n"
+
+	code, resp := importRevenue(t, c, marketer.ID, utils.RoleMarketer, "report.csv", content, "")
+	if code != http.StatusBadRequest || resp.Failed != 1 {
+		t.Fatalf("marketer: status %d, response %+v; want 400 with 1 failed", code, resp)
+	}
+	if errs := resp.Rows[0].Errors; len(errs) != 1 || !strings.Contains(errs[0], "only admins and finance") {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	var count int64
+	db.Model(&models.EmployeeRevenue{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("got %d records after rejected import, want 0", count)
+	}
+
+	// 自己的用户名可以出现在 user 列中
+	code, _ = importRevenue(t, c, marketer.ID, utils.RoleMarketer, "report.csv",
+		"user,date,platform,ad_type,region,revenue\nmarketer,2025-03-10,FB,feed,US,10\n", "")
+	if code != http.StatusOK {
+		t.Errorf("marketer importing own records: status %d, want 200", code)
+	}
+
+	code, resp = importRevenue(t, c, admin.ID, utils.RoleAdmin, "report.csv", content, "")
+	if code != http.StatusOK || resp.Created != 1 || resp.Rows[0].UserID != other.ID {
+		t.Fatalf("admin: status %d, response %+v; want 200 with a record for user %d", code, resp, other.ID)
+	}
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLeaderboardDays 未指定日期时排行榜统计最近多少天（含今天）
+const defaultLeaderboardDays = 30
+
+// leaderboardMetrics 排行榜可选的排名指标
+var leaderboardMetrics = map[string]func(t *userRevenueTotals) float64{
+	"revenue":      func(t *userRevenueTotals) float64 { return t.Revenue.Float() },
+	"roi":          func(t *userRevenueTotals) float64 { return revenueROI(t.Revenue, t.Expenditure) },
+	"orders":       func(t *userRevenueTotals) float64 { return float64(t.OrderCount) },
+	"new_products": func(t *userRevenueTotals) float64 { return float64(t.AdCreationCount) },
+}
+
+// LeaderboardEntry 排行榜中的一名员工
+type LeaderboardEntry struct {
+	Rank            int          `json:"rank"`          // 并列时名次相同，下一名跳过（1、2、2、4）
+	PreviousRank    *int         `json:"previous_rank"` // 上一周期的名次，上一周期未上榜时为 null
+	RankChange      *int         `json:"rank_change"`   // 名次变化，正数为上升
+	UserID          uint         `json:"user_id"`
+	Nickname        string       `json:"nickname"`
+	Avatar          string       `json:"avatar"`
+	Value           float64      `json:"value"` // 排名指标的值
+	Expenditure     models.Money `json:"expenditure"`
+	Revenue         models.Money `json:"revenue"`
+	ROI             float64      `json:"roi"`
+	OrderCount      int64        `json:"order_count"`
+	AdCreationCount int64        `json:"ad_creation_count"`
+}
+
+// GetRevenueLeaderboard 投手（RoleMarketer）排行榜，只包含周期内有收益记录的员工。
+// metric 为 revenue（默认）、roi、orders、new_products；startDate/endDate 默认为最近 30 天，与紧邻的上一个等长周期比较名次变化。
+// ROI 榜只包含广告支出不低于 minSpend（报表币种，默认为 revenue.leaderboard_min_spend 换算后的金额）的员工；
+// 也支持 adPlatform、adType、region、productCategories、tz、currency 筛选参数，limit 限制返回条数
+func (c *employeeRevenueController) GetRevenueLeaderboard(ctx *gin.Context) {
+	metric := ctx.DefaultQuery("metric", "revenue")
+	value, ok := leaderboardMetrics[metric]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metric, expected revenue, roi, orders or new_products"})
+		return
+	}
+	filter, err := parseRevenueFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = 0
+	limit := 0
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	// 统计周期及上一个等长周期
+	now := time.Now().In(filter.Location)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, filter.Location)
+	if filter.EndDate != nil {
+		end = *filter.EndDate
+	}
+	start := end.AddDate(0, 0, 1-defaultLeaderboardDays)
+	if filter.StartDate != nil {
+		start = *filter.StartDate
+	}
+	if end.Before(start) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "endDate must not be before startDate"})
+		return
+	}
+	if end.Sub(start) > 366*24*time.Hour {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Date range must not exceed one year"})
+		return
+	}
+	days := int(end.Sub(start).Hours()/24+0.5) + 1
+	prevEnd := start.AddDate(0, 0, -1)
+	prevStart := prevEnd.AddDate(0, 0, 1-days)
+
+	conv, err := newCurrencyConverter(c.db, filter.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	minSpend := models.MoneyFromFloat(utils.AppConfig.Revenue.LeaderboardMinSpend)
+	if minSpendStr := ctx.Query("minSpend"); minSpendStr != "" {
+		if minSpend, err = models.ParseMoney(minSpendStr); err != nil || minSpend < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid minSpend"})
+			return
+		}
+	} else if minSpend, err = conv.convert(minSpend, conv.base, end.Format("2006-01-02")); err != nil {
+		if !respondMissingRate(ctx, err) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		}
+		return
+	}
+
+	var marketers []models.Users
+	if err := c.db.Select("id, nickname, avatar").Where("role = ?", utils.RoleMarketer).Order("id").Find(&marketers).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	userIDs := make([]uint, 0, len(marketers))
+	for _, user := range marketers {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	// 按周期汇总、换算币种并排名，返回按名次排列的榜单，以及因广告支出不足未参与 ROI 排名的人数。
+	// 周期内没有收益记录的员工不上榜
+	rankPeriod := func(from, to time.Time) ([]LeaderboardEntry, int, error) {
+		period := filter
+		period.StartDate, period.EndDate = &from, &to
+		daily, err := loadUserRevenueDaily(period.Apply(c.db.Where("employee_revenue.user_id IN ?", userIDs)))
+		if err != nil {
+			return nil, 0, err
+		}
+		totals, err := sumUserRevenue(daily, conv)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries := make([]LeaderboardEntry, 0, len(totals))
+		excluded := 0
+		for _, user := range marketers {
+			total := totals[user.ID]
+			if total == nil {
+				continue
+			}
+			// 没有广告支出时 ROI 没有意义
+			if metric == "roi" && (total.Expenditure == 0 || total.Expenditure < minSpend) {
+				excluded++
+				continue
+			}
+			entries = append(entries, LeaderboardEntry{
+				UserID:          user.ID,
+				Nickname:        user.Nickname,
+				Avatar:          user.Avatar,
+				Value:           value(total),
+				Expenditure:     total.Expenditure,
+				Revenue:         total.Revenue,
+				ROI:             revenueROI(total.Revenue, total.Expenditure),
+				OrderCount:      total.OrderCount,
+				AdCreationCount: total.AdCreationCount,
+			})
+		}
+		rankLeaderboard(entries)
+		return entries, excluded, nil
+	}
+
+	var current, previous []LeaderboardEntry
+	excluded := 0
+	if len(userIDs) > 0 {
+		if current, excluded, err = rankPeriod(start, end); err == nil {
+			previous, _, err = rankPeriod(prevStart, prevEnd)
+		}
+		if err != nil {
+			if !respondMissingRate(ctx, err) {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+			}
+			return
+		}
+	}
+
+	previousRanks := make(map[uint]int, len(previous))
+	for _, entry := range previous {
+		previousRanks[entry.UserID] = entry.Rank
+	}
+	data := make([]LeaderboardEntry, 0, len(current))
+	for _, entry := range current {
+		if rank, ok := previousRanks[entry.UserID]; ok {
+			change := rank - entry.Rank
+			entry.PreviousRank, entry.RankChange = &rank, &change
+		}
+		data = append(data, entry)
+	}
+	if limit > 0 && len(data) > limit {
+		data = data[:limit]
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"metric":              metric,
+		"currency":            filter.Currency,
+		"start_date":          start.Format("2006-01-02"),
+		"end_date":            end.Format("2006-01-02"),
+		"previous_start_date": prevStart.Format("2006-01-02"),
+		"previous_end_date":   prevEnd.Format("2006-01-02"),
+		"min_spend":           minSpend,
+		"excluded_count":      excluded,
+		"data":                data,
+	})
+}
+
+// rankLeaderboard 按 Value 从高到低排序并计算名次；值相同的名次并列，按用户ID排列
+func rankLeaderboard(entries []LeaderboardEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// 并列的名次相同、下一名跳过；名次变化与上一个等长周期比较；只包含投手
+func TestRevenueLeaderboardRanks(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	m1 := createTestUser(t, db, "m1", utils.RoleMarketer)
+	m2 := createTestUser(t, db, "m2", utils.RoleMarketer)
+	m3 := createTestUser(t, db, "m3", utils.RoleMarketer)
+	m4 := createTestUser(t, db, "m4", utils.RoleMarketer)
+	regular := createTestUser(t, db, "regular", utils.RoleUser)
+	c := NewEmployeeRevenueController(db)
+
+	// 本周期 2025-03-08 ~ 2025-03-10
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m1.ID, Revenue: 40000, Expenditure: 10000}, "2025-03-08")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m2.ID, Revenue: 30000, Expenditure: 30000}, "2025-03-09")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m3.ID, Revenue: 10000, Expenditure: 5000}, "2025-03-09")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m3.ID, Revenue: 20000, AdType: "search"}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m4.ID, Revenue: 10000}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: regular.ID, Revenue: 90000, Expenditure: 100}, "2025-03-10")
+	// 上一周期 2025-03-05 ~ 2025-03-07；周期外的记录不计入
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m4.ID, Revenue: 50000}, "2025-03-07")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m1.ID, Revenue: 20000}, "2025-03-05")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m2.ID, Revenue: 10000}, "2025-03-06")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m3.ID, Revenue: 90000}, "2025-03-04")
+
+	leaderboard := func(query string) (int, []LeaderboardEntry, int) {
+		w := performRequest(c.GetRevenueLeaderboard, http.MethodGet, "/?startDate=2025-03-08&endDate=2025-03-10&"+query,
+			"", admin.ID, utils.RoleAdmin)
+		var resp struct {
+			Data          []LeaderboardEntry `json:"data"`
+			ExcludedCount int                `json:"excluded_count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data, resp.ExcludedCount
+	}
+	ptr := func(n int) *int { return &n }
+	intOrNil := func(p *int) interface{} {
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+
+	code, data, _ := leaderboard("metric=revenue")
+	if code != http.StatusOK {
+		t.Fatalf("revenue leaderboard: status %d", code)
+	}
+	want := []struct {
+		userID       uint
+		rank         int
+		previousRank *int
+		rankChange   *int
+	}{
+		{m1.ID, 1, ptr(2), ptr(1)},
+		{m2.ID, 2, ptr(3), ptr(1)},
+		{m3.ID, 2, nil, nil},
+		{m4.ID, 4, ptr(1), ptr(-3)},
+	}
+	if len(data) != len(want) {
+		t.Fatalf("got %d entries %+v, want %d", len(data), data, len(want))
+	}
+	for i, w := range want {
+		got := data[i]
+		if got.UserID != w.userID || got.Rank != w.rank ||
+			intOrNil(got.PreviousRank) != intOrNil(w.previousRank) || intOrNil(got.RankChange) != intOrNil(w.rankChange) {
+			t.Errorf("entry %d = user %d rank %d previous %v change %v; want user %d rank %d previous %v change %v",
+				i, got.UserID, got.Rank, intOrNil(got.PreviousRank), intOrNil(got.RankChange),
+				w.userID, w.rank, intOrNil(w.previousRank), intOrNil(w.rankChange))
+		}
+	}
+
+	if _, data, _ := leaderboard("metric=revenue&limit=2"); len(data) != 2 {
+		t.Errorf("limit=2: got %d entries, want 2", len(data))
+	}
+
+	// ROI 榜：广告支出低于 minSpend（100.00）或为 0 的员工不参与排名
+	code, data, excluded := leaderboard("metric=roi&minSpend=100")
+	if code != http.StatusOK || excluded != 2 || len(data) != 2 {
+		t.Fatalf("roi leaderboard: status %d, excluded %d, %d entries; want 200, 2 excluded, 2 entries", code, excluded, len(data))
+	}
+	if data[0].UserID != m1.ID || data[0].Value != 4 || data[1].UserID != m2.ID || data[1].Rank != 2 {
+		t.Errorf("roi leaderboard = %+v, want m1 (ROI 4) then m2", data)
+	}
+	if _, data, excluded := leaderboard("metric=roi&minSpend=0"); excluded != 1 || len(data) != 3 || data[0].UserID != m3.ID {
+		t.Errorf("roi leaderboard without minimum spend: excluded %d, entries %+v; want m3 first and only m4 excluded", excluded, data)
+	}
+}
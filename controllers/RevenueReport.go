@@ -0,0 +1,392 @@
+package controllers
+
+import (
+	"blog/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxReportLimit 透视报表 top-N 的上限
+const maxReportLimit = 1000
+
+// revenueDimensions 透视报表允许的分组维度：platform、category、ad_type、region、user、time（按 interval 分桶）。
+// 只接受白名单中的名称，分组在应用层完成，不会把参数拼进 SQL
+var revenueDimensions = []string{"platform", "category", "ad_type", "region", "user", "time"}
+
+// revenueMetrics 透视报表可选的指标
+var revenueMetrics = []string{"expenditure", "revenue", "order_count", "ad_creation_count", "roi", "records",
+	"product_cost", "platform_fee", "shipping_fee", "gross_profit", "margin", "roas", "break_even_roi"}
+
+// RevenueAmounts 收益记录的金额字段（统计时已换算为报表币种）；需要导出，gorm 才会读取内嵌字段
+type RevenueAmounts struct {
+	Expenditure models.Money
+	Revenue     models.Money
+	ProductCost models.Money
+	PlatformFee models.Money
+	ShippingFee models.Money
+}
+
+// add 累加另一组金额
+func (a *RevenueAmounts) add(b RevenueAmounts) {
+	a.Expenditure += b.Expenditure
+	a.Revenue += b.Revenue
+	a.ProductCost += b.ProductCost
+	a.PlatformFee += b.PlatformFee
+	a.ShippingFee += b.ShippingFee
+}
+
+// profit 按金额合计计算利润指标
+func (a *RevenueAmounts) profit() models.RevenueProfit {
+	return models.ComputeRevenueProfit(a.Revenue, a.Expenditure, a.ProductCost, a.PlatformFee, a.ShippingFee)
+}
+
+// revenueAggregate 一个分组的累计值
+type revenueAggregate struct {
+	RevenueAmounts
+	Values      map[string]string // 维度取值
+	UserID      uint
+	BucketStart time.Time
+	OrderCount  int64
+	AdCreation  int64
+	Records     int64
+}
+
+// metric 返回指标值（金额由分换算为元）
+func (a *revenueAggregate) metric(name string) float64 {
+	switch name {
+	case "expenditure":
+		return a.Expenditure.Float()
+	case "revenue":
+		return a.Revenue.Float()
+	case "order_count":
+		return float64(a.OrderCount)
+	case "ad_creation_count":
+		return float64(a.AdCreation)
+	case "roi":
+		return revenueROI(a.Revenue, a.Expenditure)
+	case "records":
+		return float64(a.Records)
+	case "product_cost":
+		return a.ProductCost.Float()
+	case "platform_fee":
+		return a.PlatformFee.Float()
+	case "shipping_fee":
+		return a.ShippingFee.Float()
+	case "gross_profit":
+		return a.profit().GrossProfit.Float()
+	case "margin":
+		return a.profit().Margin
+	case "roas":
+		return a.profit().ROAS
+	case "break_even_roi":
+		return a.profit().BreakEvenROI
+	}
+	return 0
+}
+
+// revenueRecordRow 读取的收益记录（只包含统计需要的列）
+type revenueRecordRow struct {
+	RevenueAmounts
+	ID                uint // 以下三列只在导出明细时读取
+	Nickname          string
+	Remark            string
+	UserID            uint
+	AdPlatform        string
+	ProductCategories string
+	AdType            string
+	Region            string
+	RecordTime        time.Time
+	RecordDate        string
+	Currency          string
+	OrderCount        int64
+	AdCreationCount   int64
+}
+
+// revenueRecordColumns revenueRecordRow 对应的查询列
+const revenueRecordColumns = "employee_revenue.user_id, employee_revenue.ad_platform, employee_revenue.product_categories, " +
+	"employee_revenue.ad_type, employee_revenue.region, employee_revenue.record_time, employee_revenue.record_date, " +
+	"employee_revenue.currency, employee_revenue.expenditure, employee_revenue.revenue, employee_revenue.order_count, " +
+	"employee_revenue.ad_creation_count, employee_revenue.product_cost, employee_revenue.platform_fee, employee_revenue.shipping_fee"
+
+// scanRevenueRecord 读取一行并把金额换算为报表币种
+func scanRevenueRecord(db *gorm.DB, rows *sql.Rows, conv *currencyConverter) (revenueRecordRow, error) {
+	var record revenueRecordRow
+	if err := db.ScanRows(rows, &record); err != nil {
+		return record, err
+	}
+	amounts, err := conv.convertAmounts(record.RevenueAmounts, record.Currency, record.RecordDate)
+	record.RevenueAmounts = amounts
+	return record, err
+}
+
+// parseNameList 解析逗号分隔的名称列表，检查白名单并去重
+func parseNameList(value, param string, allowed func(string) bool) ([]string, error) {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !allowed(name) {
+			return nil, fmt.Errorf("Invalid %s: %s", param, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// revenueReport 透视报表结果
+type revenueReport struct {
+	Dimensions  []string
+	Metrics     []string
+	Groups      []*revenueAggregate // 已排序并截取 top-N
+	Totals      revenueAggregate    // 全部分组的合计（不受 limit 影响）
+	TotalGroups int
+	Nicknames   map[uint]string // 用户维度的昵称
+	Currency    string          // 报表币种
+}
+
+// reportInputError 报表参数有误（接口返回 400）
+type reportInputError struct{ msg string }
+
+func (e *reportInputError) Error() string { return e.msg }
+
+// respondReportError 参数错误返回 400，其余返回 500
+func respondReportError(ctx *gin.Context, err error) {
+	var inputErr *reportInputError
+	if errors.As(err, &inputErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": inputErr.msg})
+		return
+	}
+	if respondMissingRate(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+}
+
+// GetRevenueReport 多维透视报表：按任意维度组合分组汇总，支持指标选择、排序与 top-N。
+// 参数：dimensions（platform/category/ad_type/region/user/time，逗号分隔，至少一个）、
+// metrics（expenditure/revenue/order_count/ad_creation_count/roi/records/product_cost/platform_fee/
+// shipping_fee/gross_profit/margin/roas/break_even_roi，默认全部）、
+// interval（time 维度的分桶：day/week/month，默认 month）、sort（指标或维度名，前缀 - 表示降序，默认 -revenue）、
+// limit（只返回前 N 个分组，默认全部），其余筛选条件同 parseRevenueFilter（金额换算为 currency 币种）
+func (c *employeeRevenueController) GetRevenueReport(ctx *gin.Context) {
+	report, err := c.buildRevenueReport(ctx)
+	if err != nil {
+		respondReportError(ctx, err)
+		return
+	}
+
+	data := make([]gin.H, 0, len(report.Groups))
+	for _, group := range report.Groups {
+		row := gin.H{}
+		for _, dim := range report.Dimensions {
+			if dim == "user" {
+				row["user_id"] = group.UserID
+				row["nickname"] = report.Nicknames[group.UserID]
+				continue
+			}
+			row[dim] = group.Values[dim]
+		}
+		for _, m := range report.Metrics {
+			row[m] = group.metric(m)
+		}
+		data = append(data, row)
+	}
+	totalRow := gin.H{}
+	for _, m := range report.Metrics {
+		totalRow[m] = report.Totals.metric(m)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"currency":     report.Currency,
+		"dimensions":   report.Dimensions,
+		"metrics":      report.Metrics,
+		"data":         data,
+		"totals":       totalRow,
+		"total_groups": report.TotalGroups,
+	})
+}
+
+// buildRevenueReport 解析报表参数并完成分组汇总（报表接口与导出共用）
+func (c *employeeRevenueController) buildRevenueReport(ctx *gin.Context) (*revenueReport, error) {
+	f, err := parseRevenueFilter(ctx)
+	if err != nil {
+		return nil, &reportInputError{err.Error()}
+	}
+
+	dimensions, err := parseNameList(ctx.Query("dimensions"), "dimension", func(name string) bool {
+		return containsString(revenueDimensions, name)
+	})
+	if err != nil {
+		return nil, &reportInputError{err.Error()}
+	}
+	if len(dimensions) == 0 {
+		return nil, &reportInputError{"At least one dimension is required"}
+	}
+	isMetric := func(name string) bool { return containsString(revenueMetrics, name) }
+	metrics, err := parseNameList(ctx.Query("metrics"), "metric", isMetric)
+	if err != nil {
+		return nil, &reportInputError{err.Error()}
+	}
+	if len(metrics) == 0 {
+		metrics = revenueMetrics
+	}
+
+	interval := ctx.DefaultQuery("interval", "month")
+	if interval != "day" && interval != "week" && interval != "month" {
+		return nil, &reportInputError{"Invalid interval, expected day, week or month"}
+	}
+
+	sortKey := ctx.DefaultQuery("sort", "-revenue")
+	desc := strings.HasPrefix(sortKey, "-")
+	sortKey = strings.TrimPrefix(sortKey, "-")
+	// 按维度排序时，该维度必须在分组维度中
+	sortByDimension := containsString(dimensions, sortKey)
+	if !sortByDimension && !isMetric(sortKey) {
+		return nil, &reportInputError{"Invalid sort field"}
+	}
+
+	limit := 0
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxReportLimit {
+			return nil, &reportInputError{fmt.Sprintf("Invalid limit, expected 1-%d", maxReportLimit)}
+		}
+	}
+
+	conv, err := newCurrencyConverter(c.db, f.Currency)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.Apply(c.db.Model(&models.EmployeeRevenue{})).Select(revenueRecordColumns).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &revenueReport{Dimensions: dimensions, Metrics: metrics, Nicknames: make(map[uint]string), Currency: f.Currency}
+	groups := make(map[string]*revenueAggregate)
+	for rows.Next() {
+		record, err := scanRevenueRecord(c.db, rows, conv)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string, len(dimensions))
+		keyParts := make([]string, len(dimensions))
+		var bucketStart time.Time
+		for i, dim := range dimensions {
+			switch dim {
+			case "platform":
+				values[dim] = record.AdPlatform
+			case "category":
+				values[dim] = record.ProductCategories
+			case "ad_type":
+				values[dim] = record.AdType
+			case "region":
+				values[dim] = record.Region
+			case "user":
+				values[dim] = strconv.FormatUint(uint64(record.UserID), 10)
+			case "time":
+				bucketStart = revenueBucketStart(f.recordDay(record.RecordTime, record.RecordDate), interval, f.Location)
+				values[dim] = revenueBucketLabel(bucketStart, interval)
+			}
+			keyParts[i] = values[dim]
+		}
+
+		key := strings.Join(keyParts, "\x00")
+		group, ok := groups[key]
+		if !ok {
+			group = &revenueAggregate{Values: values, UserID: record.UserID, BucketStart: bucketStart}
+			groups[key] = group
+		}
+		for _, agg := range []*revenueAggregate{group, &report.Totals} {
+			agg.add(record.RevenueAmounts)
+			agg.OrderCount += record.OrderCount
+			agg.AdCreation += record.AdCreationCount
+			agg.Records++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]*revenueAggregate, 0, len(groups))
+	for _, group := range groups {
+		list = append(list, group)
+	}
+	// 维度比较：用户与时间按数值/时间先后，其余按字符串
+	compareDimension := func(a, b *revenueAggregate, dim string) int {
+		switch dim {
+		case "user":
+			return int(a.UserID) - int(b.UserID)
+		case "time":
+			return a.BucketStart.Compare(b.BucketStart)
+		}
+		return strings.Compare(a.Values[dim], b.Values[dim])
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		cmp := 0
+		if sortByDimension {
+			cmp = compareDimension(list[i], list[j], sortKey)
+		} else if vi, vj := list[i].metric(sortKey), list[j].metric(sortKey); vi != vj {
+			cmp = 1
+			if vi < vj {
+				cmp = -1
+			}
+		}
+		if cmp != 0 {
+			return (cmp < 0) != desc
+		}
+		// 排序值相同时按维度顺序排列，保证结果稳定
+		for _, dim := range dimensions {
+			if cmp := compareDimension(list[i], list[j], dim); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	report.TotalGroups = len(list)
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	report.Groups = list
+
+	// 用户维度附带昵称
+	if containsString(dimensions, "user") && len(list) > 0 {
+		userIDs := make([]uint, 0, len(list))
+		for _, group := range list {
+			userIDs = append(userIDs, group.UserID)
+		}
+		var users []models.Users
+		if err := c.db.Select("id, nickname").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			report.Nicknames[user.ID] = user.Nickname
+		}
+	}
+	return report, nil
+}
+
+// containsString 判断切片中是否包含 s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
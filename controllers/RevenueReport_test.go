@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// 透视报表：维度与指标只接受白名单中的名称；按指标或维度排序、top-N 截取，合计不受 limit 影响
+func TestGetRevenueReport(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	m1 := createTestUser(t, db, "m1", utils.RoleMarketer)
+	m2 := createTestUser(t, db, "m2", utils.RoleMarketer)
+	c := NewEmployeeRevenueController(db)
+
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m1.ID, Revenue: 10000, Expenditure: 5000, OrderCount: 1}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m1.ID, AdPlatform: "GG", AdType: "search", Revenue: 30000,
+		Expenditure: 10000, OrderCount: 2}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m2.ID, Region: "DE", Revenue: 20000, Expenditure: 20000}, "2025-03-11")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: m2.ID, AdType: "search", Revenue: 5000}, "2025-03-11")
+
+	report := func(query string) (int, map[string]interface{}) {
+		w := performRequest(c.GetRevenueReport, http.MethodGet, "/?"+query, "", admin.ID, utils.RoleAdmin)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	for _, query := range []string{
+		"",
+		"dimensions=platform,ad_platform",
+		"dimensions=platform;DROP%20TABLE%20users",
+		"dimensions=platform&metrics=revenue,password",
+		"dimensions=platform&sort=region",
+		"dimensions=platform&sort=-bogus",
+		"dimensions=platform&limit=0",
+		"dimensions=platform&limit=1001",
+		"dimensions=time&interval=year",
+	} {
+		if code, resp := report(query); code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, response %v; want 400", query, code, resp)
+		}
+	}
+
+	code, resp := report("dimensions=platform,region&metrics=revenue,records&sort=-revenue&limit=2")
+	if code != http.StatusOK {
+		t.Fatalf("report: status %d, response %v", code, resp)
+	}
+	if got := fmt.Sprint(resp["data"]); got != "[map[platform:GG records:1 region:US revenue:300] map[platform:FB records:1 region:DE revenue:200]]" {
+		t.Errorf("data = %s", got)
+	}
+	if got := fmt.Sprint(resp["totals"], " ", resp["total_groups"]); got != "map[records:4 revenue:650] 3" {
+		t.Errorf("totals and total_groups = %s, want revenue 650, 4 records and 3 groups", got)
+	}
+
+	// 按维度升序排列，用户维度附带昵称；重复的维度只算一次
+	code, resp = report("dimensions=user,user&metrics=roi,order_count&sort=user")
+	if got := fmt.Sprint(resp["data"]); code != http.StatusOK ||
+		got != fmt.Sprintf("[map[nickname:m1 order_count:3 roi:2.67 user_id:%d] map[nickname:m2 order_count:0 roi:1.25 user_id:%d]]", m1.ID, m2.ID) {
+		t.Errorf("user report: status %d, data %s", code, got)
+	}
+	code, resp = report("dimensions=time,ad_type&interval=day&metrics=expenditure&sort=-time")
+	if got := fmt.Sprint(resp["data"]); code != http.StatusOK ||
+		got != "[map[ad_type:feed expenditure:200 time:2025-03-11] map[ad_type:search expenditure:0 time:2025-03-11] "+
+			"map[ad_type:feed expenditure:50 time:2025-03-10] map[ad_type:search expenditure:100 time:2025-03-10]]" {
+		t.Errorf("time report: status %d, data %s", code, got)
+	}
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"blog/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTimeseriesBuckets 单次查询最多返回的时间桶数量
+const maxTimeseriesBuckets = 1000
+
+// RevenuePoint 一个时间桶内的汇总数据
+type RevenuePoint struct {
+	Bucket          string       `json:"bucket"`     // 2025-03-10 / 2025-W11 / 2025-03
+	StartDate       string       `json:"start_date"` // 时间桶第一天
+	EndDate         string       `json:"end_date"`   // 时间桶最后一天（含）
+	Expenditure     models.Money `json:"expenditure"`
+	Revenue         models.Money `json:"revenue"`
+	OrderCount      int64        `json:"order_count"`
+	AdCreationCount int64        `json:"ad_creation_count"`
+	ROI             float64      `json:"roi"`
+	ProductCost     models.Money `json:"product_cost"`
+	PlatformFee     models.Money `json:"platform_fee"`
+	ShippingFee     models.Money `json:"shipping_fee"`
+	// 毛利、毛利率、ROAS、保本 ROI
+	models.RevenueProfit
+}
+
+// add 累加一条记录的金额（已换算为报表币种）
+func (p *RevenuePoint) add(a RevenueAmounts) {
+	p.Expenditure += a.Expenditure
+	p.Revenue += a.Revenue
+	p.ProductCost += a.ProductCost
+	p.PlatformFee += a.PlatformFee
+	p.ShippingFee += a.ShippingFee
+}
+
+// RevenueSeries 单个员工的时间序列
+type RevenueSeries struct {
+	UserID   uint           `json:"user_id"`
+	Nickname string         `json:"nickname"`
+	Points   []RevenuePoint `json:"points"`
+}
+
+// revenueBucketStart 返回 t 在 loc 时区下所属时间桶的开始时间：day 当天、week ISO 周的周一、month 当月 1 日
+func revenueBucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch interval {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	return day
+}
+
+// nextRevenueBucket 下一个时间桶的开始时间（按日历日计算，夏令时切换也不会错位）
+func nextRevenueBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// revenueBucketLabel 时间桶的显示名称，周使用 ISO 周编号
+func revenueBucketLabel(start time.Time, interval string) string {
+	switch interval {
+	case "week":
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// emptyRevenuePoints 生成区间内全部时间桶（数值为 0），返回桶列表与按开始时间索引的位置。
+// 最多生成 limit+1 个，调用方据此判断区间是否超出上限，不会为过大的区间分配全部时间桶
+func emptyRevenuePoints(start, end time.Time, interval string, limit int) ([]RevenuePoint, map[int64]int) {
+	points := make([]RevenuePoint, 0)
+	index := make(map[int64]int)
+	for b := revenueBucketStart(start, interval, start.Location()); !b.After(end) && len(points) <= limit; b = nextRevenueBucket(b, interval) {
+		index[b.Unix()] = len(points)
+		points = append(points, RevenuePoint{
+			Bucket:    revenueBucketLabel(b, interval),
+			StartDate: b.Format("2006-01-02"),
+			EndDate:   nextRevenueBucket(b, interval).AddDate(0, 0, -1).Format("2006-01-02"),
+		})
+	}
+	return points, index
+}
+
+// finishRevenuePoints 按各时间桶的金额合计计算 ROI 与利润指标
+func finishRevenuePoints(points []RevenuePoint) {
+	for i := range points {
+		points[i].ROI = revenueROI(points[i].Revenue, points[i].Expenditure)
+		points[i].RevenueProfit = models.ComputeRevenueProfit(points[i].Revenue, points[i].Expenditure,
+			points[i].ProductCost, points[i].PlatformFee, points[i].ShippingFee)
+	}
+}
+
+// GetRevenueTimeseries 按天/ISO 周/月汇总广告支出、销售额、订单数、上新数、ROI 与成本利润指标，空的时间桶补 0。
+// 参数：interval（day/week/month，默认 day）、groupBy=user（按员工分别返回，默认全队合计），
+// 其余筛选条件同 parseRevenueFilter；未指定日期时 day 取最近 30 天，week 取最近 12 周，month 取最近 12 个月
+func (c *employeeRevenueController) GetRevenueTimeseries(ctx *gin.Context) {
+	f, err := parseRevenueFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	interval := ctx.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval, expected day, week or month"})
+		return
+	}
+	groupBy := ctx.Query("groupBy")
+	if groupBy != "" && groupBy != "user" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid groupBy, expected user"})
+		return
+	}
+
+	// 默认区间：截至今天（所在时区）
+	if f.EndDate == nil {
+		now := time.Now().In(f.Location)
+		end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, f.Location)
+		f.EndDate = &end
+	}
+	if f.StartDate == nil {
+		var start time.Time
+		switch interval {
+		case "week":
+			start = revenueBucketStart(*f.EndDate, "week", f.Location).AddDate(0, 0, -7*11)
+		case "month":
+			start = revenueBucketStart(*f.EndDate, "month", f.Location).AddDate(0, -11, 0)
+		default:
+			start = f.EndDate.AddDate(0, 0, -29)
+		}
+		f.StartDate = &start
+	}
+
+	template, index := emptyRevenuePoints(*f.StartDate, *f.EndDate, interval, maxTimeseriesBuckets)
+	if len(template) > maxTimeseriesBuckets {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many buckets, at most %d are allowed", maxTimeseriesBuckets)})
+		return
+	}
+
+	conv, err := newCurrencyConverter(c.db, f.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+	// 逐行读取后在应用层分桶：按所在时区切分日期，不依赖数据库的时区函数
+	rows, err := f.Apply(c.db.Model(&models.EmployeeRevenue{})).Select(revenueRecordColumns).Rows()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+	defer rows.Close()
+
+	series := make(map[uint][]RevenuePoint)
+	order := make([]uint, 0)
+	for rows.Next() {
+		record, err := scanRevenueRecord(c.db, rows, conv)
+		if err != nil {
+			if !respondMissingRate(ctx, err) {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+			}
+			return
+		}
+
+		pos, ok := index[revenueBucketStart(f.recordDay(record.RecordTime, record.RecordDate), interval, f.Location).Unix()]
+		if !ok {
+			continue
+		}
+		key := uint(0)
+		if groupBy == "user" {
+			key = record.UserID
+		}
+		points, exists := series[key]
+		if !exists {
+			points = append([]RevenuePoint(nil), template...)
+			series[key] = points
+			order = append(order, key)
+		}
+		points[pos].add(record.RevenueAmounts)
+		points[pos].OrderCount += record.OrderCount
+		points[pos].AdCreationCount += record.AdCreationCount
+	}
+	if err := rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+
+	resp := gin.H{
+		"currency":   f.Currency,
+		"interval":   interval,
+		"timezone":   f.Location.String(),
+		"start_date": f.StartDate.Format("2006-01-02"),
+		"end_date":   f.EndDate.Format("2006-01-02"),
+	}
+
+	if groupBy != "user" {
+		points, ok := series[0]
+		if !ok {
+			points = template
+		}
+		finishRevenuePoints(points)
+		resp["data"] = points
+		ctx.JSON(http.StatusOK, resp)
+		return
+	}
+
+	// 按员工返回：指定 userId 时即使没有数据也返回一条全 0 的序列
+	if f.UserID != 0 && len(order) == 0 {
+		series[f.UserID] = append([]RevenuePoint(nil), template...)
+		order = append(order, f.UserID)
+	}
+	var users []models.Users
+	if err := c.db.Select("id, nickname").Where("id IN ?", order).Find(&users).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	nicknames := make(map[uint]string, len(users))
+	for _, user := range users {
+		nicknames[user.ID] = user.Nickname
+	}
+
+	result := make([]RevenueSeries, 0, len(order))
+	for _, userID := range order {
+		finishRevenuePoints(series[userID])
+		result = append(result, RevenueSeries{UserID: userID, Nickname: nicknames[userID], Points: series[userID]})
+	}
+	resp["data"] = result
+	ctx.JSON(http.StatusOK, resp)
+}
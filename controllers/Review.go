@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewController 定义编辑审核流程的接口
+type ReviewController interface {
+	SubmitForReview(ctx *gin.Context)    // 作者提交审核（draft → in_review）
+	ApproveBlog(ctx *gin.Context)        // 审核通过（in_review → approved）
+	RequestChanges(ctx *gin.Context)     // 退回修改（in_review → draft），可附带行内意见
+	ListReviews(ctx *gin.Context)        // 博客的审核记录
+	ListPendingReviews(ctx *gin.Context) // 待审核的博客
+}
+
+type reviewController struct {
+	db *gorm.DB
+}
+
+// NewReviewController 创建一个新的 ReviewController
+func NewReviewController(db *gorm.DB) ReviewController {
+	return &reviewController{db: db}
+}
+
+// ReviewInput 审核意见的请求体
+type ReviewInput struct {
+	Comment        string               `json:"comment"`
+	InlineComments []InlineCommentInput `json:"inline_comments"`
+}
+
+// InlineCommentInput 针对正文片段的修改意见；position 为片段在正文中的字符位置，省略时按 quote 自动定位
+type InlineCommentInput struct {
+	Quote    string `json:"quote"`
+	Position *int   `json:"position"`
+	Comment  string `json:"comment" binding:"required"`
+}
+
+var (
+	errReviewOnlyStatus = errors.New("in_review and approved can only be set through the review workflow")
+	errReviewRequired   = errors.New("This category requires review before publishing")
+	errBlogInReview     = errors.New("Blog is under review and cannot be edited")
+)
+
+// reviewNotices 各状态变更通知作者的文案
+var reviewNotices = map[string]string{
+	models.BlogStatusInReview:  "你的文章《%s》已提交审核",
+	models.BlogStatusApproved:  "你的文章《%s》已审核通过，可以发布了",
+	models.BlogStatusDraft:     "你的文章《%s》被退回修改",
+	models.BlogStatusPublished: "你的文章《%s》已发布",
+}
+
+// checkStatusChange 校验通过博客编辑接口修改状态是否允许：
+// in_review/approved 只能由审核流程设置；需要审核的博客只有审核通过后才能发布（管理员不受限制）
+func checkStatusChange(from, to string, reviewRequired bool, role int) error {
+	if to == "" || to == from {
+		return nil
+	}
+	if to != models.BlogStatusDraft && to != models.BlogStatusPublished {
+		return errReviewOnlyStatus
+	}
+	if to == models.BlogStatusPublished && from != models.BlogStatusApproved &&
+		reviewRequired && role > utils.RoleAdmin {
+		return errReviewRequired
+	}
+	return nil
+}
+
+// writeStatusChangeError 将状态校验错误写入响应
+func writeStatusChangeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errReviewRequired):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, errBlogInReview):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// transitionBlog 在事务中变更博客状态，写入审核记录与修改记录，并通知作者
+func transitionBlog(tx *gorm.DB, blog *models.Blog, userID uint, action, to, comment string, inline []models.BlogReviewComment) (*models.BlogReview, error) {
+	review := models.BlogReview{
+		BlogID:         blog.ID,
+		UserID:         userID,
+		Action:         action,
+		FromStatus:     blog.Status,
+		ToStatus:       to,
+		Comment:        comment,
+		InlineComments: inline,
+	}
+	if err := tx.Model(blog).Update("status", to).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&review).Error; err != nil {
+		return nil, err
+	}
+	if err := recordBlogAudit(tx, blog.ID, userID, action, gin.H{
+		"status": gin.H{"from": review.FromStatus, "to": to},
+	}); err != nil {
+		return nil, err
+	}
+	return &review, notifyBlogAuthor(tx, blog, to)
+}
+
+// notifyBlogAuthor 博客状态变更时通知作者
+func notifyBlogAuthor(tx *gorm.DB, blog *models.Blog, status string) error {
+	notice, ok := reviewNotices[status]
+	if !ok {
+		return nil
+	}
+	return tx.Create(&models.Notification{
+		UserID:  blog.AuthorID,
+		Type:    "review",
+		Content: fmt.Sprintf(notice, blog.Title),
+		Status:  "unread",
+	}).Error
+}
+
+// reviewableBlog 查找待审核的博客并校验当前用户是审核人（管理员/财务，且不能审核自己的文章）
+func (c *reviewController) reviewableBlog(ctx *gin.Context) (*models.Blog, uint, bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, 0, false
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	if !utils.IsReviewer(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, 0, false
+	}
+
+	var blog models.Blog
+	if err := c.db.First(&blog, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+		return nil, 0, false
+	}
+	if blog.AuthorID == userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Cannot review your own blog"})
+		return nil, 0, false
+	}
+	if blog.Status != models.BlogStatusInReview {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Blog is not waiting for review"})
+		return nil, 0, false
+	}
+	return &blog, userID, true
+}
+
+// SubmitForReview 作者提交审核（仅所有者或管理员，且必须是草稿）
+func (c *reviewController) SubmitForReview(ctx *gin.Context) {
+	blog, userID, _, ok := authorizeBlog(ctx, c.db, models.CollaboratorOwner)
+	if !ok {
+		return
+	}
+	if blog.Status != models.BlogStatusDraft {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Only drafts can be submitted for review"})
+		return
+	}
+
+	var input ReviewInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var review *models.BlogReview
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		review, err = transitionBlog(tx, blog, userID, "submit", models.BlogStatusInReview, input.Comment, nil)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit blog for review"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": blog.Status, "review": review})
+}
+
+// ApproveBlog 审核通过，作者随后可以发布
+func (c *reviewController) ApproveBlog(ctx *gin.Context) {
+	blog, userID, ok := c.reviewableBlog(ctx)
+	if !ok {
+		return
+	}
+
+	var input ReviewInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var review *models.BlogReview
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		review, err = transitionBlog(tx, blog, userID, "approve", models.BlogStatusApproved, input.Comment, nil)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve blog"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": blog.Status, "review": review})
+}
+
+// RequestChanges 退回修改，需要总体意见或至少一条行内意见；行内意见引用的片段必须出现在正文中
+func (c *reviewController) RequestChanges(ctx *gin.Context) {
+	blog, userID, ok := c.reviewableBlog(ctx)
+	if !ok {
+		return
+	}
+
+	var input ReviewInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Comment = strings.TrimSpace(input.Comment)
+	if input.Comment == "" && len(input.InlineComments) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "comment or inline_comments is required"})
+		return
+	}
+
+	inline, err := resolveInlineComments(blog, input.InlineComments)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var review *models.BlogReview
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		review, err = transitionBlog(tx, blog, userID, "request_changes", models.BlogStatusDraft, input.Comment, inline)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request changes"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": blog.Status, "review": review})
+}
+
+// resolveInlineComments 校验行内意见并定位其在正文中的位置（按字符计算）
+func resolveInlineComments(blog *models.Blog, inputs []InlineCommentInput) ([]models.BlogReviewComment, error) {
+	content := []rune(blog.Content)
+	comments := make([]models.BlogReviewComment, 0, len(inputs))
+	for i, input := range inputs {
+		comment := models.BlogReviewComment{
+			BlogID:  blog.ID,
+			Quote:   input.Quote,
+			Comment: strings.TrimSpace(input.Comment),
+		}
+		if comment.Comment == "" {
+			return nil, fmt.Errorf("inline_comments[%d]: comment is required", i)
+		}
+
+		quote := []rune(input.Quote)
+		switch {
+		case input.Position != nil:
+			position := *input.Position
+			if position < 0 || position+len(quote) > len(content) || string(content[position:position+len(quote)]) != input.Quote {
+				return nil, fmt.Errorf("inline_comments[%d]: quote does not match content at position %d", i, position)
+			}
+			comment.Position = position
+		case input.Quote != "":
+			index := strings.Index(blog.Content, input.Quote)
+			if index < 0 {
+				return nil, fmt.Errorf("inline_comments[%d]: quote not found in content", i)
+			}
+			comment.Position = len([]rune(blog.Content[:index]))
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// ListReviews 获取博客的审核记录（协作者或审核人可查看），按时间倒序
+func (c *reviewController) ListReviews(ctx *gin.Context) {
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	var blog *models.Blog
+	if utils.IsReviewer(role) {
+		blog = &models.Blog{}
+		if err := c.db.First(blog, ctx.Param("id")).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
+			return
+		}
+	} else {
+		var ok bool
+		if blog, _, _, ok = authorizeBlog(ctx, c.db, models.CollaboratorViewer); !ok {
+			return
+		}
+	}
+
+	reviews := make([]models.BlogReview, 0)
+	if err := c.db.Preload("InlineComments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("blog_id = ?", blog.ID).Order("created_at DESC").Order("id DESC").Find(&reviews).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":          blog.Status,
+		"review_required": utils.ReviewRequired(blog.Category),
+		"data":            reviews,
+	})
+}
+
+// ListPendingReviews 审核人查看待审核的博客，先提交的在前
+func (c *reviewController) ListPendingReviews(ctx *gin.Context) {
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+	if !utils.IsReviewer(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	p, err := utils.ParsePagination(ctx, 10)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	query := c.db.Model(&models.Blog{}).Where("blog.status = ?", models.BlogStatusInReview).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count blogs"})
+		return
+	}
+
+	var blogs []BlogWithAuthor
+	if err := p.ApplyAscending(blogListQuery(query, false), "blog.created_at", "blog.id").
+		Scan(&blogs).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
+		return
+	}
+
+	blogs, nextCursor := pageBlogs(p, blogs)
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
+}
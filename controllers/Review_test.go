@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setReviewCategories 测试期间修改 review.categories
+func setReviewCategories(t *testing.T, categories ...string) {
+	t.Helper()
+	old := utils.AppConfig.Review.Categories
+	utils.AppConfig.Review.Categories = categories
+	t.Cleanup(func() { utils.AppConfig.Review.Categories = old })
+}
+
+// updateBlog 以作者身份调用 UpdateBlog，返回状态码与更新后的博客
+func updateBlog(t *testing.T, c BlogController, blog models.Blog, body string) (int, models.Blog) {
+	t.Helper()
+	w := performRequest(c.UpdateBlog, http.MethodPut, "/", body, blog.AuthorID, utils.RoleMarketer,
+		gin.Param{Key: "id", Value: strconv.Itoa(int(blog.ID))})
+	var stored models.Blog
+	c.(*blogController).db.First(&stored, blog.ID)
+	return w.Code, stored
+}
+
+// 原分类需要审核时，不能通过同时切换到无需审核的分类来直接发布
+func TestUpdateBlogCategorySwitchRequiresReview(t *testing.T) {
+	setReviewCategories(t, "Finance")
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	draft := createTestBlog(t, db, author.ID, "draft")
+	db.Model(&draft).UpdateColumns(map[string]interface{}{"status": models.BlogStatusDraft, "category": "Finance"})
+	c := NewBlogController(db, nil)
+
+	code, stored := updateBlog(t, c, draft, `{"category":"Go","status":"published"}`)
+	if code != http.StatusForbidden || stored.Status != models.BlogStatusDraft || stored.Category != "Finance" {
+		t.Errorf("publish while leaving review category: status %d, blog %s/%s; want 403 and unchanged", code, stored.Status, stored.Category)
+	}
+	// 从无需审核的分类切换到需要审核的分类同样不能直接发布
+	free := createTestBlog(t, db, author.ID, "free")
+	db.Model(&free).UpdateColumn("status", models.BlogStatusDraft)
+	if code, _ := updateBlog(t, c, free, `{"category":"Finance","status":"published"}`); code != http.StatusForbidden {
+		t.Errorf("publish while entering review category: status %d, want 403", code)
+	}
+	// 两个分类都无需审核时可以直接发布
+	if code, stored := updateBlog(t, c, free, `{"category":"Web","status":"published"}`); code != http.StatusOK || stored.Status != "published" {
+		t.Errorf("publish without review: status %d, blog status %s; want 200 and published", code, stored.Status)
+	}
+}
+
+// 已发布（或审核通过）的文章在需要审核的分类下修改内容或分类后回到审核中
+func TestUpdateBlogResubmitsPublishedPost(t *testing.T) {
+	setReviewCategories(t, "Finance")
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleMarketer)
+	c := NewBlogController(db, nil)
+
+	published := createTestBlog(t, db, author.ID, "published")
+	db.Model(&published).UpdateColumn("category", "Finance")
+	code, stored := updateBlog(t, c, published, `{"content":"edited"}`)
+	if code != http.StatusOK || stored.Status != models.BlogStatusInReview || stored.Content != "edited" {
+		t.Errorf("edit published post: status %d, blog status %s; want 200 and in_review", code, stored.Status)
+	}
+	var reviews []models.BlogReview
+	db.Where("blog_id = ?", published.ID).Find(&reviews)
+	if len(reviews) != 1 || reviews[0].Action != "resubmit" || reviews[0].FromStatus != "published" {
+		t.Errorf("reviews = %+v, want one resubmit from published", reviews)
+	}
+
+	// 切换到需要审核的分类也需要重新审核，且不能同时保持发布
+	moved := createTestBlog(t, db, author.ID, "moved")
+	if code, _ := updateBlog(t, c, moved, `{"category":"Finance","status":"published"}`); code != http.StatusOK {
+		t.Errorf("status unchanged is ignored: status %d, want 200", code)
+	}
+	db.First(&moved, moved.ID)
+	if moved.Status != models.BlogStatusInReview {
+		t.Errorf("moved to review category: blog status %s, want in_review", moved.Status)
+	}
+
+	// 无需审核的分类下修改内容不影响发布状态
+	free := createTestBlog(t, db, author.ID, "free")
+	if code, stored := updateBlog(t, c, free, `{"title":"free edited"}`); code != http.StatusOK || stored.Status != "published" {
+		t.Errorf("edit post without review: status %d, blog status %s; want 200 and published", code, stored.Status)
+	}
+}
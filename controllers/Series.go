@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SeriesController 定义系列文章的接口
+type SeriesController interface {
+	CreateSeries(ctx *gin.Context)      // 创建系列
+	GetSeries(ctx *gin.Context)         // 获取系列及其有序文章
+	ListMySeries(ctx *gin.Context)      // 当前用户的系列
+	UpdateSeriesPosts(ctx *gin.Context) // 设置系列文章及顺序
+}
+
+type seriesController struct {
+	db *gorm.DB
+}
+
+// NewSeriesController 创建一个新的 SeriesController
+func NewSeriesController(db *gorm.DB) SeriesController {
+	return &seriesController{db: db}
+}
+
+// SeriesInput 创建系列的请求体
+type SeriesInput struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	BlogIDs     []uint `json:"blog_ids"` // 按顺序排列的博客ID
+}
+
+// SeriesPostsInput 设置系列文章顺序的请求体（传入完整的有序列表，未出现的文章会被移出系列）
+type SeriesPostsInput struct {
+	BlogIDs []uint `json:"blog_ids"`
+}
+
+// SeriesBlog 系列中的一篇博客
+type SeriesBlog struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	AuthorID  uint   `json:"author_id"`
+	CreatedAt string `json:"created_at"`
+	Position  int    `json:"position"`
+}
+
+// SeriesNavigation 博客详情中的系列上下篇导航
+type SeriesNavigation struct {
+	ID       uint        `json:"id"`
+	Title    string      `json:"title"`
+	Position int         `json:"position"` // 当前文章在可见文章中的序号（从 1 开始）
+	Total    int         `json:"total"`    // 可见文章总数
+	Prev     *SeriesBlog `json:"prev"`
+	Next     *SeriesBlog `json:"next"`
+}
+
+var errSeriesBlogNotAllowed = errors.New("blog not found or not owned by series owner")
+
+// CreateSeries 创建系列，可同时指定有序的博客列表
+func (c *seriesController) CreateSeries(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var input SeriesInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series := models.Series{UserID: userID, Title: input.Title, Description: input.Description}
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		return setSeriesItems(tx, &series, input.BlogIDs)
+	})
+	if errors.Is(err, errSeriesBlogNotAllowed) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+		return
+	}
+
+	c.renderSeries(ctx, http.StatusCreated, &series)
+}
+
+// GetSeries 获取系列详情及有序文章列表
+func (c *seriesController) GetSeries(ctx *gin.Context) {
+	var series models.Series
+	if err := c.db.First(&series, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	c.renderSeries(ctx, http.StatusOK, &series)
+}
+
+// ListMySeries 获取当前用户创建的系列
+func (c *seriesController) ListMySeries(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var series []models.Series
+	if err := c.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&series).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": series})
+}
+
+// UpdateSeriesPosts 设置系列的文章及顺序（仅创建者或管理员）
+func (c *seriesController) UpdateSeriesPosts(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	var series models.Series
+	if err := c.db.First(&series, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+	if series.UserID != userID && role > utils.RoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	var input SeriesPostsInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		return setSeriesItems(tx, &series, input.BlogIDs)
+	})
+	if errors.Is(err, errSeriesBlogNotAllowed) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update series posts"})
+		return
+	}
+
+	c.renderSeries(ctx, http.StatusOK, &series)
+}
+
+// renderSeries 输出系列信息及有序文章（草稿仅对其作者可见）
+func (c *seriesController) renderSeries(ctx *gin.Context, status int, series *models.Series) {
+	blogs, err := loadSeriesBlogs(c.db, series.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series posts"})
+		return
+	}
+
+	userIDRaw, _ := ctx.Get("userId")
+	viewerID, _ := userIDRaw.(uint)
+	visible := make([]SeriesBlog, 0, len(blogs))
+	for _, blog := range blogs {
+		if blog.Status == "published" || blog.AuthorID == viewerID {
+			visible = append(visible, blog)
+		}
+	}
+
+	ctx.JSON(status, gin.H{
+		"series": series,
+		"posts":  visible,
+	})
+}
+
+// setSeriesItems 用给定的有序博客列表替换系列中的文章；博客必须属于系列创建者，重复的ID只保留第一次出现
+func setSeriesItems(tx *gorm.DB, series *models.Series, blogIDs []uint) error {
+	ordered := make([]uint, 0, len(blogIDs))
+	seen := make(map[uint]bool, len(blogIDs))
+	for _, id := range blogIDs {
+		if !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+
+	if len(ordered) > 0 {
+		var count int64
+		if err := tx.Model(&models.Blog{}).
+			Where("id IN ? AND author_id = ?", ordered, series.UserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(ordered) {
+			return errSeriesBlogNotAllowed
+		}
+	}
+
+	if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesItem{}).Error; err != nil {
+		return err
+	}
+	if len(ordered) == 0 {
+		return nil
+	}
+
+	// 一篇博客只能属于一个系列，加入本系列时从其他系列中移出
+	if err := tx.Where("blog_id IN ?", ordered).Delete(&models.SeriesItem{}).Error; err != nil {
+		return err
+	}
+
+	items := make([]models.SeriesItem, 0, len(ordered))
+	for i, id := range ordered {
+		items = append(items, models.SeriesItem{SeriesID: series.ID, BlogID: id, Position: i + 1})
+	}
+	return tx.Create(&items).Error
+}
+
+// loadSeriesBlogs 按顺序查询系列中的文章
+func loadSeriesBlogs(db *gorm.DB, seriesID uint) ([]SeriesBlog, error) {
+	blogs := make([]SeriesBlog, 0)
+	err := db.Table("series_items").
+		Select("blog.id, blog.title, blog.status, blog.author_id, blog.created_at, series_items.position").
+		Joins("JOIN blog ON blog.id = series_items.blog_id AND blog.deleted_at IS NULL").
+		Where("series_items.series_id = ?", seriesID).
+		Order("series_items.position ASC").
+		Scan(&blogs).Error
+	return blogs, err
+}
+
+// loadSeriesNavigation 获取博客所在系列的上下篇；读者只能看到已发布的文章，作者可以看到自己的草稿
+func loadSeriesNavigation(db *gorm.DB, blogID, viewerID uint) (*SeriesNavigation, error) {
+	var item models.SeriesItem
+	if err := db.Where("blog_id = ?", blogID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var series models.Series
+	if err := db.First(&series, item.SeriesID).Error; err != nil {
+		return nil, err
+	}
+
+	blogs, err := loadSeriesBlogs(db, series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]SeriesBlog, 0, len(blogs))
+	for _, blog := range blogs {
+		if blog.ID == blogID || blog.Status == "published" || blog.AuthorID == viewerID {
+			visible = append(visible, blog)
+		}
+	}
+
+	nav := &SeriesNavigation{ID: series.ID, Title: series.Title, Total: len(visible)}
+	for i := range visible {
+		if visible[i].ID != blogID {
+			continue
+		}
+		nav.Position = i + 1
+		if i > 0 {
+			nav.Prev = &visible[i-1]
+		}
+		if i < len(visible)-1 {
+			nav.Next = &visible[i+1]
+		}
+	}
+	return nav, nil
+}
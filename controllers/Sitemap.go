@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SitemapController 定义搜索引擎相关的接口
+type SitemapController interface {
+	RobotsTxt(ctx *gin.Context)   // robots.txt
+	Sitemap(ctx *gin.Context)     // sitemap.xml（文章过多时返回 sitemap 索引）
+	SitemapPage(ctx *gin.Context) // 分片 sitemap
+}
+
+type sitemapController struct {
+	db *gorm.DB
+}
+
+// NewSitemapController 创建一个新的 SitemapController
+func NewSitemapController(db *gorm.DB) SitemapController {
+	return &sitemapController{db: db}
+}
+
+// sitemapBlog 生成 sitemap 所需的最少字段
+type sitemapBlog struct {
+	ID        uint
+	UpdatedAt time.Time
+}
+
+// publishedBlogs 只统计已发布的文章，草稿不对搜索引擎公开
+func (c *sitemapController) publishedBlogs() *gorm.DB {
+	return c.db.Model(&models.Blog{}).Where("status = ?", "published")
+}
+
+// RobotsTxt 返回 robots.txt
+func (c *sitemapController) RobotsTxt(ctx *gin.Context) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	b.WriteString("Allow: /home\n")
+	b.WriteString("Allow: /blog/\n")
+	b.WriteString("Disallow: /api/\n")
+	b.WriteString("Disallow: /login\n")
+	b.WriteString("\n")
+	b.WriteString("Sitemap: " + utils.SiteURL("/sitemap.xml") + "\n")
+
+	ctx.String(http.StatusOK, b.String())
+}
+
+// Sitemap 返回 sitemap.xml，文章数超过单个文件上限时返回 sitemap 索引
+func (c *sitemapController) Sitemap(ctx *gin.Context) {
+	var total int64
+	if err := c.publishedBlogs().Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count blogs"})
+		return
+	}
+
+	pageSize := utils.SitemapPageSize()
+	if total <= int64(pageSize) {
+		c.renderURLSet(ctx, 1, pageSize)
+		return
+	}
+
+	pages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	entries := make([]utils.SitemapEntry, 0, pages)
+	for i := 1; i <= pages; i++ {
+		entries = append(entries, utils.SitemapEntry{
+			Loc: utils.SiteURL(fmt.Sprintf("/sitemap/%d.xml", i)),
+		})
+	}
+
+	renderSitemapXML(ctx, utils.NewSitemapIndex(entries))
+}
+
+// SitemapPage 返回第 N 个分片 sitemap，路径形如 /sitemap/2.xml
+func (c *sitemapController) SitemapPage(ctx *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(ctx.Param("page"), ".xml"))
+	if err != nil || page < 1 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	c.renderURLSet(ctx, page, utils.SitemapPageSize())
+}
+
+// renderURLSet 输出第 page 页已发布文章的 urlset，第一页额外包含首页
+func (c *sitemapController) renderURLSet(ctx *gin.Context, page, pageSize int) {
+	var blogs []sitemapBlog
+	if err := c.publishedBlogs().
+		Select("id, updated_at").
+		Order("id ASC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Scan(&blogs).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
+		return
+	}
+
+	if page > 1 && len(blogs) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	urls := make([]utils.SitemapURL, 0, len(blogs)+1)
+	if page == 1 {
+		urls = append(urls, utils.SitemapURL{Loc: utils.SiteURL("/home")})
+	}
+	for _, blog := range blogs {
+		urls = append(urls, utils.SitemapURL{
+			Loc:     utils.SiteURL(fmt.Sprintf("/blog/%d", blog.ID)),
+			LastMod: utils.SitemapLastMod(blog.UpdatedAt),
+		})
+	}
+
+	renderSitemapXML(ctx, utils.NewSitemapURLSet(urls))
+}
+
+func renderSitemapXML(ctx *gin.Context, v interface{}) {
+	body, err := utils.MarshalSitemap(v)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render sitemap"})
+		return
+	}
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
@@ -0,0 +1,440 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TargetController 定义员工月度目标的接口
+type TargetController interface {
+	ListTargets(ctx *gin.Context)         // 目标列表（管理员）
+	CreateTarget(ctx *gin.Context)        // 新增目标（管理员）
+	UpdateTarget(ctx *gin.Context)        // 修改目标（管理员）
+	DeleteTarget(ctx *gin.Context)        // 删除目标（管理员）
+	GetTargetAttainment(ctx *gin.Context) // 目标完成情况
+}
+
+type targetController struct {
+	db *gorm.DB
+}
+
+// NewTargetController 创建一个新的 TargetController
+func NewTargetController(db *gorm.DB) TargetController {
+	return &targetController{db: db}
+}
+
+// targetAtRiskRatio 预计完成率低于该比例时为 behind，介于该比例和 100% 之间为 at_risk
+const targetAtRiskRatio = 0.9
+
+// 目标完成状态
+const (
+	TargetStatusNotStarted = "not_started" // 考核月份还没开始
+	TargetStatusAchieved   = "achieved"    // 已完成
+	TargetStatusOnTrack    = "on_track"    // 按当前速度月底能完成
+	TargetStatusAtRisk     = "at_risk"     // 按当前速度差一点完成（广告支出为月底会超预算）
+	TargetStatusBehind     = "behind"      // 按当前速度明显完不成
+	TargetStatusMissed     = "missed"      // 月份已结束且未完成
+	TargetStatusOverBudget = "over_budget" // 广告支出已超预算
+)
+
+// TargetInput 新增/修改目标的请求体，某项目标为 0 表示不考核该项
+type TargetInput struct {
+	UserID      uint         `json:"user_id" binding:"required"`
+	Period      string       `json:"period" binding:"required"` // YYYY-MM
+	Currency    string       `json:"currency"`                  // 默认基础币种
+	Expenditure models.Money `json:"expenditure"`
+	Revenue     models.Money `json:"revenue"`
+	ROI         float64      `json:"roi"`
+	Remark      string       `json:"remark"`
+}
+
+// parseTargetPeriod 解析考核月份（YYYY-MM），返回该月第一天和最后一天（revenue.timezone 的零点）
+func parseTargetPeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", period, utils.RevenueLocation())
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid period, expected YYYY-MM")
+	}
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+// validateTarget 校验并规范化请求体，返回错误信息
+func (c *targetController) validateTarget(input *TargetInput) string {
+	if _, _, err := parseTargetPeriod(input.Period); err != nil {
+		return err.Error()
+	}
+	currency, ok := revenueInputCurrency(input.Currency, utils.RevenueBaseCurrency())
+	if !ok {
+		return "Invalid currency, expected an ISO 4217 code such as USD"
+	}
+	input.Currency = currency
+	if input.Expenditure < 0 || input.Revenue < 0 || input.ROI < 0 || math.IsNaN(input.ROI) || math.IsInf(input.ROI, 0) {
+		return "Targets must not be negative"
+	}
+	if input.Expenditure == 0 && input.Revenue == 0 && input.ROI == 0 {
+		return "At least one of expenditure, revenue and roi is required"
+	}
+	var count int64
+	if err := c.db.Model(&models.Users{}).Where("id = ?", input.UserID).Count(&count).Error; err != nil || count == 0 {
+		return "User not found"
+	}
+	return ""
+}
+
+// ListTargets 目标列表，可按 userId、period 筛选，按月份倒序
+func (c *targetController) ListTargets(ctx *gin.Context) {
+	query := c.db.Model(&models.Target{})
+	if userIDStr := ctx.Query("userId"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if period := ctx.Query("period"); period != "" {
+		if _, _, err := parseTargetPeriod(period); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("period = ?", period)
+	}
+
+	targets := make([]models.Target, 0)
+	if err := query.Order("period DESC, user_id").Find(&targets).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch targets"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": targets})
+}
+
+// CreateTarget 新增目标，同一员工同一月份只能有一条
+func (c *targetController) CreateTarget(ctx *gin.Context) {
+	var input TargetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := c.validateTarget(&input); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var existing models.Target
+	if err := c.db.Where("user_id = ? AND period = ?", input.UserID, input.Period).First(&existing).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A target for this user and period already exists", "existing_id": existing.ID})
+		return
+	}
+
+	target := models.Target{
+		UserID:      input.UserID,
+		Period:      input.Period,
+		Currency:    input.Currency,
+		Expenditure: input.Expenditure,
+		Revenue:     input.Revenue,
+		ROI:         input.ROI,
+		Remark:      input.Remark,
+	}
+	if err := c.db.Create(&target).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create target"})
+		return
+	}
+	ctx.JSON(http.StatusCreated, target)
+}
+
+// UpdateTarget 修改目标（整体替换）
+func (c *targetController) UpdateTarget(ctx *gin.Context) {
+	var target models.Target
+	if err := c.db.First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+		return
+	}
+
+	var input TargetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := c.validateTarget(&input); msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var count int64
+	c.db.Model(&models.Target{}).Where("user_id = ? AND period = ? AND id <> ?", input.UserID, input.Period, target.ID).Count(&count)
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A target for this user and period already exists"})
+		return
+	}
+
+	target.UserID = input.UserID
+	target.Period = input.Period
+	target.Currency = input.Currency
+	target.Expenditure = input.Expenditure
+	target.Revenue = input.Revenue
+	target.ROI = input.ROI
+	target.Remark = input.Remark
+	if err := c.db.Save(&target).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update target"})
+		return
+	}
+	ctx.JSON(http.StatusOK, target)
+}
+
+// DeleteTarget 删除目标
+func (c *targetController) DeleteTarget(ctx *gin.Context) {
+	result := c.db.Delete(&models.Target{}, ctx.Param("id"))
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete target"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Target deleted successfully"})
+}
+
+// TargetMetricAttainment 单项目标的完成情况
+type TargetMetricAttainment struct {
+	Metric           string  `json:"metric"` // expenditure / revenue / roi
+	Target           float64 `json:"target"`
+	Actual           float64 `json:"actual"`
+	Percent          float64 `json:"percent"`           // 完成率（实际 / 目标）
+	Projected        float64 `json:"projected"`         // 按已过天数的日均值推算到月底（ROI 为当前值）
+	ProjectedPercent float64 `json:"projected_percent"` // 预计完成率
+	Status           string  `json:"status"`
+}
+
+// TargetAttainment 一个员工的目标完成情况
+type TargetAttainment struct {
+	TargetID uint                     `json:"target_id"`
+	UserID   uint                     `json:"user_id"`
+	Nickname string                   `json:"nickname"`
+	Currency string                   `json:"currency"`
+	Metrics  []TargetMetricAttainment `json:"metrics"`
+}
+
+// GetTargetAttainment 目标完成情况：period 为考核月份（默认本月），可按 userId 筛选。
+// 实际数据来自收益记录，按记录当天汇率换算为目标的币种；未过完的月份按日均值推算月底的数据
+func (c *targetController) GetTargetAttainment(ctx *gin.Context) {
+	loc := utils.RevenueLocation()
+	now := time.Now().In(loc)
+	period := ctx.DefaultQuery("period", now.Format("2006-01"))
+	start, end, err := parseTargetPeriod(period)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := c.db.Model(&models.Target{}).Where("period = ?", period)
+	if userIDStr := ctx.Query("userId"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	targets := make([]models.Target, 0)
+	if err := query.Order("user_id").Find(&targets).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch targets"})
+		return
+	}
+
+	// 已过天数（含今天）
+	daysTotal := end.Day()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	daysElapsed := daysTotal
+	switch {
+	case today.Before(start):
+		daysElapsed = 0
+	case !today.After(end):
+		daysElapsed = today.Day()
+	}
+
+	data := make([]TargetAttainment, 0, len(targets))
+	if len(targets) > 0 {
+		userIDs := make([]uint, 0, len(targets))
+		for _, target := range targets {
+			userIDs = append(userIDs, target.UserID)
+		}
+		daily, err := loadUserRevenueDaily(c.db.Where("employee_revenue.user_id IN ? AND employee_revenue.record_date BETWEEN ? AND ?",
+			userIDs, start.Format("2006-01-02"), end.Format("2006-01-02")))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revenue data"})
+			return
+		}
+		var users []models.Users
+		c.db.Select("id, nickname").Where("id IN ?", userIDs).Find(&users)
+		nicknames := make(map[uint]string, len(users))
+		for _, user := range users {
+			nicknames[user.ID] = user.Nickname
+		}
+
+		// 每个币种换算一次
+		actualsByCurrency := make(map[string]map[uint]*userRevenueTotals)
+		for _, target := range targets {
+			actuals, ok := actualsByCurrency[target.Currency]
+			if !ok {
+				conv, err := newCurrencyConverter(c.db, target.Currency)
+				if err == nil {
+					actuals, err = sumUserRevenue(daily, conv)
+				}
+				if err != nil {
+					if !respondMissingRate(ctx, err) {
+						ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revenue data"})
+					}
+					return
+				}
+				actualsByCurrency[target.Currency] = actuals
+			}
+			var actual userRevenueTotals
+			if totals := actuals[target.UserID]; totals != nil {
+				actual = *totals
+			}
+			data = append(data, TargetAttainment{
+				TargetID: target.ID,
+				UserID:   target.UserID,
+				Nickname: nicknames[target.UserID],
+				Currency: target.Currency,
+				Metrics:  targetMetrics(target, actual.RevenueAmounts, daysElapsed, daysTotal),
+			})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"period":       period,
+		"start_date":   start.Format("2006-01-02"),
+		"end_date":     end.Format("2006-01-02"),
+		"days_elapsed": daysElapsed,
+		"days_total":   daysTotal,
+		"data":         data,
+	})
+}
+
+// targetMetrics 计算目标中每一项（不为 0 的）的完成情况
+func targetMetrics(target models.Target, actual RevenueAmounts, daysElapsed, daysTotal int) []TargetMetricAttainment {
+	project := func(m models.Money) models.Money {
+		if daysElapsed == 0 {
+			return 0
+		}
+		return models.Money(math.Round(float64(m) * float64(daysTotal) / float64(daysElapsed)))
+	}
+	metrics := make([]TargetMetricAttainment, 0, 3)
+	for _, metric := range []struct {
+		name                      string
+		target, actual, projected float64
+	}{
+		{"expenditure", target.Expenditure.Float(), actual.Expenditure.Float(), project(actual.Expenditure).Float()},
+		{"revenue", target.Revenue.Float(), actual.Revenue.Float(), project(actual.Revenue).Float()},
+		{"roi", target.ROI, revenueROI(actual.Revenue, actual.Expenditure), revenueROI(actual.Revenue, actual.Expenditure)},
+	} {
+		if metric.target == 0 {
+			continue
+		}
+		metrics = append(metrics, TargetMetricAttainment{
+			Metric:           metric.name,
+			Target:           metric.target,
+			Actual:           metric.actual,
+			Percent:          math.Round(metric.actual/metric.target*10000) / 10000,
+			Projected:        metric.projected,
+			ProjectedPercent: math.Round(metric.projected/metric.target*10000) / 10000,
+			Status:           targetStatus(metric.name, metric.target, metric.actual, metric.projected, daysElapsed, daysTotal),
+		})
+	}
+	return metrics
+}
+
+// targetStatus 目标完成状态。广告支出是预算：超出为 over_budget，月底花到预算的 90% 以上算完成；
+// 销售额和 ROI 越高越好，ROI 在月份结束前可能回落，所以只有月份结束后才算 achieved
+func targetStatus(metric string, target, actual, projected float64, daysElapsed, daysTotal int) string {
+	finished := daysElapsed >= daysTotal
+	switch {
+	case daysElapsed == 0:
+		return TargetStatusNotStarted
+	case metric == "expenditure":
+		switch {
+		case actual > target:
+			return TargetStatusOverBudget
+		case projected > target:
+			return TargetStatusAtRisk
+		case finished && actual >= target*targetAtRiskRatio:
+			return TargetStatusAchieved
+		case finished:
+			return TargetStatusMissed
+		case projected >= target*targetAtRiskRatio:
+			return TargetStatusOnTrack
+		default:
+			return TargetStatusBehind
+		}
+	case actual >= target && (finished || metric == "revenue"):
+		return TargetStatusAchieved
+	case finished:
+		return TargetStatusMissed
+	case projected >= target:
+		return TargetStatusOnTrack
+	case projected >= target*targetAtRiskRatio:
+		return TargetStatusAtRisk
+	default:
+		return TargetStatusBehind
+	}
+}
+
+// userRevenueDaily 员工某一天某个币种的收益汇总
+type userRevenueDaily struct {
+	RevenueAmounts
+	UserID          uint
+	Currency        string
+	RecordDate      string
+	OrderCount      int64
+	AdCreationCount int64
+}
+
+// userRevenueTotals 员工在区间内的合计（已换算为同一币种）
+type userRevenueTotals struct {
+	RevenueAmounts
+	OrderCount      int64
+	AdCreationCount int64
+}
+
+// loadUserRevenueDaily 按 员工 + 币种 + 业务日期 汇总收益记录，query 为 employee_revenue 上的筛选条件
+func loadUserRevenueDaily(query *gorm.DB) ([]userRevenueDaily, error) {
+	var daily []userRevenueDaily
+	err := query.Model(&models.EmployeeRevenue{}).
+		Select("employee_revenue.user_id, employee_revenue.currency, employee_revenue.record_date, " +
+			"SUM(employee_revenue.expenditure) AS expenditure, SUM(employee_revenue.revenue) AS revenue, " +
+			"SUM(employee_revenue.product_cost) AS product_cost, SUM(employee_revenue.platform_fee) AS platform_fee, " +
+			"SUM(employee_revenue.shipping_fee) AS shipping_fee, " +
+			"SUM(employee_revenue.order_count) AS order_count, SUM(employee_revenue.ad_creation_count) AS ad_creation_count").
+		Group("employee_revenue.user_id, employee_revenue.currency, employee_revenue.record_date").
+		Scan(&daily).Error
+	return daily, err
+}
+
+// sumUserRevenue 把每天的汇总换算为 conv 的目标币种后按员工累加
+func sumUserRevenue(daily []userRevenueDaily, conv *currencyConverter) (map[uint]*userRevenueTotals, error) {
+	totals := make(map[uint]*userRevenueTotals)
+	for _, row := range daily {
+		amounts, err := conv.convertAmounts(row.RevenueAmounts, row.Currency, row.RecordDate)
+		if err != nil {
+			return nil, err
+		}
+		total := totals[row.UserID]
+		if total == nil {
+			total = &userRevenueTotals{}
+			totals[row.UserID] = total
+		}
+		total.add(amounts)
+		total.OrderCount += row.OrderCount
+		total.AdCreationCount += row.AdCreationCount
+	}
+	return totals, nil
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTargetStatus(t *testing.T) {
+	tests := []struct {
+		metric                    string
+		target, actual, projected float64
+		elapsed                   int
+		want                      string
+	}{
+		{"revenue", 1000, 0, 0, 0, TargetStatusNotStarted},
+		{"revenue", 1000, 500, 1000, 15, TargetStatusOnTrack},
+		{"revenue", 1000, 450, 930, 15, TargetStatusAtRisk},
+		{"revenue", 1000, 300, 600, 15, TargetStatusBehind},
+		{"revenue", 1000, 1200, 2400, 15, TargetStatusAchieved},
+		{"revenue", 1000, 990, 990, 30, TargetStatusMissed},
+		{"roi", 2, 2.5, 2.5, 15, TargetStatusOnTrack},
+		{"roi", 2, 2.5, 2.5, 30, TargetStatusAchieved},
+		{"expenditure", 1000, 1100, 2200, 15, TargetStatusOverBudget},
+		{"expenditure", 1000, 600, 1200, 15, TargetStatusAtRisk},
+		{"expenditure", 1000, 480, 960, 15, TargetStatusOnTrack},
+		{"expenditure", 1000, 950, 950, 30, TargetStatusAchieved},
+		{"expenditure", 1000, 700, 700, 30, TargetStatusMissed},
+	}
+	for _, tt := range tests {
+		got := targetStatus(tt.metric, tt.target, tt.actual, tt.projected, tt.elapsed, 30)
+		if got != tt.want {
+			t.Errorf("targetStatus(%s, target=%v, actual=%v, projected=%v, elapsed=%d) = %s; want %s",
+				tt.metric, tt.target, tt.actual, tt.projected, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestTargetMetrics(t *testing.T) {
+	target := models.Target{Expenditure: 100000, Revenue: 300000}
+	actual := RevenueAmounts{Expenditure: 40000, Revenue: 150000}
+
+	// 月中：按已过天数的日均值推算月底；ROI 目标为 0，不计算
+	want := []TargetMetricAttainment{
+		{Metric: "expenditure", Target: 1000, Actual: 400, Percent: 0.4, Projected: 1200, ProjectedPercent: 1.2, Status: TargetStatusAtRisk},
+		{Metric: "revenue", Target: 3000, Actual: 1500, Percent: 0.5, Projected: 4500, ProjectedPercent: 1.5, Status: TargetStatusOnTrack},
+	}
+	if got := targetMetrics(target, actual, 10, 30); !reflect.DeepEqual(got, want) {
+		t.Errorf("mid-month metrics = %+v\nwant %+v", got, want)
+	}
+
+	// 还没开始的月份：推算值为 0
+	for _, m := range targetMetrics(target, RevenueAmounts{}, 0, 30) {
+		if m.Projected != 0 || m.Status != TargetStatusNotStarted {
+			t.Errorf("future month %s: projected %v, status %s; want 0, %s", m.Metric, m.Projected, m.Status, TargetStatusNotStarted)
+		}
+	}
+
+	// 已结束的月份：推算值等于实际值
+	target.ROI = 4
+	got := targetMetrics(target, actual, 30, 30)
+	if len(got) != 3 {
+		t.Fatalf("got %d metrics, want 3", len(got))
+	}
+	for _, m := range got {
+		if m.Projected != m.Actual {
+			t.Errorf("past month %s: projected %v, want actual %v", m.Metric, m.Projected, m.Actual)
+		}
+	}
+	if got[2].Metric != "roi" || got[2].Actual != 3.75 || got[2].Status != TargetStatusMissed {
+		t.Errorf("past month roi = %+v, want actual 3.75 and %s", got[2], TargetStatusMissed)
+	}
+
+	if got := targetMetrics(models.Target{}, actual, 10, 30); len(got) != 0 {
+		t.Errorf("metrics for an empty target = %+v, want none", got)
+	}
+}
+
+// targetAttainmentResponse 目标完成情况接口的响应
+type targetAttainmentResponse struct {
+	DaysElapsed int                `json:"days_elapsed"`
+	DaysTotal   int                `json:"days_total"`
+	Data        []TargetAttainment `json:"data"`
+}
+
+func getTargetAttainment(t *testing.T, c TargetController, query string) targetAttainmentResponse {
+	t.Helper()
+	w := performRequest(c.GetTargetAttainment, http.MethodGet, "/?"+query, "", 1, utils.RoleAdmin)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetTargetAttainment(%s): status %d, body %s", query, w.Code, w.Body.String())
+	}
+	var resp targetAttainmentResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// 已结束的月份按实际值、还没开始的月份不推算、本月按已过天数推算；实际数据按记录当天汇率换算为目标的币种
+func TestGetTargetAttainment(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "marketer", utils.RoleMarketer)
+	c := NewTargetController(db)
+	db.Create(&models.ExchangeRate{Date: "2025-03-01", Currency: "EUR", Rate: 1.1})
+	db.Create(&models.ExchangeRate{Date: "2025-03-20", Currency: "EUR", Rate: 1.25})
+
+	// 过去的月份：目标为 EUR，记录分别为 USD 与 EUR
+	db.Create(&models.Target{UserID: user.ID, Period: "2025-03", Currency: "EUR", Revenue: 50000})
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Expenditure: 11000, Revenue: 22000}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Expenditure: 12500, Revenue: 25000}, "2025-03-25")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, AdType: "search", Revenue: 10000, Currency: "EUR"}, "2025-03-31")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 99900}, "2025-04-01")
+
+	resp := getTargetAttainment(t, c, "period=2025-03")
+	if resp.DaysElapsed != 31 || resp.DaysTotal != 31 || len(resp.Data) != 1 {
+		t.Fatalf("past month: %+v", resp)
+	}
+	// 220 / 1.1 + 250 / 1.25 + 100 = 500 EUR
+	want := []TargetMetricAttainment{{Metric: "revenue", Target: 500, Actual: 500, Percent: 1, Projected: 500,
+		ProjectedPercent: 1, Status: TargetStatusAchieved}}
+	if got := resp.Data[0]; got.Currency != "EUR" || !reflect.DeepEqual(got.Metrics, want) {
+		t.Errorf("past month attainment = %+v\nwant %+v", got, want)
+	}
+
+	// 还没开始的月份
+	now := time.Now().In(utils.RevenueLocation())
+	future := now.AddDate(0, 2, 1-now.Day()).Format("2006-01")
+	db.Create(&models.Target{UserID: user.ID, Period: future, Currency: "USD", Expenditure: 100000, Revenue: 300000})
+	resp = getTargetAttainment(t, c, "period="+future)
+	if resp.DaysElapsed != 0 || len(resp.Data) != 1 || len(resp.Data[0].Metrics) != 2 {
+		t.Fatalf("future month: %+v", resp)
+	}
+	for _, m := range resp.Data[0].Metrics {
+		if m.Actual != 0 || m.Projected != 0 || m.Status != TargetStatusNotStarted {
+			t.Errorf("future month %+v, want not started", m)
+		}
+	}
+
+	// 本月：按今天是第几天推算月底
+	period := now.Format("2006-01")
+	db.Create(&models.Target{UserID: user.ID, Period: period, Currency: "USD", Revenue: 1000000})
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: user.ID, Revenue: 12345}, now.Format("2006-01-02"))
+	resp = getTargetAttainment(t, c, "period="+period+"&userId="+strconv.FormatUint(uint64(user.ID), 10))
+	daysTotal := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if resp.DaysElapsed != now.Day() || resp.DaysTotal != daysTotal || len(resp.Data) != 1 || len(resp.Data[0].Metrics) != 1 {
+		t.Fatalf("current month: %+v", resp)
+	}
+	projected := math.Round(12345*float64(daysTotal)/float64(now.Day())) / 100
+	if m := resp.Data[0].Metrics[0]; m.Actual != 123.45 || m.Projected != projected {
+		t.Errorf("current month revenue: actual %v, projected %v; want 123.45, %v", m.Actual, m.Projected, projected)
+	}
+
+	// 没有目标币种的汇率
+	other := createTestUser(t, db, "other", utils.RoleMarketer)
+	db.Create(&models.Target{UserID: other.ID, Period: "2025-03", Currency: "GBP", Revenue: 10000})
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: other.ID, Revenue: 10000}, "2025-03-10")
+	if w := performRequest(c.GetTargetAttainment, http.MethodGet, "/?period=2025-03", "", 1, utils.RoleAdmin); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("missing exchange rate: status %d, want 422", w.Code)
+	}
+}
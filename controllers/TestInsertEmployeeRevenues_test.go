@@ -36,16 +36,14 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 			ProductCategories: "Electronics",
 			AdType:            "CPC",
 			Region:            "North America",
-			Expenditure:       1200.0,
+			Expenditure:       1200 * models.MoneyScale,
 			OrderCount:        40,
-			ProductCost:       5000.0,
-			ShippingFee:       300.0,
-			AdDeliveryCount:   10000,
-			Date:              time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
-			Remark1:           "第一条测试记录",
-			StartTime:         time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
-			EndTime:           time.Date(2025, 3, 31, 18, 0, 0, 0, time.UTC),
-			Remark2:           "测试成功",
+			Revenue:           9000 * models.MoneyScale,
+			ProductCost:       5000 * models.MoneyScale,
+			ShippingFee:       300 * models.MoneyScale,
+			RecordTime:        time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			RecordDate:        "2025-03-01",
+			Remark:            "第一条测试记录，测试成功",
 		},
 		{
 			UserID:            2,
@@ -53,16 +51,14 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 			ProductCategories: "Books",
 			AdType:            "CPM",
 			Region:            "Europe",
-			Expenditure:       800.0,
+			Expenditure:       800 * models.MoneyScale,
 			OrderCount:        30,
-			ProductCost:       3000.0,
-			ShippingFee:       150.0,
-			AdDeliveryCount:   8000,
-			Date:              time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
-			Remark1:           "第二条测试记录",
-			StartTime:         time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC),
-			EndTime:           time.Date(2025, 3, 30, 17, 0, 0, 0, time.UTC),
-			Remark2:           "反应良好",
+			Revenue:           5000 * models.MoneyScale,
+			ProductCost:       3000 * models.MoneyScale,
+			ShippingFee:       150 * models.MoneyScale,
+			RecordTime:        time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+			RecordDate:        "2025-03-02",
+			Remark:            "第二条测试记录，反应良好",
 		},
 		{
 			UserID:            3,
@@ -70,16 +66,14 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 			ProductCategories: "Fashion",
 			AdType:            "CPC",
 			Region:            "Asia",
-			Expenditure:       1500.0,
+			Expenditure:       1500 * models.MoneyScale,
 			OrderCount:        35,
-			ProductCost:       4000.0,
-			ShippingFee:       180.0,
-			AdDeliveryCount:   9000,
-			Date:              time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
-			Remark1:           "第三条测试记录",
-			StartTime:         time.Date(2025, 3, 3, 8, 30, 0, 0, time.UTC),
-			EndTime:           time.Date(2025, 3, 29, 18, 0, 0, 0, time.UTC),
-			Remark2:           "测试中",
+			Revenue:           7000 * models.MoneyScale,
+			ProductCost:       4000 * models.MoneyScale,
+			ShippingFee:       180 * models.MoneyScale,
+			RecordTime:        time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			RecordDate:        "2025-03-03",
+			Remark:            "第三条测试记录，测试中",
 		},
 		{
 			UserID:            4,
@@ -87,16 +81,14 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 			ProductCategories: "Services",
 			AdType:            "CPM",
 			Region:            "Australia",
-			Expenditure:       1000.0,
+			Expenditure:       1000 * models.MoneyScale,
 			OrderCount:        25,
-			ProductCost:       3500.0,
-			ShippingFee:       100.0,
-			AdDeliveryCount:   7000,
-			Date:              time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
-			Remark1:           "第四条测试记录",
-			StartTime:         time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC),
-			EndTime:           time.Date(2025, 3, 28, 17, 30, 0, 0, time.UTC),
-			Remark2:           "反馈不错",
+			Revenue:           6000 * models.MoneyScale,
+			ProductCost:       3500 * models.MoneyScale,
+			ShippingFee:       100 * models.MoneyScale,
+			RecordTime:        time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+			RecordDate:        "2025-03-04",
+			Remark:            "第四条测试记录，反馈不错",
 		},
 		{
 			UserID:            5,
@@ -104,16 +96,14 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 			ProductCategories: "Entertainment",
 			AdType:            "CPC",
 			Region:            "South America",
-			Expenditure:       900.0,
+			Expenditure:       900 * models.MoneyScale,
 			OrderCount:        20,
-			ProductCost:       2800.0,
-			ShippingFee:       120.0,
-			AdDeliveryCount:   6000,
-			Date:              time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
-			Remark1:           "第五条测试记录",
-			StartTime:         time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC),
-			EndTime:           time.Date(2025, 3, 27, 16, 0, 0, 0, time.UTC),
-			Remark2:           "效果一般",
+			Revenue:           4000 * models.MoneyScale,
+			ProductCost:       2800 * models.MoneyScale,
+			ShippingFee:       120 * models.MoneyScale,
+			RecordTime:        time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			RecordDate:        "2025-03-05",
+			Remark:            "第五条测试记录，效果一般",
 		},
 	}
 
@@ -122,7 +112,12 @@ func TestInsertEmployeeRevenues(t *testing.T) {
 		if err := config.DB.Create(&record).Error; err != nil {
 			t.Errorf("Failed to insert employee revenue record: %v", err)
 		} else {
-			log.Printf("Inserted employee revenue for UserID: %d, Date: %s", record.UserID, record.Date.Format("2006-01-02"))
+			// 毛利由服务端计算：销售额 - 商品成本 - 平台手续费 - 运费 - 广告支出
+			want := record.Revenue - record.ProductCost - record.PlatformFee - record.ShippingFee - record.Expenditure
+			if record.GrossProfit != want {
+				t.Errorf("GrossProfit = %s, want %s", record.GrossProfit, want)
+			}
+			log.Printf("Inserted employee revenue for UserID: %d, Date: %s", record.UserID, record.RecordDate)
 		}
 	}
 }
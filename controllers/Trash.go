@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrashController 定义回收站接口：删除的博客、评论、用户保留一段时间，期间可以恢复
+type TrashController interface {
+	ListTrashedBlogs(ctx *gin.Context)    // 回收站中的博客（管理员查看全部，其他人查看自己的）
+	RestoreBlog(ctx *gin.Context)         // 恢复博客及随博客一起删除的评论
+	ListTrashedComments(ctx *gin.Context) // 回收站中的评论（管理员查看全部，其他人查看自己发表的和自己博客下的）
+	RestoreComment(ctx *gin.Context)      // 恢复评论
+	ListTrashedUsers(ctx *gin.Context)    // 回收站中的用户（管理员）
+	RestoreUser(ctx *gin.Context)         // 恢复用户及随用户一起删除的内容（管理员）
+}
+
+type trashController struct {
+	db *gorm.DB
+}
+
+// NewTrashController 创建一个新的 TrashController
+func NewTrashController(db *gorm.DB) TrashController {
+	return &trashController{db: db}
+}
+
+// TrashedBlog 回收站中的博客
+type TrashedBlog struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Category  string    `json:"category"`
+	Status    string    `json:"status"`
+	AuthorID  uint      `json:"author_id"`
+	Nickname  string    `json:"nickname"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" gorm:"-"` // 到期后将被彻底删除
+}
+
+// TrashedComment 回收站中的评论
+type TrashedComment struct {
+	ID        uint      `json:"id"`
+	BlogID    uint      `json:"blog_id"`
+	BlogTitle string    `json:"blog_title"`
+	UserID    uint      `json:"user_id"`
+	Nickname  string    `json:"nickname"`
+	Content   string    `json:"content"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" gorm:"-"`
+}
+
+// TrashedUser 回收站中的用户
+type TrashedUser struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Nickname  string    `json:"nickname"`
+	Email     string    `json:"email"`
+	Role      int       `json:"role"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" gorm:"-"`
+}
+
+// errAuthorTrashed 博客作者或评论人在回收站中，需先恢复用户
+var errAuthorTrashed = errors.New("author is in the trash")
+
+// trashBlogs 将博客及其评论移入回收站；评论与博客使用同一删除时间，恢复博客时一并恢复
+func trashBlogs(tx *gorm.DB, blogIDs []uint, at time.Time) error {
+	if len(blogIDs) == 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Comment{}).Where("blog_id IN ?", blogIDs).UpdateColumn("deleted_at", at).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Blog{}).Where("id IN ?", blogIDs).UpdateColumn("deleted_at", at).Error
+}
+
+// restoreBlogs 恢复在 at 时刻删除的博客及随之删除的评论（之前单独删除的评论仍留在回收站），并重新计算评论数
+func restoreBlogs(tx *gorm.DB, blogIDs []uint, at time.Time) error {
+	if len(blogIDs) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("blog_id IN ? AND deleted_at = ?", blogIDs, at).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("id IN ? AND deleted_at = ?", blogIDs, at).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	return syncBlogCommentStats(tx.Where("id IN ?", blogIDs))
+}
+
+// trashUser 将用户移入回收站，并级联删除：其博客（连同博客下的评论）、其发表的评论、其收益记录。
+// 关注、点赞、收藏、通知等关联数据保留，恢复用户后原样可用；清理任务彻底删除用户时才一并删除
+func trashUser(tx *gorm.DB, userID uint, at time.Time) error {
+	result := tx.Model(&models.Users{}).Where("id = ?", userID).UpdateColumn("deleted_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var blogIDs []uint
+	if err := tx.Model(&models.Blog{}).Where("author_id = ?", userID).Pluck("id", &blogIDs).Error; err != nil {
+		return err
+	}
+	if err := trashBlogs(tx, blogIDs, at); err != nil {
+		return err
+	}
+
+	var commented []uint
+	if err := tx.Model(&models.Comment{}).Where("user_id = ?", userID).Distinct("blog_id").Pluck("blog_id", &commented).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Comment{}).Where("user_id = ?", userID).UpdateColumn("deleted_at", at).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.EmployeeRevenue{}).Where("user_id = ?", userID).UpdateColumn("deleted_at", at).Error; err != nil {
+		return err
+	}
+	if len(commented) == 0 {
+		return nil
+	}
+	return syncBlogCommentStats(tx.Where("id IN ?", commented))
+}
+
+// restoreUser 恢复用户以及与用户同一时刻被级联删除的博客、评论和收益记录
+func restoreUser(tx *gorm.DB, user *models.Users) error {
+	at := user.DeletedAt.Time
+
+	var blogIDs []uint
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("author_id = ? AND deleted_at = ?", user.ID, at).
+		Pluck("id", &blogIDs).Error; err != nil {
+		return err
+	}
+	var commented []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ? AND deleted_at = ?", user.ID, at).
+		Distinct("blog_id").Pluck("blog_id", &commented).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(&models.Users{}).Where("id = ?", user.ID).UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ? AND deleted_at = ?", user.ID, at).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.EmployeeRevenue{}).Where("user_id = ? AND deleted_at = ?", user.ID, at).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	if err := restoreBlogs(tx, blogIDs, at); err != nil {
+		return err
+	}
+	if len(commented) == 0 {
+		return nil
+	}
+	return syncBlogCommentStats(tx.Where("id IN ?", commented))
+}
+
+// trashPage 统计总数并按删除时间倒序取一页（多取一条判断是否有下一页）
+func trashPage(ctx *gin.Context, query *gorm.DB, table, columns string, dest interface{}) (utils.Pagination, int64, bool) {
+	p, err := utils.ParsePagination(ctx, 20)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return p, 0, false
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count trash"})
+		return p, 0, false
+	}
+	if err := p.Apply(query.Select(columns), table+".deleted_at", table+".id").Scan(dest).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return p, 0, false
+	}
+	return p, total, true
+}
+
+// ListTrashedBlogs 获取回收站中的博客，按删除时间倒序分页
+func (c *trashController) ListTrashedBlogs(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	query := c.db.Table("blog").
+		Joins("LEFT JOIN users ON users.id = blog.author_id").
+		Where("blog.deleted_at IS NOT NULL")
+	if role > utils.RoleAdmin {
+		query = query.Where("blog.author_id = ?", userID)
+	}
+
+	var blogs []TrashedBlog
+	p, total, ok := trashPage(ctx, query, "blog",
+		"blog.id, blog.title, blog.category, blog.status, blog.author_id, users.nickname, blog.deleted_at", &blogs)
+	if !ok {
+		return
+	}
+
+	nextCursor := ""
+	if len(blogs) > p.Limit {
+		blogs = blogs[:p.Limit]
+		last := blogs[len(blogs)-1]
+		nextCursor = utils.EncodeCursor(last.DeletedAt, last.ID)
+	}
+	if blogs == nil {
+		blogs = []TrashedBlog{}
+	}
+	for i := range blogs {
+		blogs[i].PurgeAt = blogs[i].DeletedAt.Add(utils.TrashRetention())
+	}
+
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = blogs
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RestoreBlog 恢复回收站中的博客（所有者或管理员）；作者本人在回收站中时需先恢复作者
+func (c *trashController) RestoreBlog(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	var blog models.Blog
+	if err := c.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", ctx.Param("id")).First(&blog).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Blog not found in trash"})
+		return
+	}
+	blogRole, err := blogCollaboratorRole(c.db, &blog, userID, role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission"})
+		return
+	}
+	if blogRole != models.CollaboratorOwner {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		var authors int64
+		if err := tx.Model(&models.Users{}).Where("id = ?", blog.AuthorID).Count(&authors).Error; err != nil {
+			return err
+		}
+		if authors == 0 {
+			return errAuthorTrashed
+		}
+		if err := restoreBlogs(tx, []uint{blog.ID}, blog.DeletedAt.Time); err != nil {
+			return err
+		}
+		return recordBlogAudit(tx, blog.ID, userID, "restore", gin.H{"title": blog.Title})
+	})
+	if errors.Is(err, errAuthorTrashed) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The author is in the trash, restore the user first"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore blog"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Blog restored", "id": blog.ID})
+}
+
+// ListTrashedComments 获取回收站中的评论；随博客或用户一起删除的评论不在此列出，恢复博客或用户时一并恢复
+func (c *trashController) ListTrashedComments(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	query := c.db.Table("comments").
+		Joins("JOIN blog ON blog.id = comments.blog_id AND blog.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = comments.user_id AND users.deleted_at IS NULL").
+		Where("comments.deleted_at IS NOT NULL")
+	if role > utils.RoleAdmin {
+		query = query.Where("(comments.user_id = ? OR blog.author_id = ?)", userID, userID)
+	}
+
+	var comments []TrashedComment
+	p, total, ok := trashPage(ctx, query, "comments",
+		"comments.id, comments.blog_id, blog.title as blog_title, comments.user_id, users.nickname, comments.content, comments.deleted_at", &comments)
+	if !ok {
+		return
+	}
+
+	nextCursor := ""
+	if len(comments) > p.Limit {
+		comments = comments[:p.Limit]
+		last := comments[len(comments)-1]
+		nextCursor = utils.EncodeCursor(last.DeletedAt, last.ID)
+	}
+	if comments == nil {
+		comments = []TrashedComment{}
+	}
+	for i := range comments {
+		comments[i].PurgeAt = comments[i].DeletedAt.Add(utils.TrashRetention())
+	}
+
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = comments
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RestoreComment 恢复回收站中的评论（评论人、博客作者或管理员）；所在博客或评论人在回收站中时无法单独恢复
+func (c *trashController) RestoreComment(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	roleRaw, _ := ctx.Get("role")
+	role, _ := roleRaw.(int)
+
+	var comment models.Comment
+	if err := c.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", ctx.Param("id")).First(&comment).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found in trash"})
+		return
+	}
+
+	var blog models.Blog
+	if err := c.db.First(&blog, comment.BlogID).Error; err != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The blog is in the trash, restore the blog first"})
+		return
+	}
+	if role > utils.RoleAdmin && comment.UserID != userID && blog.AuthorID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var users int64
+		if err := tx.Model(&models.Users{}).Where("id = ?", comment.UserID).Count(&users).Error; err != nil {
+			return err
+		}
+		if users == 0 {
+			return errAuthorTrashed
+		}
+		if err := tx.Unscoped().Model(&comment).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return syncBlogCommentStats(tx.Where("id = ?", comment.BlogID))
+	})
+	if errors.Is(err, errAuthorTrashed) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "The commenter is in the trash, restore the user first"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore comment"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment restored", "id": comment.ID})
+}
+
+// ListTrashedUsers 获取回收站中的用户（管理员），按删除时间倒序分页
+func (c *trashController) ListTrashedUsers(ctx *gin.Context) {
+	query := c.db.Table("users").Where("users.deleted_at IS NOT NULL")
+
+	var users []TrashedUser
+	p, total, ok := trashPage(ctx, query, "users",
+		"users.id, users.username, users.nickname, users.email, users.role, users.deleted_at", &users)
+	if !ok {
+		return
+	}
+
+	nextCursor := ""
+	if len(users) > p.Limit {
+		users = users[:p.Limit]
+		last := users[len(users)-1]
+		nextCursor = utils.EncodeCursor(last.DeletedAt, last.ID)
+	}
+	if users == nil {
+		users = []TrashedUser{}
+	}
+	for i := range users {
+		users[i].PurgeAt = users[i].DeletedAt.Add(utils.TrashRetention())
+	}
+
+	resp := p.Meta(total, nextCursor)
+	resp["data"] = users
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RestoreUser 恢复回收站中的用户（管理员），同时恢复随用户一起删除的博客、评论和收益记录
+func (c *trashController) RestoreUser(ctx *gin.Context) {
+	var user models.Users
+	if err := c.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", ctx.Param("id")).First(&user).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found in trash"})
+		return
+	}
+
+	if err := c.db.Transaction(func(tx *gorm.DB) error {
+		return restoreUser(tx, &user)
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore user"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "User restored", "id": user.ID})
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize 每个事务彻底删除的记录数，避免一次删除过多数据长时间锁表
+const purgeBatchSize = 200
+
+// TrashPurger 回收站清理任务：按 trash.purge_schedule 定时彻底删除超过保留期的博客、评论和用户
+type TrashPurger struct {
+	db   *gorm.DB
+	cron *cron.Cron
+}
+
+// TrashPurgeResult 一次清理彻底删除的数量
+type TrashPurgeResult struct {
+	Users    int `json:"users"`
+	Blogs    int `json:"blogs"`
+	Comments int `json:"comments"`
+}
+
+// NewTrashPurger 创建回收站清理任务
+func NewTrashPurger(db *gorm.DB) *TrashPurger {
+	return &TrashPurger{db: db, cron: cron.New()}
+}
+
+// Start 按配置的 cron 表达式启动清理任务
+func (p *TrashPurger) Start() {
+	schedule := utils.TrashPurgeSchedule()
+	if _, err := p.cron.AddFunc(schedule, p.run); err != nil {
+		log.Printf("Invalid trash purge schedule %q: %v", schedule, err)
+		return
+	}
+	p.cron.Start()
+}
+
+func (p *TrashPurger) run() {
+	result, err := PurgeTrash(p.db, time.Now().Add(-utils.TrashRetention()))
+	if err != nil {
+		log.Printf("Failed to purge trash: %v", err)
+		return
+	}
+	if result.Users+result.Blogs+result.Comments > 0 {
+		log.Printf("Purged trash: %d users, %d blogs, %d comments", result.Users, result.Blogs, result.Comments)
+	}
+}
+
+// PurgeTrash 彻底删除 before 之前进入回收站的用户、博客和评论及其关联数据。
+// 删除用户时：其博客与评论、系列、收益与充值记录、点赞收藏、关注、通知、协作关系一并删除；
+// 删除博客时：评论、点赞收藏、浏览统计、协作者、修改记录、审核记录、系列位置一并删除；
+// 删除评论时：表情回应一并删除，回复它的评论变为顶层评论
+func PurgeTrash(db *gorm.DB, before time.Time) (TrashPurgeResult, error) {
+	var result TrashPurgeResult
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(&models.Users{}).Where("deleted_at < ?", before).
+			Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			if err != nil {
+				return result, err
+			}
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeUsers(tx, ids) }); err != nil {
+			return result, err
+		}
+		result.Users += len(ids)
+	}
+
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(&models.Blog{}).Where("deleted_at < ?", before).
+			Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			if err != nil {
+				return result, err
+			}
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeBlogs(tx, ids) }); err != nil {
+			return result, err
+		}
+		result.Blogs += len(ids)
+	}
+
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(&models.Comment{}).Where("deleted_at < ?", before).
+			Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			if err != nil {
+				return result, err
+			}
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeComments(tx, ids) }); err != nil {
+			return result, err
+		}
+		result.Comments += len(ids)
+	}
+	return result, nil
+}
+
+// purgeUsers 彻底删除用户及其全部数据，并重新计算受影响博客与评论上的计数
+func purgeUsers(tx *gorm.DB, userIDs []uint) error {
+	var blogIDs []uint
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("author_id IN ?", userIDs).Pluck("id", &blogIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeBlogs(tx, blogIDs); err != nil {
+		return err
+	}
+
+	var commentIDs, commentedBlogs []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id IN ?", userIDs).Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id IN ?", userIDs).Distinct("blog_id").Pluck("blog_id", &commentedBlogs).Error; err != nil {
+		return err
+	}
+	if err := purgeComments(tx, commentIDs); err != nil {
+		return err
+	}
+	if len(commentedBlogs) > 0 {
+		if err := syncBlogCommentStats(tx.Where("id IN ?", commentedBlogs)); err != nil {
+			return err
+		}
+	}
+
+	// 用户创建的系列（其中的文章已随用户删除或属于其他作者，只删除系列本身）
+	var seriesIDs []uint
+	if err := tx.Model(&models.Series{}).Where("user_id IN ?", userIDs).Pluck("id", &seriesIDs).Error; err != nil {
+		return err
+	}
+	if len(seriesIDs) > 0 {
+		if err := tx.Where("series_id IN ?", seriesIDs).Delete(&models.SeriesItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", seriesIDs).Delete(&models.Series{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 该用户在其他博客、评论上的点赞、收藏、表情回应：删除后重新计数
+	var likedBlogs, bookmarkedBlogs, reactedComments []uint
+	if err := tx.Model(&models.BlogLike{}).Where("user_id IN ?", userIDs).Distinct("blog_id").Pluck("blog_id", &likedBlogs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.BlogBookmark{}).Where("user_id IN ?", userIDs).Distinct("blog_id").Pluck("blog_id", &bookmarkedBlogs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.CommentReaction{}).Where("user_id IN ?", userIDs).Distinct("comment_id").Pluck("comment_id", &reactedComments).Error; err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&models.BlogLike{}, &models.BlogBookmark{}, &models.CommentReaction{},
+		&models.BlogCollaborator{}, &models.Notification{}, &models.RechargeTransaction{}} {
+		if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&models.EmployeeRevenue{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("follower_id IN ? OR followee_id IN ?", userIDs, userIDs).Delete(&models.UserFollow{}).Error; err != nil {
+		return err
+	}
+
+	if len(likedBlogs) > 0 {
+		if err := tx.Unscoped().Model(&models.Blog{}).Where("id IN ?", likedBlogs).
+			UpdateColumn("like_count", gorm.Expr("(SELECT COUNT(*) FROM blog_likes WHERE blog_likes.blog_id = blog.id)")).Error; err != nil {
+			return err
+		}
+	}
+	if len(bookmarkedBlogs) > 0 {
+		if err := tx.Unscoped().Model(&models.Blog{}).Where("id IN ?", bookmarkedBlogs).
+			UpdateColumn("bookmark_count", gorm.Expr("(SELECT COUNT(*) FROM blog_bookmarks WHERE blog_bookmarks.blog_id = blog.id)")).Error; err != nil {
+			return err
+		}
+	}
+	if len(reactedComments) > 0 {
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("id IN ?", reactedComments).
+			UpdateColumn("reaction_count", gorm.Expr("(SELECT COUNT(*) FROM comment_reactions WHERE comment_reactions.comment_id = comments.id)")).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id IN ?", userIDs).Delete(&models.Users{}).Error
+}
+
+// purgeBlogs 彻底删除博客及其全部关联数据（修改记录只对博客本身有意义，一并删除）
+func purgeBlogs(tx *gorm.DB, blogIDs []uint) error {
+	if len(blogIDs) == 0 {
+		return nil
+	}
+
+	var commentIDs []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("blog_id IN ?", blogIDs).Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeComments(tx, commentIDs); err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&models.BlogLike{}, &models.BlogBookmark{}, &models.BlogViewDaily{},
+		&models.BlogReferrerDaily{}, &models.BlogCollaborator{}, &models.BlogAuditLog{}, &models.BlogReview{},
+		&models.BlogReviewComment{}, &models.SeriesItem{}} {
+		if err := tx.Where("blog_id IN ?", blogIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Where("id IN ?", blogIDs).Delete(&models.Blog{}).Error
+}
+
+// purgeComments 彻底删除评论及其表情回应；回复这些评论的评论改为顶层评论
+func purgeComments(tx *gorm.DB, commentIDs []uint) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("comment_id IN ?", commentIDs).Delete(&models.CommentReaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("parent_id IN ?", commentIDs).
+		UpdateColumn("parent_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", commentIDs).Delete(&models.Comment{}).Error
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashed 记录是否在回收站中（软删除）；记录已被彻底删除时测试失败
+func trashed(t *testing.T, db *gorm.DB, model interface{}, id uint) bool {
+	t.Helper()
+	var count int64
+	if err := db.Unscoped().Model(model).Where("id = ?", id).Count(&count).Error; err != nil || count == 0 {
+		t.Fatalf("%T %d not found: %v", model, id, err)
+	}
+	db.Model(model).Where("id = ?", id).Count(&count)
+	return count == 0
+}
+
+// exists 记录是否还在数据库中（包括回收站）
+func exists(db *gorm.DB, model interface{}, id uint) bool {
+	var count int64
+	db.Unscoped().Model(model).Where("id = ?", id).Count(&count)
+	return count > 0
+}
+
+// 删除用户时级联删除其博客（连同博客下的评论）、评论和收益记录；恢复用户时只恢复同一时刻删除的内容
+func TestTrashAndRestoreUser(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	alice := createTestUser(t, db, "alice", utils.RoleMarketer)
+	bob := createTestUser(t, db, "bob", utils.RoleUser)
+
+	aliceBlog := createTestBlog(t, db, alice.ID, "alice blog")
+	bobBlog := createTestBlog(t, db, bob.ID, "bob blog")
+	bobOnAlice := createTestComment(t, db, aliceBlog.ID, bob.ID, "bob on alice")
+	aliceOnBob := createTestComment(t, db, bobBlog.ID, alice.ID, "alice on bob")
+	revenue := createTestRevenue(t, db, models.EmployeeRevenue{UserID: alice.ID, Revenue: 1000}, "2025-03-10")
+
+	// 在删除用户之前单独删除的评论
+	earlier := time.Now().Add(-time.Hour)
+	aliceDeleted := createTestComment(t, db, bobBlog.ID, alice.ID, "alice deleted")
+	bobDeleted := createTestComment(t, db, aliceBlog.ID, bob.ID, "bob deleted")
+	db.Model(&models.Comment{}).Where("id IN ?", []uint{aliceDeleted.ID, bobDeleted.ID}).UpdateColumn("deleted_at", earlier)
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return trashUser(tx, alice.ID, time.Now()) }); err != nil {
+		t.Fatalf("trashUser: %v", err)
+	}
+	for _, c := range []struct {
+		model interface{}
+		id    uint
+		want  bool
+	}{
+		{&models.Users{}, alice.ID, true},
+		{&models.Blog{}, aliceBlog.ID, true},
+		{&models.Comment{}, bobOnAlice.ID, true}, // 随博客删除
+		{&models.Comment{}, aliceOnBob.ID, true}, // 用户发表的评论
+		{&models.EmployeeRevenue{}, revenue.ID, true},
+		{&models.Users{}, bob.ID, false},
+		{&models.Blog{}, bobBlog.ID, false},
+	} {
+		if got := trashed(t, db, c.model, c.id); got != c.want {
+			t.Errorf("after trash: %T %d trashed = %v, want %v", c.model, c.id, got, c.want)
+		}
+	}
+	var blog models.Blog
+	db.First(&blog, bobBlog.ID)
+	if blog.CommentCount != 0 {
+		t.Errorf("bob blog comment_count = %d after trash, want 0", blog.CommentCount)
+	}
+
+	w := performRequest(NewTrashController(db).RestoreUser, http.MethodPost, "/", "", admin.ID, utils.RoleAdmin,
+		gin.Param{Key: "id", Value: strconv.Itoa(int(alice.ID))})
+	if w.Code != http.StatusOK {
+		t.Fatalf("RestoreUser: status %d, body %s", w.Code, w.Body.String())
+	}
+	for _, c := range []struct {
+		model interface{}
+		id    uint
+		want  bool
+	}{
+		{&models.Users{}, alice.ID, false},
+		{&models.Blog{}, aliceBlog.ID, false},
+		{&models.Comment{}, bobOnAlice.ID, false},
+		{&models.Comment{}, aliceOnBob.ID, false},
+		{&models.EmployeeRevenue{}, revenue.ID, false},
+		// 删除时间不同，仍留在回收站
+		{&models.Comment{}, aliceDeleted.ID, true},
+		{&models.Comment{}, bobDeleted.ID, true},
+	} {
+		if got := trashed(t, db, c.model, c.id); got != c.want {
+			t.Errorf("after restore: %T %d trashed = %v, want %v", c.model, c.id, got, c.want)
+		}
+	}
+	for _, id := range []uint{aliceBlog.ID, bobBlog.ID} {
+		var restored models.Blog
+		db.First(&restored, id)
+		if restored.CommentCount != 1 {
+			t.Errorf("blog %d comment_count = %d after restore, want 1", id, restored.CommentCount)
+		}
+	}
+}
+
+// 作者在回收站中时不能单独恢复博客；恢复博客只恢复随博客一起删除的评论
+func TestRestoreBlog(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	alice := createTestUser(t, db, "alice", utils.RoleUser)
+	bob := createTestUser(t, db, "bob", utils.RoleUser)
+	blog := createTestBlog(t, db, alice.ID, "alice blog")
+	kept := createTestComment(t, db, blog.ID, bob.ID, "kept")
+	deleted := createTestComment(t, db, blog.ID, bob.ID, "deleted")
+	db.Model(&deleted).UpdateColumn("deleted_at", time.Now().Add(-time.Hour))
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return trashBlogs(tx, []uint{blog.ID}, time.Now()) }); err != nil {
+		t.Fatalf("trashBlogs: %v", err)
+	}
+	restore := func(userID uint, role int) int {
+		return performRequest(NewTrashController(db).RestoreBlog, http.MethodPost, "/", "", userID, role,
+			gin.Param{Key: "id", Value: strconv.Itoa(int(blog.ID))}).Code
+	}
+
+	if code := restore(bob.ID, utils.RoleUser); code != http.StatusForbidden {
+		t.Errorf("restore by other user: status %d, want 403", code)
+	}
+	db.Model(&alice).UpdateColumn("deleted_at", time.Now())
+	if code := restore(admin.ID, utils.RoleAdmin); code != http.StatusConflict {
+		t.Errorf("restore with trashed author: status %d, want 409", code)
+	}
+	db.Unscoped().Model(&alice).UpdateColumn("deleted_at", nil)
+
+	if code := restore(alice.ID, utils.RoleUser); code != http.StatusOK {
+		t.Fatalf("restore by author: status %d, want 200", code)
+	}
+	if trashed(t, db, &models.Blog{}, blog.ID) || trashed(t, db, &models.Comment{}, kept.ID) {
+		t.Error("blog and its comment should be restored")
+	}
+	if !trashed(t, db, &models.Comment{}, deleted.ID) {
+		t.Error("comment deleted before the blog should stay in the trash")
+	}
+	var stored models.Blog
+	db.First(&stored, blog.ID)
+	if stored.CommentCount != 1 {
+		t.Errorf("comment_count = %d, want 1", stored.CommentCount)
+	}
+}
+
+// 清理任务只彻底删除超过 trash.retention_days 的数据
+func TestTrashPurgerRetention(t *testing.T) {
+	old := utils.AppConfig.Trash.RetentionDays
+	utils.AppConfig.Trash.RetentionDays = 7
+	t.Cleanup(func() { utils.AppConfig.Trash.RetentionDays = old })
+
+	db := newTestDB(t)
+	expired := createTestUser(t, db, "expired", utils.RoleMarketer)
+	recent := createTestUser(t, db, "recent", utils.RoleUser)
+	active := createTestUser(t, db, "active", utils.RoleUser)
+
+	expiredBlog := createTestBlog(t, db, expired.ID, "expired user blog")
+	expiredComment := createTestComment(t, db, expiredBlog.ID, active.ID, "on expired blog")
+	expiredRevenue := createTestRevenue(t, db, models.EmployeeRevenue{UserID: expired.ID, Revenue: 1000}, "2025-03-10")
+	recentBlog := createTestBlog(t, db, recent.ID, "recent user blog")
+	activeBlog := createTestBlog(t, db, active.ID, "active blog")
+	oldTrashedBlog := createTestBlog(t, db, active.ID, "old trashed blog")
+	newTrashedComment := createTestComment(t, db, activeBlog.ID, active.ID, "new trashed comment")
+	oldTrashedComment := createTestComment(t, db, activeBlog.ID, active.ID, "old trashed comment")
+
+	now := time.Now()
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := trashUser(tx, expired.ID, now.AddDate(0, 0, -10)); err != nil {
+			return err
+		}
+		if err := trashUser(tx, recent.ID, now.AddDate(0, 0, -3)); err != nil {
+			return err
+		}
+		return trashBlogs(tx, []uint{oldTrashedBlog.ID}, now.AddDate(0, 0, -8))
+	}); err != nil {
+		t.Fatalf("Failed to trash: %v", err)
+	}
+	db.Model(&newTrashedComment).UpdateColumn("deleted_at", now.AddDate(0, 0, -6))
+	db.Model(&oldTrashedComment).UpdateColumn("deleted_at", now.AddDate(0, 0, -7).Add(-time.Minute))
+
+	NewTrashPurger(db).run()
+
+	for _, c := range []struct {
+		model interface{}
+		id    uint
+		want  bool
+	}{
+		{&models.Users{}, expired.ID, false},
+		{&models.Blog{}, expiredBlog.ID, false},
+		{&models.Comment{}, expiredComment.ID, false},
+		{&models.EmployeeRevenue{}, expiredRevenue.ID, false},
+		{&models.Blog{}, oldTrashedBlog.ID, false},
+		{&models.Comment{}, oldTrashedComment.ID, false},
+		{&models.Users{}, recent.ID, true},
+		{&models.Blog{}, recentBlog.ID, true},
+		{&models.Comment{}, newTrashedComment.ID, true},
+		{&models.Users{}, active.ID, true},
+		{&models.Blog{}, activeBlog.ID, true},
+	} {
+		if got := exists(db, c.model, c.id); got != c.want {
+			t.Errorf("after purge: %T %d exists = %v, want %v", c.model, c.id, got, c.want)
+		}
+	}
+	if !trashed(t, db, &models.Users{}, recent.ID) {
+		t.Error("recently trashed user should stay in the trash")
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"blog/models"
 	"blog/utils"
+	"errors"
 	"gorm.io/gorm"
 	"log"
 	"net/http"
@@ -53,9 +54,14 @@ func (c *userController) RegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否存在
+	if isReservedUsername(userInput.Username) {
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已被注册"})
+		return
+	}
+
+	// 检查用户名是否存在（回收站中的用户仍占用用户名）
 	var existingUser models.Users
-	if err := c.db.Where("username = ?", userInput.Username).First(&existingUser).Error; err == nil {
+	if err := c.db.Unscoped().Where("username = ?", userInput.Username).First(&existingUser).Error; err == nil {
 		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已被注册"})
 		return
 	}
@@ -151,6 +157,10 @@ func (c *userController) UpdateUserProfile(ctx *gin.Context) {
 	}
 
 	// 可选：检查用户名是否重复、邮箱格式是否合法等校验逻辑
+	if isReservedUsername(updateData.Username) {
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已被占用"})
+		return
+	}
 
 	// 更新用户数据（仅更新提供的字段）
 	if err := c.db.Model(&models.Users{}).Where("id = ?", userID).Updates(updateData).Error; err != nil {
@@ -201,21 +211,58 @@ func (c *userController) GetAdminAllUsers(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": users})
 }
 
+// DeleteUser 删除用户：必须选择将其博客与收益记录转给其他用户（mode=reassign&target_id=）或匿名化（mode=anonymize），
+// 在同一事务中完成转移并将用户移入回收站；不能删除超级管理员和自己
 func (c *userController) DeleteUser(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 
 	id, err := strconv.Atoi(idStr)
-	if err != nil {
+	if err != nil || id < 1 {
 		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "非法用户ID"})
 		return
 	}
 
-	if err := c.db.Where("id = ?", id).Delete(&models.Users{}).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "删除用户失败"})
+	operatorID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var input UserDeletionInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "输入格式错误"})
+		return
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "输入格式错误"})
+			return
+		}
+	}
+	if input.Mode != UserDeletionReassign && input.Mode != UserDeletionAnonymize {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "请选择内容处理方式：reassign（转给其他用户）或 anonymize（匿名化）"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "删除成功"})
+	var summary *UserDeletionSummary
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		summary, err = deleteUserWithContent(tx, uint(id), operatorID, input)
+		return err
+	})
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "删除成功", "data": summary})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "用户不存在"})
+	case errors.Is(err, errDeleteSelf):
+		ctx.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "不能删除自己"})
+	case errors.Is(err, errDeleteSuperAdmin):
+		ctx.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "不能删除超级管理员"})
+	case errors.Is(err, errInvalidTarget):
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "接收内容的用户不存在或无效"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "删除用户失败"})
+	}
 }
 
 // ✅ 管理员新增用户
@@ -226,9 +273,14 @@ func (c *userController) CreateUserByAdmin(ctx *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否已存在
+	if isReservedUsername(userInput.Username) {
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已存在"})
+		return
+	}
+
+	// 检查用户名是否已存在（回收站中的用户仍占用用户名）
 	var existingUser models.Users
-	if err := c.db.Where("username = ?", userInput.Username).First(&existingUser).Error; err == nil {
+	if err := c.db.Unscoped().Where("username = ?", userInput.Username).First(&existingUser).Error; err == nil {
 		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已存在"})
 		return
 	}
@@ -289,6 +341,11 @@ func (c *userController) UpdateUserByAdmin(ctx *gin.Context) {
 		return
 	}
 
+	if isReservedUsername(updateData.Username) {
+		ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "用户名已被占用"})
+		return
+	}
+
 	//// 角色权限校验（管理员不可修改超管权限）
 	//if updateData.Role < utils.RoleUser || updateData.Role > utils.RoleAdmin {
 	//	ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "非法角色"})
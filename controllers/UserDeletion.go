@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 删除用户时其博客与收益记录的处理方式
+const (
+	UserDeletionReassign  = "reassign"  // 转给指定用户
+	UserDeletionAnonymize = "anonymize" // 转给匿名账号
+)
+
+// anonymousUsername 匿名账号的用户名：匿名化删除的用户内容统一归到该账号下。
+// 含有 IsValidUsername 不允许的冒号，注册和修改用户名时也单独保留，普通用户无法占用
+const anonymousUsername = "system:deleted_user"
+
+// isReservedUsername 是否为系统保留的用户名
+func isReservedUsername(username string) bool {
+	return strings.EqualFold(strings.TrimSpace(username), anonymousUsername)
+}
+
+// UserDeletionInput 删除用户的参数（query 或 JSON 请求体）
+type UserDeletionInput struct {
+	Mode     string `form:"mode" json:"mode"`           // reassign 或 anonymize
+	TargetID uint   `form:"target_id" json:"target_id"` // reassign 时接收内容的用户ID
+}
+
+// UserDeletionSummary 删除用户的结果汇总
+type UserDeletionSummary struct {
+	UserID         uint   `json:"user_id"`
+	Mode           string `json:"mode"`
+	TargetUserID   uint   `json:"target_user_id"` // 接收内容的用户（匿名化时为匿名账号）
+	Blogs          int64  `json:"blogs"`          // 转移的博客（含回收站中的）
+	RevenueRecords int64  `json:"revenue_records"`
+	RevenueMerged  int64  `json:"revenue_merged"` // 与接收人同日同平台的记录，合并到接收人的记录上
+	Series         int64  `json:"series"`
+	Collaborators  int64  `json:"collaborators"` // 转移的所有者协作关系
+	Comments       int64  `json:"comments"`      // 随用户移入回收站的评论
+}
+
+var (
+	errDeleteSelf       = errors.New("cannot delete yourself")
+	errDeleteSuperAdmin = errors.New("cannot delete the super admin")
+	errInvalidTarget    = errors.New("invalid target user")
+)
+
+// deleteUserWithContent 在同一事务中转移（或匿名化）用户的博客与收益记录，再将用户移入回收站。
+// 评论属于个人发言，不转移，随用户进入回收站
+func deleteUserWithContent(tx *gorm.DB, userID, operatorID uint, input UserDeletionInput) (*UserDeletionSummary, error) {
+	if userID == operatorID {
+		return nil, errDeleteSelf
+	}
+	var user models.Users
+	if err := tx.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.Role == utils.RoleSuperAdmin {
+		return nil, errDeleteSuperAdmin
+	}
+
+	summary := &UserDeletionSummary{UserID: userID, Mode: input.Mode}
+	switch input.Mode {
+	case UserDeletionReassign:
+		var target models.Users
+		if input.TargetID == 0 || input.TargetID == userID || tx.First(&target, input.TargetID).Error != nil {
+			return nil, errInvalidTarget
+		}
+		summary.TargetUserID = target.ID
+	case UserDeletionAnonymize:
+		anonymous, err := anonymousUser(tx)
+		if err != nil {
+			return nil, err
+		}
+		if anonymous.ID == userID {
+			return nil, errInvalidTarget
+		}
+		summary.TargetUserID = anonymous.ID
+	default:
+		return nil, errInvalidTarget
+	}
+
+	if err := transferUserContent(tx, userID, summary.TargetUserID, operatorID, summary); err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.Comment{}).Where("user_id = ?", userID).Count(&summary.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := trashUser(tx, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// transferUserContent 将博客（含回收站中的）、所有者协作关系、系列和收益记录从 fromID 转给 toID，每篇博客写入修改记录；
+// 收益记录与接收人已有记录唯一键相同时合并
+func transferUserContent(tx *gorm.DB, fromID, toID, operatorID uint, summary *UserDeletionSummary) error {
+	var blogIDs []uint
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("author_id = ?", fromID).Pluck("id", &blogIDs).Error; err != nil {
+		return err
+	}
+	summary.Blogs = int64(len(blogIDs))
+
+	if len(blogIDs) > 0 {
+		if err := tx.Unscoped().Model(&models.Blog{}).Where("id IN ?", blogIDs).
+			UpdateColumns(map[string]interface{}{"author_id": toID, "user_id": toID}).Error; err != nil {
+			return err
+		}
+		// 接收人原本是这些博客的编辑/查看者时，改为所有者
+		if err := tx.Where("blog_id IN ? AND user_id = ?", blogIDs, toID).Delete(&models.BlogCollaborator{}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.BlogCollaborator{}).Where("blog_id IN ? AND user_id = ?", blogIDs, fromID).
+			UpdateColumn("user_id", toID)
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.Collaborators = result.RowsAffected
+
+		for _, blogID := range blogIDs {
+			if err := recordBlogAudit(tx, blogID, operatorID, "reassign", map[string]interface{}{
+				"from": fromID, "to": toID, "mode": summary.Mode,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	result := tx.Model(&models.Series{}).Where("user_id = ?", fromID).UpdateColumn("user_id", toID)
+	if result.Error != nil {
+		return result.Error
+	}
+	summary.Series = result.RowsAffected
+
+	// 接收人已有同一唯一键的收益记录时合并，避免转移后出现重复记录
+	var collisions []struct {
+		FromID uint
+		ToID   uint
+	}
+	if err := tx.Raw(`SELECT f.id AS from_id, t.id AS to_id FROM employee_revenue f
+		JOIN employee_revenue t ON t.user_id = ? AND t.record_date = f.record_date AND t.ad_platform = f.ad_platform
+			AND t.ad_type = f.ad_type AND t.region = f.region AND t.deleted_at IS NULL
+		WHERE f.user_id = ? AND f.deleted_at IS NULL`, toID, fromID).Scan(&collisions).Error; err != nil {
+		return err
+	}
+	for _, collision := range collisions {
+		var keep, dup models.EmployeeRevenue
+		if err := tx.First(&keep, collision.ToID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&dup, collision.FromID).Error; err != nil {
+			return err
+		}
+		if err := mergeRevenueRecords(tx, &keep, []models.EmployeeRevenue{dup}); err != nil {
+			return err
+		}
+	}
+	summary.RevenueMerged = int64(len(collisions))
+
+	result = tx.Unscoped().Model(&models.EmployeeRevenue{}).Where("user_id = ?", fromID).UpdateColumn("user_id", toID)
+	if result.Error != nil {
+		return result.Error
+	}
+	summary.RevenueRecords = result.RowsAffected
+	return nil
+}
+
+// anonymousUser 获取匿名账号，不存在时创建（禁用状态、随机密码），在回收站中时恢复
+func anonymousUser(tx *gorm.DB) (models.Users, error) {
+	var user models.Users
+	err := tx.Unscoped().Where("username = ?", anonymousUsername).First(&user).Error
+	if err == nil {
+		if user.DeletedAt.Valid {
+			err = tx.Unscoped().Model(&user).UpdateColumn("deleted_at", nil).Error
+		}
+		return user, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	user = models.Users{
+		Username: anonymousUsername,
+		Nickname: "已注销用户",
+		Email:    "deleted_user@placeholder.invalid",
+		Role:     utils.RoleUser,
+		Bio:      "已删除用户的匿名化内容",
+	}
+	return user, createDisabledUser(tx, &user)
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deleteUserInTx 在事务中调用 deleteUserWithContent，失败时回滚
+func deleteUserInTx(db *gorm.DB, userID, operatorID uint, input UserDeletionInput) (*UserDeletionSummary, error) {
+	var summary *UserDeletionSummary
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		summary, err = deleteUserWithContent(tx, userID, operatorID, input)
+		return err
+	})
+	return summary, err
+}
+
+// 不能删除自己和超级管理员；处理方式或接收人无效时不做任何修改
+func TestDeleteUserWithContentGuards(t *testing.T) {
+	db := newTestDB(t)
+	superAdmin := createTestUser(t, db, "root", utils.RoleSuperAdmin)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	alice := createTestUser(t, db, "alice", utils.RoleMarketer)
+	blog := createTestBlog(t, db, alice.ID, "alice blog")
+
+	reassign := func(target uint) UserDeletionInput {
+		return UserDeletionInput{Mode: UserDeletionReassign, TargetID: target}
+	}
+	for _, c := range []struct {
+		name   string
+		userID uint
+		input  UserDeletionInput
+		want   error
+	}{
+		{"self", admin.ID, reassign(alice.ID), errDeleteSelf},
+		{"super admin", superAdmin.ID, reassign(alice.ID), errDeleteSuperAdmin},
+		{"missing user", 999, reassign(alice.ID), gorm.ErrRecordNotFound},
+		{"no mode", alice.ID, UserDeletionInput{TargetID: admin.ID}, errInvalidTarget},
+		{"unknown mode", alice.ID, UserDeletionInput{Mode: "purge"}, errInvalidTarget},
+		{"no target", alice.ID, reassign(0), errInvalidTarget},
+		{"target is the user", alice.ID, reassign(alice.ID), errInvalidTarget},
+		{"missing target", alice.ID, reassign(999), errInvalidTarget},
+	} {
+		if _, err := deleteUserInTx(db, c.userID, admin.ID, c.input); !errors.Is(err, c.want) {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	// 接收人在回收站中时同样无效
+	trashedTarget := createTestUser(t, db, "trashed", utils.RoleUser)
+	db.Model(&trashedTarget).UpdateColumn("deleted_at", time.Now())
+	if _, err := deleteUserInTx(db, alice.ID, admin.ID, reassign(trashedTarget.ID)); !errors.Is(err, errInvalidTarget) {
+		t.Errorf("trashed target: got error %v, want %v", err, errInvalidTarget)
+	}
+
+	if trashed(t, db, &models.Users{}, alice.ID) {
+		t.Error("user should not be deleted after a rejected request")
+	}
+	var stored models.Blog
+	db.First(&stored, blog.ID)
+	if stored.AuthorID != alice.ID {
+		t.Errorf("blog author = %d, want %d", stored.AuthorID, alice.ID)
+	}
+}
+
+// reassign：博客（含回收站中的）、系列、所有者协作关系和收益记录转给接收人，同键的收益记录合并到接收人的记录上
+func TestDeleteUserWithContentReassign(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	alice := createTestUser(t, db, "alice", utils.RoleMarketer)
+	bob := createTestUser(t, db, "bob", utils.RoleMarketer)
+
+	blog := createTestBlog(t, db, alice.ID, "alice blog")
+	trashedBlog := createTestBlog(t, db, alice.ID, "alice trashed blog")
+	db.Model(&trashedBlog).UpdateColumn("deleted_at", time.Now().Add(-time.Hour))
+	db.Create(&models.BlogCollaborator{BlogID: blog.ID, UserID: alice.ID, Role: models.CollaboratorOwner})
+	db.Create(&models.BlogCollaborator{BlogID: blog.ID, UserID: bob.ID, Role: models.CollaboratorViewer})
+	series := models.Series{UserID: alice.ID, Title: "alice series"}
+	db.Create(&series)
+	comment := createTestComment(t, db, blog.ID, alice.ID, "alice comment")
+
+	// 与 bob 的记录唯一键相同，合并
+	collision := createTestRevenue(t, db, models.EmployeeRevenue{UserID: alice.ID, Expenditure: 100, Revenue: 300,
+		OrderCount: 1}, "2025-03-10")
+	bobRecord := createTestRevenue(t, db, models.EmployeeRevenue{UserID: bob.ID, Expenditure: 200, Revenue: 500,
+		OrderCount: 2}, "2025-03-10")
+	moved := createTestRevenue(t, db, models.EmployeeRevenue{UserID: alice.ID, Revenue: 700}, "2025-03-11")
+	trashedRevenue := createTestRevenue(t, db, models.EmployeeRevenue{UserID: alice.ID, Revenue: 900}, "2025-03-12")
+	db.Model(&trashedRevenue).UpdateColumn("deleted_at", time.Now().Add(-time.Hour))
+
+	summary, err := deleteUserInTx(db, alice.ID, admin.ID, UserDeletionInput{Mode: UserDeletionReassign, TargetID: bob.ID})
+	if err != nil {
+		t.Fatalf("deleteUserWithContent: %v", err)
+	}
+	want := UserDeletionSummary{UserID: alice.ID, Mode: UserDeletionReassign, TargetUserID: bob.ID, Blogs: 2,
+		RevenueRecords: 2, RevenueMerged: 1, Series: 1, Collaborators: 1, Comments: 1}
+	if *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+
+	if !trashed(t, db, &models.Users{}, alice.ID) || !trashed(t, db, &models.Comment{}, comment.ID) {
+		t.Error("user and their comments should be in the trash")
+	}
+	for _, id := range []uint{blog.ID, trashedBlog.ID} {
+		var stored models.Blog
+		db.Unscoped().First(&stored, id)
+		if stored.AuthorID != bob.ID || stored.UserID != bob.ID {
+			t.Errorf("blog %d author = %d, want %d", id, stored.AuthorID, bob.ID)
+		}
+	}
+	if trashed(t, db, &models.Blog{}, blog.ID) || !trashed(t, db, &models.Blog{}, trashedBlog.ID) {
+		t.Error("blogs should keep their trash state")
+	}
+	var collaborators []models.BlogCollaborator
+	db.Where("blog_id = ?", blog.ID).Find(&collaborators)
+	if len(collaborators) != 1 || collaborators[0].UserID != bob.ID || collaborators[0].Role != models.CollaboratorOwner {
+		t.Errorf("collaborators = %+v, want bob as the only owner", collaborators)
+	}
+	var audits int64
+	db.Model(&models.BlogAuditLog{}).Where("action = ?", "reassign").Count(&audits)
+	if audits != 2 {
+		t.Errorf("got %d reassign audit logs, want 2", audits)
+	}
+	db.First(&series, series.ID)
+	if series.UserID != bob.ID {
+		t.Errorf("series owner = %d, want %d", series.UserID, bob.ID)
+	}
+
+	var merged models.EmployeeRevenue
+	db.First(&merged, bobRecord.ID)
+	if merged.Expenditure != 300 || merged.Revenue != 800 || merged.OrderCount != 3 {
+		t.Errorf("merged record = %+v, want expenditure 3.00, revenue 8.00, 3 orders", merged)
+	}
+	if exists(db, &models.EmployeeRevenue{}, collision.ID) {
+		t.Error("merged record should be deleted")
+	}
+	var owners []uint
+	db.Unscoped().Model(&models.EmployeeRevenue{}).Where("id IN ?", []uint{moved.ID, trashedRevenue.ID}).Pluck("user_id", &owners)
+	if len(owners) != 2 || owners[0] != bob.ID || owners[1] != bob.ID {
+		t.Errorf("revenue owners = %v, want %d", owners, bob.ID)
+	}
+	if !trashed(t, db, &models.EmployeeRevenue{}, trashedRevenue.ID) || trashed(t, db, &models.EmployeeRevenue{}, moved.ID) {
+		t.Error("revenue records should keep their trash state")
+	}
+}
+
+// anonymize：内容转给禁用的匿名账号；匿名账号只创建一次，在回收站中时自动恢复
+func TestDeleteUserWithContentAnonymize(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	alice := createTestUser(t, db, "alice", utils.RoleMarketer)
+	carol := createTestUser(t, db, "carol", utils.RoleMarketer)
+	blog := createTestBlog(t, db, alice.ID, "alice blog")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: alice.ID, Revenue: 100}, "2025-03-10")
+	createTestRevenue(t, db, models.EmployeeRevenue{UserID: carol.ID, Revenue: 200}, "2025-03-10")
+
+	summary, err := deleteUserInTx(db, alice.ID, admin.ID, UserDeletionInput{Mode: UserDeletionAnonymize})
+	if err != nil {
+		t.Fatalf("deleteUserWithContent: %v", err)
+	}
+	var anonymous models.Users
+	if err := db.Where("username = ?", anonymousUsername).First(&anonymous).Error; err != nil {
+		t.Fatalf("anonymous user not created: %v", err)
+	}
+	if anonymous.Status != 0 || anonymous.Role != utils.RoleUser {
+		t.Errorf("anonymous user status %d, role %d; want disabled regular user", anonymous.Status, anonymous.Role)
+	}
+	want := UserDeletionSummary{UserID: alice.ID, Mode: UserDeletionAnonymize, TargetUserID: anonymous.ID, Blogs: 1, RevenueRecords: 1}
+	if *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+	var stored models.Blog
+	db.First(&stored, blog.ID)
+	if stored.AuthorID != anonymous.ID {
+		t.Errorf("blog author = %d, want anonymous user %d", stored.AuthorID, anonymous.ID)
+	}
+
+	// 第二个用户的同键记录与匿名账号下的记录合并
+	db.Model(&anonymous).UpdateColumn("deleted_at", time.Now())
+	summary, err = deleteUserInTx(db, carol.ID, admin.ID, UserDeletionInput{Mode: UserDeletionAnonymize})
+	if err != nil {
+		t.Fatalf("deleteUserWithContent: %v", err)
+	}
+	if summary.TargetUserID != anonymous.ID || summary.RevenueMerged != 1 || summary.RevenueRecords != 0 {
+		t.Errorf("summary = %+v, want a merge into anonymous user %d", *summary, anonymous.ID)
+	}
+	var count int64
+	db.Unscoped().Model(&models.Users{}).Where("username = ?", anonymousUsername).Count(&count)
+	if count != 1 || trashed(t, db, &models.Users{}, anonymous.ID) {
+		t.Errorf("got %d anonymous users, want one restored from the trash", count)
+	}
+	var revenue models.EmployeeRevenue
+	db.Where("user_id = ?", anonymous.ID).First(&revenue)
+	if revenue.Revenue != 300 {
+		t.Errorf("anonymous revenue = %v, want 3.00", revenue.Revenue)
+	}
+}
+
+// 普通用户无法注册或改用匿名账号的用户名；已注册为 deleted_user 的用户不会收到匿名化的内容
+func TestAnonymousUsernameReserved(t *testing.T) {
+	db := newTestDB(t)
+	admin := createTestUser(t, db, "admin", utils.RoleAdmin)
+	squatter := createTestUser(t, db, "deleted_user", utils.RoleUser)
+	alice := createTestUser(t, db, "alice", utils.RoleMarketer)
+	blog := createTestBlog(t, db, alice.ID, "alice blog")
+	c := NewUserController(db)
+
+	body := `{"username":"` + anonymousUsername + `","password":"secret123","email":"x@example.com","role":4}`
+	if code := performRequest(c.RegisterUser, http.MethodPost, "/", body, 0, utils.RoleUser).Code; code != http.StatusConflict {
+		t.Errorf("register reserved username: status %d, want 409", code)
+	}
+	if code := performRequest(c.CreateUserByAdmin, http.MethodPost, "/", body, admin.ID, utils.RoleAdmin).Code; code != http.StatusConflict {
+		t.Errorf("admin create reserved username: status %d, want 409", code)
+	}
+	if code := performRequest(c.UpdateUserProfile, http.MethodPut, "/", `{"username":"`+anonymousUsername+`"}`,
+		squatter.ID, utils.RoleUser).Code; code != http.StatusConflict {
+		t.Errorf("rename to reserved username: status %d, want 409", code)
+	}
+
+	summary, err := deleteUserInTx(db, alice.ID, admin.ID, UserDeletionInput{Mode: UserDeletionAnonymize})
+	if err != nil {
+		t.Fatalf("deleteUserWithContent: %v", err)
+	}
+	if summary.TargetUserID == squatter.ID {
+		t.Fatalf("content anonymized into user %q", squatter.Username)
+	}
+	var stored models.Blog
+	db.First(&stored, blog.ID)
+	if stored.AuthorID != summary.TargetUserID {
+		t.Errorf("blog author = %d, want anonymous user %d", stored.AuthorID, summary.TargetUserID)
+	}
+	var anonymous models.Users
+	db.First(&anonymous, summary.TargetUserID)
+	if anonymous.Username != anonymousUsername || anonymous.Status != 0 {
+		t.Errorf("anonymous user %q status %d, want %q disabled", anonymous.Username, anonymous.Status, anonymousUsername)
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ViewTracker 博客浏览量缓冲：在内存中去重并累计浏览量，定时批量写库，避免每次阅读都产生一次写操作
+type ViewTracker struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	window    time.Duration
+	batchSize int
+	interval  time.Duration
+	buffered  int64
+	seen      map[string]time.Time // blogID|访客 -> 最近一次计数时间
+	views     map[viewBucket]int64
+	referrers map[referrerBucket]int64
+	flushCh   chan struct{}
+	stopCh    chan struct{}
+	done      chan struct{} // 后台协程退出后关闭，未调用 Start 时为 nil
+	stopOnce  sync.Once
+}
+
+type viewBucket struct {
+	BlogID uint
+	Date   string
+}
+
+type referrerBucket struct {
+	BlogID   uint
+	Date     string
+	Referrer string
+}
+
+// NewViewTracker 创建浏览量缓冲，参数来自 analytics 配置（未配置时使用默认值）
+func NewViewTracker(db *gorm.DB) *ViewTracker {
+	cfg := utils.AppConfig.Analytics
+
+	window := time.Duration(cfg.ViewDedupMinutes) * time.Minute
+	if window <= 0 {
+		window = 30 * time.Minute
+	}
+	interval := time.Duration(cfg.FlushIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	batchSize := cfg.FlushBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	return &ViewTracker{
+		db:        db,
+		window:    window,
+		batchSize: batchSize,
+		interval:  interval,
+		seen:      make(map[string]time.Time),
+		views:     make(map[viewBucket]int64),
+		referrers: make(map[referrerBucket]int64),
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start 启动后台写库协程
+func (t *ViewTracker) Start() {
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-t.flushCh:
+			case <-t.stopCh:
+				return
+			}
+			if err := t.Flush(); err != nil {
+				log.Printf("Failed to flush blog views: %v", err)
+			}
+		}
+	}()
+}
+
+// Stop 停止后台写库协程，并把缓冲中剩余的浏览量写入数据库；进程退出前调用，避免丢失最后一个周期的浏览量
+func (t *ViewTracker) Stop() error {
+	t.stopOnce.Do(func() {
+		close(t.stopCh)
+		if t.done != nil {
+			<-t.done
+		}
+	})
+	return t.Flush()
+}
+
+// Record 记录一次浏览，viewer 为用户或 IP 标识；窗口期内的重复访问返回 false
+func (t *ViewTracker) Record(blogID uint, viewer, referrer string, now time.Time) bool {
+	key := strconv.FormatUint(uint64(blogID), 10) + "|" + viewer
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.seen[key]; ok && now.Sub(last) < t.window {
+		return false
+	}
+	t.seen[key] = now
+
+	date := now.Format("2006-01-02")
+	t.views[viewBucket{BlogID: blogID, Date: date}]++
+	t.referrers[referrerBucket{BlogID: blogID, Date: date, Referrer: normalizeReferrer(referrer)}]++
+	t.buffered++
+
+	// 缓冲过多时提前触发写库（非阻塞）
+	if t.buffered >= int64(t.batchSize) {
+		select {
+		case t.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Flush 将缓冲中的浏览量写入数据库；写库失败时数据会合并回缓冲等待下次重试
+func (t *ViewTracker) Flush() error {
+	t.mu.Lock()
+	views, referrers := t.views, t.referrers
+	t.views = make(map[viewBucket]int64)
+	t.referrers = make(map[referrerBucket]int64)
+	t.buffered = 0
+
+	// 顺便清理已过期的去重记录，防止内存无限增长
+	now := time.Now()
+	for key, last := range t.seen {
+		if now.Sub(last) >= t.window {
+			delete(t.seen, key)
+		}
+	}
+	t.mu.Unlock()
+
+	if len(views) == 0 {
+		return nil
+	}
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		totals := make(map[uint]int64)
+		for bucket, n := range views {
+			row := models.BlogViewDaily{BlogID: bucket.BlogID, Date: bucket.Date, Views: n}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "blog_id"}, {Name: "date"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views":      gorm.Expr("views + excluded.views"),
+					"updated_at": now,
+				}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+			totals[bucket.BlogID] += n
+		}
+
+		for bucket, n := range referrers {
+			row := models.BlogReferrerDaily{BlogID: bucket.BlogID, Date: bucket.Date, Referrer: bucket.Referrer, Views: n}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "blog_id"}, {Name: "date"}, {Name: "referrer"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views":      gorm.Expr("views + excluded.views"),
+					"updated_at": now,
+				}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+
+		// 使用 UpdateColumn，避免浏览量变化刷新博客的 updated_at
+		for blogID, n := range totals {
+			if err := tx.Model(&models.Blog{}).Where("id = ?", blogID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.mu.Lock()
+		for bucket, n := range views {
+			t.views[bucket] += n
+			t.buffered += n
+		}
+		for bucket, n := range referrers {
+			t.referrers[bucket] += n
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// normalizeReferrer 只保留来源的域名，空值或无法解析时记为 direct
+func normalizeReferrer(referrer string) string {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return "direct"
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return "direct"
+	}
+	host := strings.ToLower(u.Hostname())
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
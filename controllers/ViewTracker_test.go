@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 同一访客在去重窗口内重复访问只计一次
+func TestViewTrackerDedupWindow(t *testing.T) {
+	tracker := NewViewTracker(newTestDB(t))
+	tracker.window = 30 * time.Minute
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		blogID uint
+		viewer string
+		at     time.Time
+		want   bool
+	}{
+		{1, "user:1", now, true},
+		{1, "user:1", now.Add(29 * time.Minute), false},
+		{1, "ip:10.0.0.1", now.Add(time.Minute), true},
+		{2, "user:1", now.Add(time.Minute), true},
+		{1, "user:1", now.Add(30 * time.Minute), true}, // 窗口从上一次计数开始
+		{1, "user:1", now.Add(59 * time.Minute), false},
+	} {
+		if got := tracker.Record(c.blogID, c.viewer, "", c.at); got != c.want {
+			t.Errorf("Record(%d, %s, +%s) = %v, want %v", c.blogID, c.viewer, c.at.Sub(now), got, c.want)
+		}
+	}
+	if tracker.buffered != 4 || tracker.views[viewBucket{BlogID: 1, Date: "2025-03-10"}] != 3 {
+		t.Errorf("buffered %d, blog 1 views %d; want 4 and 3", tracker.buffered, tracker.views[viewBucket{BlogID: 1, Date: "2025-03-10"}])
+	}
+}
+
+// 写库时按天累加到已有的统计行，并更新博客的累计浏览量；Stop 写入剩余的缓冲
+func TestViewTrackerFlush(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleUser)
+	blog := createTestBlog(t, db, author.ID, "blog")
+	tracker := NewViewTracker(db)
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tracker.Record(blog.ID, "user:1", "https://www.Google.com/search?q=go", day)
+	tracker.Record(blog.ID, "user:2", "", day)
+	if err := tracker.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	tracker.Record(blog.ID, "user:3", "https://www.google.com/", day)
+	tracker.Record(blog.ID, "user:1", "", day.Add(24*time.Hour))
+	if err := tracker.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if tracker.buffered != 0 || len(tracker.views) != 0 {
+		t.Errorf("buffer not cleared after flush: %d buffered", tracker.buffered)
+	}
+
+	var daily []models.BlogViewDaily
+	db.Where("blog_id = ?", blog.ID).Order("date").Find(&daily)
+	if len(daily) != 2 || daily[0].Date != "2025-03-10" || daily[0].Views != 3 || daily[1].Date != "2025-03-11" || daily[1].Views != 1 {
+		t.Errorf("daily views = %+v, want 3 on 2025-03-10 and 1 on 2025-03-11", daily)
+	}
+	referrers := map[string]int64{}
+	var rows []models.BlogReferrerDaily
+	db.Where("blog_id = ? AND date = ?", blog.ID, "2025-03-10").Find(&rows)
+	for _, row := range rows {
+		referrers[row.Referrer] = row.Views
+	}
+	if len(referrers) != 2 || referrers["www.google.com"] != 2 || referrers["direct"] != 1 {
+		t.Errorf("referrers = %v, want www.google.com: 2, direct: 1", referrers)
+	}
+
+	// 后台协程运行中停止：剩余的缓冲写入数据库，重复调用 Stop 不会出错
+	tracker.Start()
+	tracker.Record(blog.ID, "user:4", "", day)
+	if err := tracker.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := tracker.Stop(); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+	var stored models.Blog
+	db.First(&stored, blog.ID)
+	if stored.ViewCount != 5 {
+		t.Errorf("view_count = %d, want 5", stored.ViewCount)
+	}
+}
+
+// 每日浏览量逐日补 0，区间超过一年时返回 400
+func TestGetDailyViewsRange(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author", utils.RoleUser)
+	blog := createTestBlog(t, db, author.ID, "blog")
+	db.Create(&models.BlogViewDaily{BlogID: blog.ID, Date: "2025-03-02", Views: 4})
+	c := NewBlogAnalyticsController(db)
+
+	get := func(query string) (int, []DailyViews) {
+		w := performRequest(c.GetDailyViews, http.MethodGet, "/?"+query, "", author.ID, utils.RoleUser,
+			gin.Param{Key: "id", Value: strconv.Itoa(int(blog.ID))})
+		var resp struct {
+			Data []DailyViews `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	if code, data := get("startDate=2025-03-01&endDate=2025-03-03"); code != http.StatusOK || len(data) != 3 || data[1].Views != 4 {
+		t.Errorf("three days: status %d, data %+v; want 200 with 4 views on 2025-03-02", code, data)
+	}
+	if code, data := get("startDate=2024-03-01&endDate=2025-03-01"); code != http.StatusOK || len(data) != 366 {
+		t.Errorf("one year: status %d, %d days; want 200 and 366", code, len(data))
+	}
+	for _, query := range []string{"startDate=2024-03-01&endDate=2025-03-03", "startDate=0001-01-01&endDate=9999-12-31"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, code)
+		}
+	}
+}
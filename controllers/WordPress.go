@@ -0,0 +1,526 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WordPressController 定义 WordPress 导入接口
+type WordPressController interface {
+	ImportWXR(ctx *gin.Context) // 导入 WordPress 导出的 WXR 文件（管理员）
+}
+
+type wordPressController struct {
+	db *gorm.DB
+}
+
+// NewWordPressController 创建一个新的 WordPressController
+func NewWordPressController(db *gorm.DB) WordPressController {
+	return &wordPressController{db: db}
+}
+
+// WXRImportOptions WXR 导入选项（接口参数与命令行参数共用）
+type WXRImportOptions struct {
+	DryRun             bool              // 只生成报告，不写库
+	Dedup              string            // slug（默认）/ title / none
+	AuthorMap          map[string]string // WordPress 登录名 → 本站用户名
+	DefaultAuthor      string            // 找不到作者且不创建占位账号时使用的用户名（默认导入人）
+	CreatePlaceholders bool              // 找不到对应用户时创建占位账号（禁用状态、随机密码）
+}
+
+// WXRAuthorResult 作者映射结果
+type WXRAuthorResult struct {
+	Login    string `json:"login"`
+	Username string `json:"username"`
+	UserID   uint   `json:"user_id,omitempty"`
+	Action   string `json:"action"` // mapped / existing / placeholder / default
+}
+
+// WXRImportReport WXR 导入报告
+type WXRImportReport struct {
+	DryRun          bool               `json:"dry_run"`
+	Total           int                `json:"total"` // 文章总数（不含页面、附件等）
+	Created         int                `json:"created"`
+	Skipped         int                `json:"skipped"`
+	Failed          int                `json:"failed"`
+	Ignored         int                `json:"ignored"` // 跳过的非文章条目（页面、附件、菜单等）
+	Comments        int                `json:"comments"`
+	CommentsSkipped int                `json:"comments_skipped"` // 未通过审核、pingback 或无法确定评论人的评论
+	Authors         []WXRAuthorResult  `json:"authors"`
+	Items           []BlogImportResult `json:"items"`
+}
+
+// wxrDocument WXR（WordPress eXtended RSS）文件结构；字段只按本地名匹配，兼容 1.0~1.2 各版本的命名空间
+type wxrDocument struct {
+	Channel struct {
+		Authors []wxrAuthor `xml:"author"`
+		Items   []wxrItem   `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAuthor struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrItem struct {
+	PostID      string        `xml:"post_id"`
+	Title       string        `xml:"title"`
+	Creator     string        `xml:"creator"`
+	Content     string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostName    string        `xml:"post_name"`
+	PostDate    string        `xml:"post_date"`
+	PostDateGMT string        `xml:"post_date_gmt"`
+	Modified    string        `xml:"post_modified_gmt"`
+	Status      string        `xml:"status"`
+	PostType    string        `xml:"post_type"`
+	Categories  []wxrCategory `xml:"category"`
+	Comments    []wxrComment  `xml:"comment"`
+}
+
+type wxrCategory struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+type wxrComment struct {
+	ID          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      string `xml:"comment_parent"`
+	UserID      string `xml:"comment_user_id"`
+}
+
+// wxrStatuses WordPress 文章状态到本站状态的映射；未列出的（如 trash）不导入
+var wxrStatuses = map[string]string{
+	"publish": models.BlogStatusPublished,
+	"draft":   models.BlogStatusDraft,
+	"pending": models.BlogStatusDraft,
+	"future":  models.BlogStatusDraft,
+	"private": models.BlogStatusDraft,
+}
+
+// ImportWXR 上传 WXR 文件导入文章与评论。参数与博客导入一致：
+// dry_run、dedup、author_map、default_author，另有 placeholders=false 关闭占位账号创建
+func (c *wordPressController) ImportWXR(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	opts := WXRImportOptions{Dedup: ctx.DefaultQuery("dedup", ctx.DefaultPostForm("dedup", "slug")), CreatePlaceholders: true}
+	opts.DryRun, _ = strconv.ParseBool(ctx.DefaultQuery("dry_run", ctx.PostForm("dry_run")))
+	if placeholders := ctx.DefaultQuery("placeholders", ctx.PostForm("placeholders")); placeholders != "" {
+		opts.CreatePlaceholders, _ = strconv.ParseBool(placeholders)
+	}
+	opts.DefaultAuthor = ctx.DefaultQuery("default_author", ctx.PostForm("default_author"))
+	if mapping := ctx.DefaultQuery("author_map", ctx.PostForm("author_map")); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.AuthorMap); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author_map, expected a JSON object"})
+			return
+		}
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxImportUpload {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file is too large"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	report, err := ImportWXR(c.db, file, userID, opts)
+	var inputErr *wxrInputError
+	if errors.As(err, &inputErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import WordPress export"})
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// wxrInputError 导入文件或参数有误（接口返回 400）
+type wxrInputError struct{ msg string }
+
+func (e *wxrInputError) Error() string { return e.msg }
+
+// ImportWXR 解析 WXR 并在一个事务中导入文章、分类标签与评论（保留原始时间与评论的回复关系），
+// importerID 为执行导入的用户（记录在修改记录中，也是默认作者）
+func ImportWXR(db *gorm.DB, r io.Reader, importerID uint, opts WXRImportOptions) (*WXRImportReport, error) {
+	if opts.Dedup == "" {
+		opts.Dedup = "slug"
+	}
+	if opts.Dedup != "slug" && opts.Dedup != "title" && opts.Dedup != "none" {
+		return nil, &wxrInputError{"Invalid dedup, expected slug, title or none"}
+	}
+
+	var doc wxrDocument
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, &wxrInputError{fmt.Sprintf("Invalid WXR file: %v", err)}
+	}
+
+	authors, err := newAuthorResolver(db, importerID, "", opts.DefaultAuthor)
+	if err != nil {
+		return nil, &wxrInputError{err.Error()}
+	}
+
+	report := &WXRImportReport{DryRun: opts.DryRun, Authors: []WXRAuthorResult{}, Items: []BlogImportResult{}}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// wpUsers：WordPress 作者ID → 本站用户ID，用于登录用户发表的评论
+		// placeholders：本次导入已生成的占位用户名，试运行不写库时也不会重复分配
+		placeholders := make(map[string]bool)
+		wpUsers, err := mapWXRAuthors(tx, doc.Channel.Authors, authors, placeholders, opts, report)
+		if err != nil {
+			return err
+		}
+
+		commenters := make(map[string]wxrCommenterMatch)
+		seen := make(map[string]bool)
+		blogIDs := make([]uint, 0)
+		for _, entry := range doc.Channel.Items {
+			status, ok := wxrStatuses[entry.Status]
+			if entry.PostType != "post" || !ok {
+				report.Ignored++
+				continue
+			}
+			report.Total++
+
+			result, blog := planImportItem(tx, wxrToItem(entry, status), opts.Dedup, seen, authors)
+			result.Source = "post " + entry.PostID
+			if blog != nil && !opts.DryRun {
+				if err := createBlogWithOwner(tx, blog, importerID, gin.H{"title": blog.Title, "status": blog.Status, "source": "wordpress"}); err != nil {
+					return err
+				}
+				result.BlogID = blog.ID
+				blogIDs = append(blogIDs, blog.ID)
+			}
+			if blog != nil {
+				created, skipped, err := importWXRComments(tx, blog, entry.Comments, wpUsers, commenters, placeholders, opts)
+				if err != nil {
+					return err
+				}
+				report.Comments += created
+				report.CommentsSkipped += skipped
+			}
+
+			switch result.Action {
+			case "create":
+				report.Created++
+			case "skip":
+				report.Skipped++
+			default:
+				report.Failed++
+			}
+			report.Items = append(report.Items, result)
+		}
+
+		if opts.DryRun || len(blogIDs) == 0 {
+			return nil
+		}
+		return syncBlogCommentStats(tx.Where("id IN ?", blogIDs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// mapWXRAuthors 为每个 WordPress 作者确定本站用户：author_map 指定 > 同名或同邮箱的已有用户 > 占位账号 > 默认作者
+func mapWXRAuthors(tx *gorm.DB, wpAuthors []wxrAuthor, authors *authorResolver, placeholders map[string]bool, opts WXRImportOptions, report *WXRImportReport) (map[string]uint, error) {
+	wpUsers := make(map[string]uint)
+	for _, author := range wpAuthors {
+		result := WXRAuthorResult{Login: author.Login}
+		if mapped, ok := opts.AuthorMap[author.Login]; ok {
+			id, found := authors.lookup(tx, mapped)
+			if !found {
+				return nil, &wxrInputError{fmt.Sprintf("author_map: user %q not found", mapped)}
+			}
+			result.Username, result.UserID, result.Action = mapped, id, "mapped"
+		} else if user, found := findUserByLoginOrEmail(tx, author.Login, author.Email); found {
+			result.Username, result.UserID, result.Action = user.Username, user.ID, "existing"
+		} else if opts.CreatePlaceholders {
+			user, err := createPlaceholderUser(tx, author.Login, author.Email, author.DisplayName, placeholders, opts.DryRun)
+			if err != nil {
+				return nil, err
+			}
+			result.Username, result.UserID, result.Action = user.Username, user.ID, "placeholder"
+		} else {
+			result.UserID, result.Action = authors.defaultID, "default"
+		}
+
+		if result.Username != "" {
+			authors.mapping[author.Login] = result.Username
+			if result.UserID != 0 {
+				authors.cache[result.Username] = result.UserID
+			} else {
+				// 试运行时占位账号并未创建，文章暂记在默认作者名下
+				authors.cache[result.Username] = authors.defaultID
+			}
+		} else {
+			// 映射到默认作者：用一个不会与用户名冲突的键
+			authors.mapping[author.Login] = ""
+		}
+		if author.ID != "" && result.UserID != 0 {
+			wpUsers[author.ID] = result.UserID
+		}
+		report.Authors = append(report.Authors, result)
+	}
+	return wpUsers, nil
+}
+
+// findUserByLoginOrEmail 按用户名或邮箱查找已有用户
+func findUserByLoginOrEmail(tx *gorm.DB, login, email string) (models.Users, bool) {
+	var user models.Users
+	query := tx.Where("username = ?", login)
+	if email != "" {
+		query = tx.Where("username = ? OR email = ?", login, email)
+	}
+	err := query.First(&user).Error
+	return user, err == nil
+}
+
+var placeholderUsernameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// createPlaceholderUser 创建禁用状态的占位账号（随机密码，用户名为 wp_<登录名>，与已有用户或本次导入已生成的用户名重名时追加序号）；
+// 生成的用户名记入 placeholders，dryRun 时只生成用户名
+func createPlaceholderUser(tx *gorm.DB, login, email, displayName string, placeholders map[string]bool, dryRun bool) (models.Users, error) {
+	base := placeholderUsernameInvalid.ReplaceAllString(login, "")
+	if base == "" {
+		base = "user"
+	}
+	base = "wp_" + base
+	if len(base) > 16 {
+		base = base[:16]
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Users{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return models.Users{}, err
+		}
+		if count == 0 && !placeholders[username] {
+			break
+		}
+		username = base + strconv.Itoa(i)
+	}
+	placeholders[username] = true
+
+	if displayName == "" {
+		displayName = login
+	}
+	if email == "" || !utils.IsValidEmail(email) {
+		email = username + "@placeholder.invalid"
+	}
+	user := models.Users{
+		Username: username,
+		Nickname: displayName,
+		Email:    email,
+		Role:     utils.RoleUser,
+		Status:   0,
+		Bio:      "由 WordPress 导入创建的占位账号",
+	}
+	if dryRun {
+		return user, nil
+	}
+	return user, createDisabledUser(tx, &user)
+}
+
+// createDisabledUser 以随机密码创建禁用状态的账号（占位账号、匿名账号等不用于登录的账号）
+func createDisabledUser(tx *gorm.DB, user *models.Users) error {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password, err := utils.NewJWTTools().HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		return err
+	}
+	user.Password = password
+	// Status 的零值会被 gorm 默认值覆盖，创建后再单独更新
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	user.Status = 0
+	return tx.Model(user).Update("status", 0).Error
+}
+
+// wxrToItem 将 WordPress 文章转换为导入条目：第一个分类作为文章分类，其余分类与标签合并为标签
+func wxrToItem(entry wxrItem, status string) BlogTransferItem {
+	item := BlogTransferItem{
+		Title:   strings.TrimSpace(entry.Title),
+		Status:  status,
+		Author:  entry.Creator,
+		Content: entry.Content,
+	}
+	if slug, err := url.PathUnescape(entry.PostName); err == nil {
+		item.Slug = slug
+	} else {
+		item.Slug = entry.PostName
+	}
+
+	for _, category := range entry.Categories {
+		name := strings.TrimSpace(category.Name)
+		if name == "" {
+			continue
+		}
+		switch {
+		case category.Domain == "category" && item.Category == "":
+			item.Category = name
+		case category.Domain == "category" || category.Domain == "post_tag":
+			item.Tags = append(item.Tags, strings.ReplaceAll(name, ",", " "))
+		}
+	}
+
+	if createdAt, ok := parseWXRTime(entry.PostDateGMT, entry.PostDate); ok {
+		item.CreatedAt = &createdAt
+	}
+	if updatedAt, ok := parseWXRTime(entry.Modified, ""); ok {
+		item.UpdatedAt = &updatedAt
+	}
+	return item
+}
+
+// parseWXRTime 优先使用 GMT 时间；WordPress 用 0000-00-00 00:00:00 表示未设置，此时退回站点本地时间（按 UTC 处理）
+func parseWXRTime(gmt, local string) (time.Time, bool) {
+	for _, value := range []string{gmt, local} {
+		if value == "" || strings.HasPrefix(value, "0000-00-00") {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02 15:04:05", value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// importWXRComments 导入一篇文章已通过审核的评论，先创建全部评论再按 comment_parent 补上回复关系；
+// 登录用户的评论映射到对应作者，游客评论按邮箱匹配已有用户或创建占位账号
+func importWXRComments(tx *gorm.DB, blog *models.Blog, entries []wxrComment, wpUsers map[string]uint, commenters map[string]wxrCommenterMatch, placeholders map[string]bool, opts WXRImportOptions) (int, int, error) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, _ := strconv.Atoi(entries[i].ID)
+		b, _ := strconv.Atoi(entries[j].ID)
+		return a < b
+	})
+
+	created, skipped := 0, 0
+	newIDs := make(map[string]uint)
+	parents := make(map[uint]string)
+	for _, entry := range entries {
+		if entry.Approved != "1" || (entry.Type != "" && entry.Type != "comment") || strings.TrimSpace(entry.Content) == "" {
+			skipped++
+			continue
+		}
+
+		commenter, err := wxrCommenter(tx, entry, wpUsers, commenters, placeholders, opts)
+		if err != nil {
+			return created, skipped, err
+		}
+		if !commenter.Found {
+			skipped++
+			continue
+		}
+		created++
+		// 试运行只统计：待创建的占位账号没有用户ID，不能用于写入评论
+		if opts.DryRun {
+			continue
+		}
+
+		comment := models.Comment{BlogID: blog.ID, UserID: commenter.UserID, Content: entry.Content}
+		if t, ok := parseWXRTime(entry.DateGMT, entry.Date); ok {
+			comment.CreatedAt, comment.UpdatedAt = t, t
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return created, skipped, err
+		}
+		newIDs[entry.ID] = comment.ID
+		if entry.Parent != "" && entry.Parent != "0" {
+			parents[comment.ID] = entry.Parent
+		}
+	}
+
+	for commentID, wpParent := range parents {
+		parentID, ok := newIDs[wpParent]
+		if !ok {
+			continue // 父评论未导入时作为顶层评论
+		}
+		if err := tx.Model(&models.Comment{}).Where("id = ?", commentID).UpdateColumn("parent_id", parentID).Error; err != nil {
+			return created, skipped, err
+		}
+	}
+	return created, skipped, nil
+}
+
+// wxrCommenterMatch 评论人的匹配结果：Found 为 false 时跳过其评论；
+// 试运行时需要新建的占位账号 Found 为 true、UserID 为 0
+type wxrCommenterMatch struct {
+	UserID uint
+	Found  bool
+}
+
+// wxrCommenter 确定评论人对应的本站用户，结果按邮箱（没有邮箱时按名字）缓存在 commenters 中
+func wxrCommenter(tx *gorm.DB, entry wxrComment, wpUsers map[string]uint, commenters map[string]wxrCommenterMatch, placeholders map[string]bool, opts WXRImportOptions) (wxrCommenterMatch, error) {
+	if id, ok := wpUsers[entry.UserID]; ok && entry.UserID != "0" {
+		return wxrCommenterMatch{UserID: id, Found: true}, nil
+	}
+
+	key := strings.ToLower(strings.TrimSpace(entry.AuthorEmail))
+	if key == "" {
+		key = "name:" + strings.TrimSpace(entry.Author)
+	}
+	if match, ok := commenters[key]; ok {
+		return match, nil
+	}
+
+	var match wxrCommenterMatch
+	var user models.Users
+	if entry.AuthorEmail != "" && tx.Where("email = ?", entry.AuthorEmail).First(&user).Error == nil {
+		match = wxrCommenterMatch{UserID: user.ID, Found: true}
+	} else if opts.CreatePlaceholders {
+		// 试运行时 createPlaceholderUser 不写库，返回的 ID 为 0
+		user, err := createPlaceholderUser(tx, entry.Author, entry.AuthorEmail, entry.Author, placeholders, opts.DryRun)
+		if err != nil {
+			return match, err
+		}
+		match = wxrCommenterMatch{UserID: user.ID, Found: true}
+	}
+	commenters[key] = match
+	return match, nil
+}
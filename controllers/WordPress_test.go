@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"strings"
+	"testing"
+)
+
+// testWXR 一篇文章：游客评论、对它的回复、已有用户的评论和一条未通过审核的评论
+const testWXR = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>Hello WordPress</title>
+		<content:encoded><![CDATA[<p>Hello</p>]]></content:encoded>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_name>hello-wordpress</wp:post_name>
+		<wp:post_date_gmt>2024-01-02 03:04:05</wp:post_date_gmt>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<wp:comment>
+			<wp:comment_id>1</wp:comment_id>
+			<wp:comment_author>Guest</wp:comment_author>
+			<wp:comment_author_email>guest@example.org</wp:comment_author_email>
+			<wp:comment_date_gmt>2024-01-03 00:00:00</wp:comment_date_gmt>
+			<wp:comment_content>First</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+			<wp:comment_parent>0</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>2</wp:comment_id>
+			<wp:comment_author>Guest</wp:comment_author>
+			<wp:comment_author_email>GUEST@example.org</wp:comment_author_email>
+			<wp:comment_content>Reply</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+			<wp:comment_parent>1</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>3</wp:comment_id>
+			<wp:comment_author>Reader</wp:comment_author>
+			<wp:comment_author_email>reader@example.com</wp:comment_author_email>
+			<wp:comment_content>Existing user</wp:comment_content>
+			<wp:comment_approved>1</wp:comment_approved>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>4</wp:comment_id>
+			<wp:comment_author>Spammer</wp:comment_author>
+			<wp:comment_content>Spam</wp:comment_content>
+			<wp:comment_approved>0</wp:comment_approved>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+// 试运行只统计评论，不创建占位账号和评论；正式导入时评论归到占位账号并保留回复关系
+func TestImportWXRComments(t *testing.T) {
+	db := newTestDB(t)
+	importer := createTestUser(t, db, "admin", utils.RoleAdmin)
+	reader := createTestUser(t, db, "reader", utils.RoleUser)
+
+	report, err := ImportWXR(db, strings.NewReader(testWXR), importer.ID, WXRImportOptions{DryRun: true, CreatePlaceholders: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Created != 1 || report.Comments != 3 || report.CommentsSkipped != 1 {
+		t.Errorf("dry run report: created %d, comments %d, skipped %d; want 1, 3, 1", report.Created, report.Comments, report.CommentsSkipped)
+	}
+	var users, comments, blogs int64
+	db.Model(&models.Users{}).Count(&users)
+	db.Model(&models.Comment{}).Count(&comments)
+	db.Model(&models.Blog{}).Count(&blogs)
+	if users != 2 || comments != 0 || blogs != 0 {
+		t.Fatalf("dry run wrote data: %d users, %d comments, %d blogs", users, comments, blogs)
+	}
+
+	// 不创建占位账号时游客评论被跳过
+	report, err = ImportWXR(db, strings.NewReader(testWXR), importer.ID, WXRImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run without placeholders: %v", err)
+	}
+	if report.Comments != 1 || report.CommentsSkipped != 3 {
+		t.Errorf("dry run without placeholders: comments %d, skipped %d; want 1, 3", report.Comments, report.CommentsSkipped)
+	}
+
+	report, err = ImportWXR(db, strings.NewReader(testWXR), importer.ID, WXRImportOptions{CreatePlaceholders: true})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Comments != 3 || report.CommentsSkipped != 1 {
+		t.Errorf("import report: comments %d, skipped %d; want 3, 1", report.Comments, report.CommentsSkipped)
+	}
+
+	var guest models.Users
+	if err := db.Unscoped().Where("email = ?", "guest@example.org").First(&guest).Error; err != nil {
+		t.Fatalf("placeholder user not created: %v", err)
+	}
+	var imported []models.Comment
+	db.Order("id").Find(&imported)
+	if len(imported) != 3 {
+		t.Fatalf("got %d comments, want 3", len(imported))
+	}
+	first, reply, existing := imported[0], imported[1], imported[2]
+	if first.UserID != guest.ID || reply.UserID != guest.ID || existing.UserID != reader.ID {
+		t.Errorf("comment users = %d, %d, %d; want %d, %d, %d", first.UserID, reply.UserID, existing.UserID, guest.ID, guest.ID, reader.ID)
+	}
+	if reply.ParentID == nil || *reply.ParentID != first.ID || first.ParentID != nil {
+		t.Errorf("reply parent = %v, want %d", reply.ParentID, first.ID)
+	}
+	var blog models.Blog
+	db.First(&blog)
+	if blog.CommentCount != 3 {
+		t.Errorf("comment_count = %d, want 3", blog.CommentCount)
+	}
+}
+
+// 两个作者生成相同的占位用户名时，试运行与正式导入都追加相同的序号
+func TestImportWXRPlaceholderUsernames(t *testing.T) {
+	db := newTestDB(t)
+	importer := createTestUser(t, db, "admin", utils.RoleAdmin)
+	const wxr = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author><wp:author_id>1</wp:author_id><wp:author_login>jane.doe</wp:author_login></wp:author>
+	<wp:author><wp:author_id>2</wp:author_id><wp:author_login>jane doe</wp:author_login></wp:author>
+</channel>
+</rss>`
+
+	usernames := func(dryRun bool) []string {
+		report, err := ImportWXR(db, strings.NewReader(wxr), importer.ID, WXRImportOptions{DryRun: dryRun, CreatePlaceholders: true})
+		if err != nil {
+			t.Fatalf("import (dry run %v): %v", dryRun, err)
+		}
+		names := make([]string, 0, len(report.Authors))
+		for _, author := range report.Authors {
+			names = append(names, author.Username)
+		}
+		return names
+	}
+
+	preview := usernames(true)
+	if strings.Join(preview, ",") != "wp_janedoe,wp_janedoe2" {
+		t.Errorf("dry run usernames = %v, want [wp_janedoe wp_janedoe2]", preview)
+	}
+	if imported := usernames(false); strings.Join(imported, ",") != strings.Join(preview, ",") {
+		t.Errorf("imported usernames = %v, want the dry run preview %v", imported, preview)
+	}
+	var count int64
+	db.Unscoped().Model(&models.Users{}).Where("username IN ?", preview).Count(&count)
+	if count != 2 {
+		t.Errorf("got %d placeholder users, want 2", count)
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建只属于当前测试的内存数据库，并迁移全部模型
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// 内存数据库只在一个连接内有效
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&models.Users{},
+		&models.Blog{},
+		&models.EmployeeRevenue{},
+		&models.RechargeTransaction{},
+		&models.Comment{},
+		&models.Notification{},
+		&models.BlogViewDaily{},
+		&models.BlogReferrerDaily{},
+		&models.BlogLike{},
+		&models.BlogBookmark{},
+		&models.CommentReaction{},
+		&models.UserFollow{},
+		&models.Series{},
+		&models.SeriesItem{},
+		&models.BlogCollaborator{},
+		&models.BlogAuditLog{},
+		&models.BlogReview{},
+		&models.BlogReviewComment{},
+		&models.BlogTemplate{},
+		&models.ExchangeRate{},
+		&models.Target{},
+	); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db
+}
+
+// createTestUser 创建一个测试用户
+func createTestUser(t *testing.T, db *gorm.DB, username string, role int) models.Users {
+	t.Helper()
+	user := models.Users{Username: username, Nickname: username, Email: username + "@example.com", Role: role}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user %s: %v", username, err)
+	}
+	return user
+}
+
+// performRequest 以 userID、role 的身份调用 handler；body 不为空时按 JSON 发送
+func performRequest(handler gin.HandlerFunc, method, target, body string, userID uint, role int, params ...gin.Param) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	if body != "" {
+		ctx.Request.Header.Set("Content-Type", "application/json")
+	}
+	ctx.Params = params
+	ctx.Set("userId", userID)
+	ctx.Set("role", role)
+	handler(ctx)
+	return w
+}
+
+// createTestBlog 创建一篇已发布的测试博客
+func createTestBlog(t *testing.T, db *gorm.DB, authorID uint, title string) models.Blog {
+	t.Helper()
+	blog := models.Blog{UserID: authorID, AuthorID: authorID, Title: title, Content: title, Category: "Go", Status: "published"}
+	if err := db.Create(&blog).Error; err != nil {
+		t.Fatalf("Failed to create blog %s: %v", title, err)
+	}
+	return blog
+}
+
+// createTestComment 创建一条测试评论
+func createTestComment(t *testing.T, db *gorm.DB, blogID, userID uint, content string) models.Comment {
+	t.Helper()
+	comment := models.Comment{BlogID: blogID, UserID: userID, Content: content}
+	if err := db.Create(&comment).Error; err != nil {
+		t.Fatalf("Failed to create comment %s: %v", content, err)
+	}
+	return comment
+}
+
+// createTestRevenue 创建一条测试收益记录，recordDate 为业务日期（revenue.timezone 中午）
+func createTestRevenue(t *testing.T, db *gorm.DB, record models.EmployeeRevenue, recordDate string) models.EmployeeRevenue {
+	t.Helper()
+	day, err := time.ParseInLocation("2006-01-02", recordDate, utils.RevenueLocation())
+	if err != nil {
+		t.Fatalf("Invalid record date %s: %v", recordDate, err)
+	}
+	record.RecordTime, record.RecordDate = day.Add(12*time.Hour), recordDate
+	if record.AdPlatform == "" {
+		record.AdPlatform = "FB"
+	}
+	if record.AdType == "" {
+		record.AdType = "feed"
+	}
+	if record.Region == "" {
+		record.Region = "US"
+	}
+	if record.Currency == "" {
+		record.Currency = utils.RevenueBaseCurrency()
+	}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatalf("Failed to create revenue record: %v", err)
+	}
+	return record
+}
@@ -0,0 +1,85 @@
+package init
+
+import (
+	"blog/config"
+	"blog/controllers"
+	"blog/models"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// commands 命令行子命令，不带子命令时启动 HTTP 服务
+var commands = map[string]func(args []string) error{
+	"import-wxr": importWXRCommand,
+}
+
+// runCommand 执行 os.Args 中的子命令；没有匹配的子命令时返回 false
+func runCommand() bool {
+	if len(os.Args) < 2 {
+		return false
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		return false
+	}
+	if err := command(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+	return true
+}
+
+// importWXRCommand 从命令行导入 WordPress 导出文件，例如：
+// ./blog import-wxr -file export.xml -importer admin -dry-run
+func importWXRCommand(args []string) error {
+	flags := flag.NewFlagSet("import-wxr", flag.ExitOnError)
+	file := flags.String("file", "", "WordPress 导出的 WXR 文件路径")
+	importer := flags.String("importer", "admin", "执行导入的用户名（记录在修改记录中，也是默认作者）")
+	dryRun := flags.Bool("dry-run", false, "只输出导入报告，不写入数据库")
+	dedup := flags.String("dedup", "slug", "去重方式：slug / title / none")
+	authorMap := flags.String("author-map", "", `作者映射 JSON，例如 {"wp_login":"username"}`)
+	defaultAuthor := flags.String("default-author", "", "找不到作者时使用的用户名（默认导入人）")
+	noPlaceholders := flags.Bool("no-placeholders", false, "找不到作者或评论人时不创建占位账号")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		flags.Usage()
+		return fmt.Errorf("-file is required")
+	}
+
+	opts := controllers.WXRImportOptions{
+		DryRun:             *dryRun,
+		Dedup:              *dedup,
+		DefaultAuthor:      *defaultAuthor,
+		CreatePlaceholders: !*noPlaceholders,
+	}
+	if *authorMap != "" {
+		if err := json.Unmarshal([]byte(*authorMap), &opts.AuthorMap); err != nil {
+			return fmt.Errorf("invalid -author-map: %v", err)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	config.Initialize()
+	var user models.Users
+	if err := config.DB.Where("username = ?", *importer).First(&user).Error; err != nil {
+		return fmt.Errorf("importer %q not found", *importer)
+	}
+
+	report, err := controllers.ImportWXR(config.DB, f, user.ID, opts)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
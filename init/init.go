@@ -3,15 +3,47 @@ package init
 import (
 	"blog/api"
 	"blog/utils"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout 收到退出信号后等待进行中的请求完成的最长时间
+const shutdownTimeout = 10 * time.Second
+
 func init() {
 	configPath := filepath.Join("config", "config.yaml")
 	utils.LoadConfig(configPath)
-	r := api.SetupRouter()
-	err := r.Run(":8089")
-	if err != nil {
+	// 命令行子命令（如 import-wxr）执行完直接退出，不启动服务
+	if runCommand() {
 		return
 	}
+	r := api.SetupRouter()
+	server := &http.Server{Addr: ":8089", Handler: r}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+
+	// 收到 SIGINT/SIGTERM 时停止接收新请求，等进行中的请求完成后执行清理（如写入缓冲中的浏览量）
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server stopped: %v", err)
+		}
+	case <-quit:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Failed to shut down server: %v", err)
+		}
+	}
+	api.Shutdown()
 }
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Blog 博客表
 type Blog struct {
 	BaseModel
@@ -9,7 +15,18 @@ type Blog struct {
 	AuthorID uint   `gorm:"not null" json:"author_id"`                      // 作者ID
 	Category string `gorm:"type:varchar(100);not null" json:"category"`     // 文章分类
 	Tags     string `gorm:"type:varchar(255)" json:"tags"`                  // 文章标签（逗号分隔）
-	Status   string `gorm:"type:varchar(50);default:'draft'" json:"status"` // 状态（draft/published）
+	Status   string `gorm:"type:varchar(50);default:'draft'" json:"status"` // 状态（draft/in_review/approved/published）
+	Slug     string `gorm:"type:varchar(255);index" json:"slug"`            // URL 标识（可选，导入导出时用于去重）
+
+	ViewCount     int64 `gorm:"not null;default:0" json:"view_count"`     // 累计浏览量（由浏览量缓冲批量写入）
+	LikeCount     int64 `gorm:"not null;default:0" json:"like_count"`     // 点赞数（与 blog_likes 同事务维护）
+	BookmarkCount int64 `gorm:"not null;default:0" json:"bookmark_count"` // 收藏数（与 blog_bookmarks 同事务维护）
+	CommentCount  int64 `gorm:"not null;default:0" json:"comment_count"`  // 评论数（与 comments 同事务维护）
+
+	LastCommentAt *time.Time `json:"last_comment_at"` // 最近一条评论的时间
+	PublishedAt   *time.Time `json:"published_at"`    // 首次发布的时间（之后撤回再发布不变），只在首次发布时通知关注者
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 删除时间（软删除，进入回收站）
 
 	Users    Users     `gorm:"foreignKey:UserID"`
 	Comments []Comment `gorm:"foreignKey:BlogID"` // 关联评论
@@ -0,0 +1,15 @@
+package models
+
+// BlogAuditLog 博客修改记录（谁在什么时候改了哪些字段）
+type BlogAuditLog struct {
+	BaseModel
+	BlogID  uint   `gorm:"not null;index" json:"blog_id"`           // 博客ID
+	UserID  uint   `gorm:"not null" json:"user_id"`                 // 操作人ID
+	Action  string `gorm:"type:varchar(50);not null" json:"action"` // 操作类型（create/update/delete/collaborator_set/collaborator_remove）
+	Changes string `gorm:"type:text" json:"changes"`                // 变更内容（JSON）
+}
+
+// TableName 指定 BlogAuditLog 表名
+func (BlogAuditLog) TableName() string {
+	return "blog_audit_logs"
+}
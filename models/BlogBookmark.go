@@ -0,0 +1,13 @@
+package models
+
+// BlogBookmark 博客收藏表（每个用户对同一篇博客只能收藏一次）
+type BlogBookmark struct {
+	BaseModel
+	BlogID uint `gorm:"not null;uniqueIndex:idx_blog_bookmark_user" json:"blog_id"` // 博客ID
+	UserID uint `gorm:"not null;uniqueIndex:idx_blog_bookmark_user" json:"user_id"` // 收藏用户ID
+}
+
+// TableName 指定 BlogBookmark 表名
+func (BlogBookmark) TableName() string {
+	return "blog_bookmarks"
+}
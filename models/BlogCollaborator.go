@@ -0,0 +1,21 @@
+package models
+
+// 博客协作者角色
+const (
+	CollaboratorOwner  = "owner"  // 所有者：可编辑、发布、删除并管理协作者
+	CollaboratorEditor = "editor" // 编辑：可修改内容，不能改变状态或删除
+	CollaboratorViewer = "viewer" // 查看者：只读
+)
+
+// BlogCollaborator 博客协作者表（每个用户在一篇博客上只有一个角色）
+type BlogCollaborator struct {
+	BaseModel
+	BlogID uint   `gorm:"not null;uniqueIndex:idx_blog_collaborator" json:"blog_id"`       // 博客ID
+	UserID uint   `gorm:"not null;uniqueIndex:idx_blog_collaborator;index" json:"user_id"` // 协作者ID
+	Role   string `gorm:"type:varchar(20);not null" json:"role"`                           // 角色（owner/editor/viewer）
+}
+
+// TableName 指定 BlogCollaborator 表名
+func (BlogCollaborator) TableName() string {
+	return "blog_collaborators"
+}
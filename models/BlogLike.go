@@ -0,0 +1,13 @@
+package models
+
+// BlogLike 博客点赞表（每个用户对同一篇博客只能点赞一次）
+type BlogLike struct {
+	BaseModel
+	BlogID uint `gorm:"not null;uniqueIndex:idx_blog_like_user" json:"blog_id"` // 博客ID
+	UserID uint `gorm:"not null;uniqueIndex:idx_blog_like_user" json:"user_id"` // 点赞用户ID
+}
+
+// TableName 指定 BlogLike 表名
+func (BlogLike) TableName() string {
+	return "blog_likes"
+}
@@ -0,0 +1,42 @@
+package models
+
+// 博客审核流程中的状态
+const (
+	BlogStatusDraft     = "draft"     // 草稿
+	BlogStatusInReview  = "in_review" // 已提交审核
+	BlogStatusApproved  = "approved"  // 审核通过，待作者发布
+	BlogStatusPublished = "published" // 已发布
+)
+
+// BlogReview 审核记录（提交、通过、退回修改各记一条）
+type BlogReview struct {
+	BaseModel
+	BlogID     uint   `gorm:"not null;index" json:"blog_id"`           // 博客ID
+	UserID     uint   `gorm:"not null" json:"user_id"`                 // 操作人ID（提交者或审核人）
+	Action     string `gorm:"type:varchar(30);not null" json:"action"` // 操作（submit/approve/request_changes）
+	FromStatus string `gorm:"type:varchar(50)" json:"from_status"`     // 操作前状态
+	ToStatus   string `gorm:"type:varchar(50)" json:"to_status"`       // 操作后状态
+	Comment    string `gorm:"type:text" json:"comment"`                // 总体意见
+
+	InlineComments []BlogReviewComment `gorm:"foreignKey:ReviewID" json:"inline_comments"`
+}
+
+// TableName 指定 BlogReview 表名
+func (BlogReview) TableName() string {
+	return "blog_reviews"
+}
+
+// BlogReviewComment 审核时针对正文某一段落的行内意见
+type BlogReviewComment struct {
+	BaseModel
+	ReviewID uint   `gorm:"not null;index" json:"review_id"`    // 所属审核记录
+	BlogID   uint   `gorm:"not null;index" json:"blog_id"`      // 博客ID
+	Quote    string `gorm:"type:text" json:"quote"`             // 引用的原文片段
+	Position int    `gorm:"not null;default:0" json:"position"` // 片段在正文中的字符位置
+	Comment  string `gorm:"type:text;not null" json:"comment"`  // 修改意见
+}
+
+// TableName 指定 BlogReviewComment 表名
+func (BlogReviewComment) TableName() string {
+	return "blog_review_comments"
+}
@@ -0,0 +1,19 @@
+package models
+
+// BlogTemplate 博客模板（管理员维护），标题与正文中可使用 {{name}} 占位符
+type BlogTemplate struct {
+	BaseModel
+	Name        string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"` // 模板名称
+	Description string `gorm:"type:varchar(255)" json:"description"`               // 模板说明
+	Period      string `gorm:"type:varchar(20);default:'daily'" json:"period"`     // 默认统计周期（daily/weekly）
+	Title       string `gorm:"type:varchar(255);not null" json:"title"`            // 标题模板
+	Content     string `gorm:"type:text;not null" json:"content"`                  // 正文模板
+	Category    string `gorm:"type:varchar(100);not null" json:"category"`         // 生成文章的分类
+	Tags        string `gorm:"type:varchar(255)" json:"tags"`                      // 生成文章的标签（逗号分隔）
+	CreatedBy   uint   `gorm:"not null" json:"created_by"`                         // 创建模板的管理员ID
+}
+
+// TableName 指定 BlogTemplate 表名
+func (BlogTemplate) TableName() string {
+	return "blog_templates"
+}
@@ -0,0 +1,28 @@
+package models
+
+// BlogViewDaily 博客每日浏览量（由浏览量缓冲批量写入）
+type BlogViewDaily struct {
+	BaseModel
+	BlogID uint   `gorm:"not null;uniqueIndex:idx_blog_view_daily" json:"blog_id"`               // 博客ID
+	Date   string `gorm:"type:varchar(10);not null;uniqueIndex:idx_blog_view_daily" json:"date"` // 日期（YYYY-MM-DD）
+	Views  int64  `gorm:"not null;default:0" json:"views"`                                       // 去重后的浏览量
+}
+
+// TableName 指定 BlogViewDaily 表名
+func (BlogViewDaily) TableName() string {
+	return "blog_view_daily"
+}
+
+// BlogReferrerDaily 博客每日来源统计
+type BlogReferrerDaily struct {
+	BaseModel
+	BlogID   uint   `gorm:"not null;uniqueIndex:idx_blog_referrer_daily" json:"blog_id"`                    // 博客ID
+	Date     string `gorm:"type:varchar(10);not null;uniqueIndex:idx_blog_referrer_daily" json:"date"`      // 日期（YYYY-MM-DD）
+	Referrer string `gorm:"type:varchar(255);not null;uniqueIndex:idx_blog_referrer_daily" json:"referrer"` // 来源域名（direct 表示直接访问）
+	Views    int64  `gorm:"not null;default:0" json:"views"`                                                // 浏览量
+}
+
+// TableName 指定 BlogReferrerDaily 表名
+func (BlogReferrerDaily) TableName() string {
+	return "blog_referrer_daily"
+}
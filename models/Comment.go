@@ -1,5 +1,7 @@
 package models
 
+import "gorm.io/gorm"
+
 // Comment 留言/评论表
 type Comment struct {
 	BaseModel
@@ -7,6 +9,10 @@ type Comment struct {
 	UserID   uint   `gorm:"not null" json:"user_id"`           // 留言用户ID
 	Content  string `gorm:"type:text;not null" json:"content"` // 留言内容
 	ParentID *uint  `json:"parent_id,omitempty"`               // 父评论ID（可选，用于回复或多级评论）
+
+	ReactionCount int64 `gorm:"not null;default:0" json:"reaction_count"` // 表情回应总数（与 comment_reactions 同事务维护）
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 删除时间（软删除，进入回收站）
 }
 
 // TableName 指定 Comment 表名
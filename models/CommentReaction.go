@@ -0,0 +1,14 @@
+package models
+
+// CommentReaction 评论表情回应表（每个用户对同一条评论的同一个表情只能回应一次）
+type CommentReaction struct {
+	BaseModel
+	CommentID uint   `gorm:"not null;uniqueIndex:idx_comment_reaction_user" json:"comment_id"`             // 评论ID
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_comment_reaction_user" json:"user_id"`                // 回应用户ID
+	Emoji     string `gorm:"type:varchar(16);not null;uniqueIndex:idx_comment_reaction_user" json:"emoji"` // 表情
+}
+
+// TableName 指定 CommentReaction 表名
+func (CommentReaction) TableName() string {
+	return "comment_reactions"
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type EmployeeRevenue struct {
@@ -18,28 +20,108 @@ type EmployeeRevenue struct {
 	AdType string `gorm:"type:varchar(255);not null" json:"ad_type"`
 	// 地区（如 华东地区、北美地区等）
 	Region string `gorm:"type:varchar(255);not null" json:"region"`
-	// 广告支出（单位：美元）
-	Expenditure float64 `gorm:"not null;default:0" json:"expenditure"`
+	// 广告支出（以 Currency 币种的分存储，JSON 中为元）
+	Expenditure Money `gorm:"not null;default:0" json:"expenditure"`
 	// 销售订单数量
 	OrderCount int `gorm:"not null;default:0" json:"order_count"`
 	// 上新品数量（表示每天上了多少个新品）
 	AdCreationCount int `gorm:"not null;default:0" json:"ad_creation_count"`
-	// 销售额（单位：美元）
-	Revenue float64 `gorm:"not null;default:0" json:"revenue"`
+	// 销售额（以 Currency 币种的分存储，JSON 中为元）
+	Revenue Money `gorm:"not null;default:0" json:"revenue"`
 	// ROI 销售额/广告费（自动计算）
 	ROI float64 `gorm:"not null;default:0" json:"roi"`
-	// 记录时间（替代 start_time）
+	// 币种代码（ISO 4217，如 USD、EUR、CNY），本条记录所有金额都使用该币种
+	Currency string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	// 商品成本（以 Currency 币种的分存储，JSON 中为元）
+	ProductCost Money `gorm:"not null;default:0" json:"product_cost"`
+	// 平台手续费（以 Currency 币种的分存储，JSON 中为元）
+	PlatformFee Money `gorm:"not null;default:0" json:"platform_fee"`
+	// 运费（以 Currency 币种的分存储，JSON 中为元）
+	ShippingFee Money `gorm:"not null;default:0" json:"shipping_fee"`
+	// 毛利、毛利率、ROAS、保本 ROI，数据库不存储，查询和保存后自动计算
+	RevenueProfit `gorm:"-"`
+	// 记录时间（替代 start_time），以 UTC 存储
 	RecordTime time.Time `json:"record_time"`
+	// 业务日期（YYYY-MM-DD，按 revenue.timezone 时区），与员工、广告平台、广告类型、地区组成唯一键
+	RecordDate string `gorm:"type:varchar(10);not null;default:''" json:"record_date"`
 	// 前端传入时间格式，数据库不存储该字段
 	RecordTimeStr string `json:"record_time_str" gorm:"-"`
 
 	// 备注（可选）
 	Remark string `gorm:"type:text" json:"remark,omitempty"`
+	// 删除时间（软删除；随员工一起进入回收站）
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	User Users `gorm:"foreignKey:UserID" json:"user"`
 }
 
+// RevenueProfit 由销售额、广告支出与各项成本计算出的利润指标
+type RevenueProfit struct {
+	// 毛利 = 销售额 - 商品成本 - 平台手续费 - 运费 - 广告支出
+	GrossProfit Money `json:"gross_profit"`
+	// 毛利率 = 毛利 / 销售额（没有销售额时为 0）
+	Margin float64 `json:"margin"`
+	// ROAS = 销售额 / 广告支出（没有广告支出时为 0）
+	ROAS float64 `json:"roas"`
+	// 保本 ROI = 销售额 / (销售额 - 商品成本 - 平台手续费 - 运费)，即毛利为 0 时的 ROAS；扣除成本后已无利润时为 0
+	BreakEvenROI float64 `json:"break_even_roi"`
+}
+
+// ComputeRevenueProfit 计算利润指标；金额精确到分，ROAS 与保本 ROI 保留两位小数，毛利率保留四位小数
+func ComputeRevenueProfit(revenue, expenditure, productCost, platformFee, shippingFee Money) RevenueProfit {
+	contribution := revenue - productCost - platformFee - shippingFee
+	p := RevenueProfit{
+		GrossProfit: contribution - expenditure,
+		Margin:      Ratio(contribution-expenditure, revenue, 4),
+		ROAS:        Ratio(revenue, expenditure, 2),
+	}
+	if contribution > 0 {
+		p.BreakEvenROI = Ratio(revenue, contribution, 2)
+	}
+	return p
+}
+
+// BeforeSave 记录时间统一以 UTC 保存：SQLite 按文本比较时间，时区偏移不同的值无法正确比较大小
+func (r *EmployeeRevenue) BeforeSave(tx *gorm.DB) error {
+	r.RecordTime = r.RecordTime.UTC()
+	return nil
+}
+
+// AfterFind 查询后计算利润指标
+func (r *EmployeeRevenue) AfterFind(tx *gorm.DB) error {
+	r.RevenueProfit = ComputeRevenueProfit(r.Revenue, r.Expenditure, r.ProductCost, r.PlatformFee, r.ShippingFee)
+	return nil
+}
+
+// AfterSave 保存后计算利润指标，创建和更新接口直接返回记录时也带上这些字段
+func (r *EmployeeRevenue) AfterSave(tx *gorm.DB) error {
+	return r.AfterFind(tx)
+}
+
 // TableName 指定 EmployeeRevenue 表名
 func (EmployeeRevenue) TableName() string {
 	return "employee_revenue"
 }
+
+// RevenueNaturalKeyIndex 收益记录唯一键（员工 + 业务日期 + 广告平台 + 广告类型 + 地区）的索引名，回收站中的记录不参与
+const RevenueNaturalKeyIndex = "idx_employee_revenue_natural_key"
+
+// EnsureRevenueNaturalKey 创建收益记录的唯一索引。历史数据中仍有重复记录时不创建并返回 false，
+// 需要管理员通过重复记录报表合并或删除后再次调用
+func EnsureRevenueNaturalKey(db *gorm.DB) (bool, error) {
+	if db.Migrator().HasIndex(&EmployeeRevenue{}, RevenueNaturalKeyIndex) {
+		return true, nil
+	}
+	var duplicates int64
+	if err := db.Raw(`SELECT COUNT(*) FROM (SELECT 1 FROM employee_revenue WHERE deleted_at IS NULL
+		GROUP BY user_id, record_date, ad_platform, ad_type, region HAVING COUNT(*) > 1) AS duplicates`).
+		Scan(&duplicates).Error; err != nil {
+		return false, err
+	}
+	if duplicates > 0 {
+		return false, nil
+	}
+	err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS " + RevenueNaturalKeyIndex +
+		" ON employee_revenue (user_id, record_date, ad_platform, ad_type, region) WHERE deleted_at IS NULL").Error
+	return err == nil, err
+}
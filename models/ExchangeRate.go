@@ -0,0 +1,36 @@
+package models
+
+import "strings"
+
+// ExchangeRate 汇率（管理员维护），每个币种每天一条
+type ExchangeRate struct {
+	BaseModel
+
+	// 日期（YYYY-MM-DD）：该日及之后没有更新汇率的日期都使用这一条
+	Date string `gorm:"type:varchar(10);not null;uniqueIndex:idx_exchange_rate_date_currency" json:"date"`
+	// 币种代码（ISO 4217，如 EUR、CNY），不能是基础币种
+	Currency string `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_date_currency" json:"currency"`
+	// 1 单位该币种折合多少基础币种（revenue.currency，默认 USD）
+	Rate float64 `gorm:"not null" json:"rate"`
+	// 备注（如数据来源）
+	Remark string `gorm:"type:varchar(255)" json:"remark,omitempty"`
+}
+
+// TableName 指定 ExchangeRate 表名
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// NormalizeCurrency 规范化币种代码（去空格、转大写），不是三位字母时返回 false
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return code, false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return code, false
+		}
+	}
+	return code, true
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 金额，以最小货币单位（分）存储为整数，累加不会产生浮点误差；
+// JSON 中仍是保留两位小数的数字（如 12.5 元输出为 12.50），前端无需改动
+type Money int64
+
+// MoneyScale 每个货币单位包含的最小单位数
+const MoneyScale = 100
+
+var errInvalidMoney = errors.New("invalid money amount")
+
+// ParseMoney 按十进制精确解析金额字符串，超过两位的小数四舍五入（远离 0）
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, errInvalidMoney
+	}
+
+	var cents int64
+	for _, d := range intPart {
+		if cents > (math.MaxInt64-9)/10/MoneyScale {
+			return 0, errInvalidMoney
+		}
+		cents = cents*10 + int64(d-'0')
+	}
+	cents *= MoneyScale
+	fracPart += "000"
+	cents += int64(fracPart[0]-'0')*10 + int64(fracPart[1]-'0')
+	if fracPart[2] >= '5' {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromFloat 把浮点金额四舍五入到分（只用于无法按字符串解析的来源，如 Excel 科学计数法）
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * MoneyScale))
+}
+
+// Float 转换为浮点数，只用于计算比率或写入导出文件
+func (m Money) Float() float64 {
+	return float64(m) / MoneyScale
+}
+
+// String 两位小数的十进制表示，如 -12.05
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return sign + strconv.FormatInt(cents/MoneyScale, 10) + "." + strconv.FormatInt(cents%MoneyScale+MoneyScale, 10)[1:]
+}
+
+// MarshalJSON 输出为两位小数的数字
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 接受数字或字符串（"12.50"），null 视为 0
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if string(data) == "null" || len(data) == 0 {
+		*m = 0
+		return nil
+	}
+	parsed, err := ParseMoney(string(data))
+	if err != nil {
+		// 兼容 1e3 这类科学计数法写法
+		f, ferr := strconv.ParseFloat(string(data), 64)
+		if ferr != nil {
+			return err
+		}
+		parsed = MoneyFromFloat(f)
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan 读取数据库中的金额；SUM 没有记录时返回的 NULL 视为 0
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case float64:
+		*m = Money(math.Round(v))
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*m = Money(math.Round(f))
+	return nil
+}
+
+// Ratio 两个金额的比值（如 ROI、毛利率），保留 digits 位小数；分母为 0 时返回 0
+func Ratio(numerator, denominator Money, digits int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	scale := math.Pow10(digits)
+	return math.Round(float64(numerator)/float64(denominator)*scale) / scale
+}
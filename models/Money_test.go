@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for input, want := range map[string]Money{
+		"0":       0,
+		"12":      1200,
+		"12.5":    1250,
+		"12.345":  1235,
+		"12.344":  1234,
+		"-0.005":  -1,
+		".99":     99,
+		"+3.10":   310,
+		"1000.01": 100001,
+	} {
+		got, err := ParseMoney(input)
+		if err != nil || got != want {
+			t.Errorf("ParseMoney(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", ".", "abc", "1.2.3", "1e3", "--1"} {
+		if _, err := ParseMoney(input); err == nil {
+			t.Errorf("ParseMoney(%q) expected error", input)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Amount Money `json:"amount"`
+		Fee    Money `json:"fee"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":0.1,"fee":"-7.05"}`), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if v.Amount != 10 || v.Fee != -705 {
+		t.Errorf("got amount=%d fee=%d", v.Amount, v.Fee)
+	}
+
+	// 0.1 + 0.2 用浮点数累加会得到 0.30000000000000004
+	v.Amount += 20
+	out, _ := json.Marshal(v)
+	if string(out) != `{"amount":0.30,"fee":-7.05}` {
+		t.Errorf("Marshal = %s", out)
+	}
+}
@@ -8,7 +8,8 @@ type RechargeTransaction struct {
 	BaseModel
 	UserID          uint      `gorm:"not null" json:"user_id"`
 	OrderNumber     string    `gorm:"type:varchar(100);unique;not null" json:"order_number"`
-	Amount          float64   `gorm:"not null;default:0" json:"amount"`
+	Amount          Money     `gorm:"not null;default:0" json:"amount"`                       // 单位：分，JSON 中为元
+	Currency        string    `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"` // 币种代码（ISO 4217）
 	PaymentMethod   string    `gorm:"type:varchar(50);not null" json:"payment_method"`
 	Status          string    `gorm:"type:varchar(50);not null" json:"status"`
 	TransactionTime time.Time `gorm:"not null" json:"transaction_time"`
@@ -0,0 +1,29 @@
+package models
+
+// Series 系列文章（按顺序组织的一组博客，例如多篇连载的投放手册）
+type Series struct {
+	BaseModel
+	UserID      uint   `gorm:"not null;index" json:"user_id"`           // 创建者ID
+	Title       string `gorm:"type:varchar(255);not null" json:"title"` // 系列标题
+	Description string `gorm:"type:text" json:"description"`            // 系列简介
+
+	Items []SeriesItem `gorm:"foreignKey:SeriesID" json:"-"`
+}
+
+// TableName 指定 Series 表名
+func (Series) TableName() string {
+	return "series"
+}
+
+// SeriesItem 系列中的一篇博客及其顺序（一篇博客最多属于一个系列）
+type SeriesItem struct {
+	BaseModel
+	SeriesID uint `gorm:"not null;index" json:"series_id"`     // 所属系列ID
+	BlogID   uint `gorm:"not null;uniqueIndex" json:"blog_id"` // 博客ID
+	Position int  `gorm:"not null;default:0" json:"position"`  // 在系列中的顺序（从 1 开始）
+}
+
+// TableName 指定 SeriesItem 表名
+func (SeriesItem) TableName() string {
+	return "series_items"
+}
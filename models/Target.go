@@ -0,0 +1,25 @@
+package models
+
+// Target 员工（投手）的月度目标（管理员维护），每个员工每月一条；某项目标为 0 表示不考核该项
+type Target struct {
+	BaseModel
+
+	UserID uint `gorm:"not null;uniqueIndex:idx_target_user_period" json:"user_id"`
+	// 考核月份（YYYY-MM），按 revenue.timezone 的业务日期统计
+	Period string `gorm:"type:varchar(7);not null;uniqueIndex:idx_target_user_period" json:"period"`
+	// 目标金额的币种，实际数据按记录当天汇率换算为该币种后比较
+	Currency string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	// 广告支出预算（分，JSON 中为元）
+	Expenditure Money `gorm:"not null;default:0" json:"expenditure"`
+	// 销售额目标（分，JSON 中为元）
+	Revenue Money `gorm:"not null;default:0" json:"revenue"`
+	// ROI 目标（销售额 / 广告支出）
+	ROI float64 `gorm:"not null;default:0" json:"roi"`
+	// 备注
+	Remark string `gorm:"type:varchar(255)" json:"remark,omitempty"`
+}
+
+// TableName 指定 Target 表名
+func (Target) TableName() string {
+	return "targets"
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Users struct {
@@ -16,6 +18,8 @@ type Users struct {
 	Website     string     `gorm:"type:varchar(255)" json:"website,omitempty"`
 	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 	Status      int        `gorm:"not null;default:1" json:"status"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 删除时间（软删除，进入回收站）
 }
 
 // TableName sets the insert table name for this struct type
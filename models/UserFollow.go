@@ -0,0 +1,13 @@
+package models
+
+// UserFollow 用户关注表（FollowerID 关注了 FolloweeID）
+type UserFollow struct {
+	BaseModel
+	FollowerID uint `gorm:"not null;uniqueIndex:idx_user_follow" json:"follower_id"`       // 关注者ID
+	FolloweeID uint `gorm:"not null;uniqueIndex:idx_user_follow;index" json:"followee_id"` // 被关注的作者ID
+}
+
+// TableName 指定 UserFollow 表名
+func (UserFollow) TableName() string {
+	return "user_follows"
+}
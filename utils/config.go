@@ -34,6 +34,33 @@ type Config struct {
 	FilePaths struct {
 		HTMLIndex string `yaml:"html_index"`
 	} `yaml:"file_paths"`
+
+	Site struct {
+		BaseURL         string `yaml:"base_url"`          // 站点对外访问地址，例如 https://blog.example.com
+		SitemapPageSize int    `yaml:"sitemap_page_size"` // 单个 sitemap 文件最多包含的 URL 数量
+	} `yaml:"site"`
+
+	Analytics struct {
+		ViewDedupMinutes     int `yaml:"view_dedup_minutes"`     // 同一用户/IP 在该时间窗口内重复访问只计一次
+		FlushIntervalSeconds int `yaml:"flush_interval_seconds"` // 浏览量缓冲写库的间隔
+		FlushBatchSize       int `yaml:"flush_batch_size"`       // 缓冲的浏览量达到该数量时提前写库
+	} `yaml:"analytics"`
+
+	Review struct {
+		Categories []string `yaml:"categories"` // 需要经过审核才能发布的文章分类，"*" 表示全部分类
+	} `yaml:"review"`
+
+	Trash struct {
+		RetentionDays int    `yaml:"retention_days"` // 博客、评论、用户删除后在回收站保留的天数
+		PurgeSchedule string `yaml:"purge_schedule"` // 彻底删除过期数据的 cron 表达式
+	} `yaml:"trash"`
+
+	Revenue struct {
+		Timezone string `yaml:"timezone"` // 收益记录的业务日期所在时区（IANA 名称）
+		Currency string `yaml:"currency"` // 基础币种（ISO 4217），汇率以它为基准，也是报表的默认币种
+		// ROI 排行榜的最低广告支出（基础币种），支出低于它的员工不参与 ROI 排名
+		LeaderboardMinSpend float64 `yaml:"leaderboard_min_spend"`
+	} `yaml:"revenue"`
 }
 
 var AppConfig Config
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 10, 16, 8, 0, 123456789, time.UTC)
+
+	gotTime, gotID, err := DecodeCursor(EncodeCursor(createdAt, 42))
+	if err != nil {
+		t.Fatalf("DecodeCursor failed: %v", err)
+	}
+	if !gotTime.Equal(createdAt) || gotID != 42 {
+		t.Errorf("got (%v, %d), want (%v, 42)", gotTime, gotID, createdAt)
+	}
+
+	for _, bad := range []string{"", "!!!", EncodeCursor(createdAt, 0)} {
+		if _, _, err := DecodeCursor(bad); err == nil {
+			t.Errorf("DecodeCursor(%q) expected error", bad)
+		}
+	}
+}
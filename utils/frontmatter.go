@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoFrontMatter 文本不是以 --- 开头的 front matter 格式
+var ErrNoFrontMatter = errors.New("missing front matter")
+
+// MarshalFrontMatter 生成 "---\n<yaml>---\n\n<body>" 格式的 Markdown 文本
+func MarshalFrontMatter(meta interface{}, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(body)
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseFrontMatter 解析 Markdown 文本开头的 YAML front matter 到 meta，返回去掉 front matter 后的正文
+func ParseFrontMatter(data []byte, meta interface{}) (string, error) {
+	text := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return "", ErrNoFrontMatter
+	}
+	rest := text[len("---\n"):]
+
+	var header, body string
+	if strings.HasPrefix(rest, "---\n") {
+		body = rest[len("---\n"):]
+	} else if end := strings.Index(rest, "\n---\n"); end >= 0 {
+		header, body = rest[:end+1], rest[end+len("\n---\n"):]
+	} else if strings.HasSuffix(rest, "\n---") {
+		header = rest[:len(rest)-len("---")]
+	} else {
+		return "", ErrNoFrontMatter
+	}
+
+	if err := yaml.Unmarshal([]byte(header), meta); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(body, "\n"), nil
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify 将标题转换为只含小写字母、数字和连字符的 slug；没有可用字符时返回空字符串
+func Slugify(title string) string {
+	slug := slugInvalid.ReplaceAllString(strings.ToLower(title), "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > 80 {
+		slug = strings.TrimRight(slug[:80], "-")
+	}
+	return slug
+}
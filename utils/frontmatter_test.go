@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFrontMatterRoundTrip(t *testing.T) {
+	type meta struct {
+		Title string   `yaml:"title"`
+		Tags  []string `yaml:"tags"`
+	}
+	in := meta{Title: "Hello: 世界", Tags: []string{"go", "seo"}}
+
+	data, err := MarshalFrontMatter(in, "# 正文\n\n---\n分隔线之后")
+	if err != nil {
+		t.Fatalf("MarshalFrontMatter failed: %v", err)
+	}
+
+	var out meta
+	body, err := ParseFrontMatter(data, &out)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter failed: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("meta = %+v, want %+v", out, in)
+	}
+	if body != "# 正文\n\n---\n分隔线之后\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	if _, err := ParseFrontMatter([]byte("no front matter"), &out); err != ErrNoFrontMatter {
+		t.Errorf("expected ErrNoFrontMatter, got %v", err)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello, World!":    "hello-world",
+		"  Go 1.22 发布说明  ": "go-1-22",
+		"全中文标题":            "",
+	}
+	for title, want := range cases {
+		if got := Slugify(title); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
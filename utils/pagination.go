@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MaxPageLimit 列表接口单页最多返回的条数
+const MaxPageLimit = 50
+
+// Pagination 列表分页参数：支持页码分页，也支持基于 (created_at, id) 的游标分页
+type Pagination struct {
+	Page  int
+	Limit int
+
+	// 传入 cursor 时使用游标分页，忽略 Page
+	CursorTime time.Time
+	CursorID   uint
+	HasCursor  bool
+}
+
+// ParsePagination 解析 page / limit / cursor 参数，limit 超出上限时截断，cursor 非法时返回错误
+func ParsePagination(ctx *gin.Context, defaultLimit int) (Pagination, error) {
+	p := Pagination{Page: 1, Limit: defaultLimit}
+
+	if page, err := strconv.Atoi(ctx.DefaultQuery("page", "1")); err == nil && page > 0 {
+		p.Page = page
+	}
+	if limit, err := strconv.Atoi(ctx.Query("limit")); err == nil && limit > 0 {
+		p.Limit = limit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := DecodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		p.CursorTime, p.CursorID, p.HasCursor = createdAt, id, true
+	}
+	return p, nil
+}
+
+// Apply 为查询加上排序（created_at DESC, id DESC）与分页条件；多取一条用于判断是否还有下一页
+func (p Pagination) Apply(db *gorm.DB, createdAtColumn, idColumn string) *gorm.DB {
+	return p.apply(db, createdAtColumn, idColumn, true)
+}
+
+// ApplyAscending 与 Apply 相同，但按 (created_at, id) 升序
+func (p Pagination) ApplyAscending(db *gorm.DB, createdAtColumn, idColumn string) *gorm.DB {
+	return p.apply(db, createdAtColumn, idColumn, false)
+}
+
+// ApplyOffset 仅加上页码分页（排序由调用方决定，不支持游标）；同样多取一条
+func (p Pagination) ApplyOffset(db *gorm.DB) *gorm.DB {
+	return db.Limit(p.Limit + 1).Offset((p.Page - 1) * p.Limit)
+}
+
+func (p Pagination) apply(db *gorm.DB, createdAtColumn, idColumn string, desc bool) *gorm.DB {
+	direction, op := " DESC", "<"
+	if !desc {
+		direction, op = " ASC", ">"
+	}
+
+	db = db.Order(createdAtColumn + direction).Order(idColumn + direction).Limit(p.Limit + 1)
+	if p.HasCursor {
+		return db.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", createdAtColumn, op, createdAtColumn, idColumn, op),
+			p.CursorTime, p.CursorTime, p.CursorID,
+		)
+	}
+	return db.Offset((p.Page - 1) * p.Limit)
+}
+
+// Meta 生成统一的分页元数据：total / page / limit / totalPages / next_cursor
+func (p Pagination) Meta(total int64, nextCursor string) gin.H {
+	meta := gin.H{
+		"total":       total,
+		"limit":       p.Limit,
+		"totalPages":  (total + int64(p.Limit) - 1) / int64(p.Limit),
+		"next_cursor": nextCursor,
+	}
+	if !p.HasCursor {
+		meta["page"] = p.Page
+	}
+	return meta
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+)
+
+// placeholderPattern 匹配模板中的 {{name}} 占位符，名称只允许字母、数字和下划线
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// RenderPlaceholders 用 vars 替换文本中的占位符；没有对应变量的占位符保持原样，并按名称排序返回
+func RenderPlaceholders(text string, vars map[string]string) (string, []string) {
+	missing := make(map[string]bool)
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		missing[name] = true
+		return match
+	})
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return rendered, names
+}
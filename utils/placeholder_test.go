@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderPlaceholders(t *testing.T) {
+	text := "{{ author }} 的日报 {{start_date}}~{{end_date}}\n{{revenue_table}}{{unknown}} {{ unknown }}"
+	got, missing := RenderPlaceholders(text, map[string]string{
+		"author":        "李骏",
+		"start_date":    "2025-03-10",
+		"end_date":      "2025-03-10",
+		"revenue_table": "",
+	})
+
+	want := "李骏 的日报 2025-03-10~2025-03-10\n{{unknown}} {{ unknown }}"
+	if got != want {
+		t.Errorf("rendered = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"unknown"}) {
+		t.Errorf("missing = %v, want [unknown]", missing)
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	revenueLocationMu   sync.Mutex
+	revenueLocationName string
+	revenueLocation     *time.Location
+)
+
+// RevenueLocation 收益记录业务日期所在的时区（由 revenue.timezone 配置，默认 UTC）；
+// 按配置值缓存，配置变化后重新加载
+func RevenueLocation() *time.Location {
+	revenueLocationMu.Lock()
+	defer revenueLocationMu.Unlock()
+	name := AppConfig.Revenue.Timezone
+	if revenueLocation != nil && name == revenueLocationName {
+		return revenueLocation
+	}
+	revenueLocationName, revenueLocation = name, time.UTC
+	if name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Invalid revenue timezone %q, using UTC: %v", name, err)
+			return revenueLocation
+		}
+		revenueLocation = loc
+	}
+	return revenueLocation
+}
+
+// RevenueBaseCurrency 基础币种（由 revenue.currency 配置，默认 USD）
+func RevenueBaseCurrency() string {
+	if code := strings.ToUpper(strings.TrimSpace(AppConfig.Revenue.Currency)); len(code) == 3 {
+		return code
+	}
+	return "USD"
+}
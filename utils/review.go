@@ -0,0 +1,19 @@
+package utils
+
+import "strings"
+
+// ReviewRequired 判断该分类的文章是否需要经过审核流程才能发布（由 review.categories 配置）
+func ReviewRequired(category string) bool {
+	category = strings.TrimSpace(category)
+	for _, c := range AppConfig.Review.Categories {
+		if c == "*" || strings.EqualFold(strings.TrimSpace(c), category) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReviewer 管理员与财务可以审核文章
+func IsReviewer(role int) bool {
+	return role <= RoleFinance
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapMaxURLs sitemap 协议规定单个文件最多 50000 条 URL
+const SitemapMaxURLs = 50000
+
+// SitemapURL sitemap 中的单条 URL
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapURLSet <urlset> 根节点
+type SitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+// SitemapEntry sitemap 索引中的单个子 sitemap
+type SitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapIndex <sitemapindex> 根节点
+type SitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	XMLNS    string         `xml:"xmlns,attr"`
+	Sitemaps []SitemapEntry `xml:"sitemap"`
+}
+
+// NewSitemapURLSet 创建带命名空间的 urlset
+func NewSitemapURLSet(urls []SitemapURL) SitemapURLSet {
+	return SitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls}
+}
+
+// NewSitemapIndex 创建带命名空间的 sitemapindex
+func NewSitemapIndex(entries []SitemapEntry) SitemapIndex {
+	return SitemapIndex{XMLNS: sitemapXMLNS, Sitemaps: entries}
+}
+
+// SitemapLastMod 将时间格式化为 W3C Datetime（sitemap 要求的格式）
+func SitemapLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SiteURL 基于配置中的站点地址拼接绝对 URL
+func SiteURL(path string) string {
+	base := strings.TrimRight(AppConfig.Site.BaseURL, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return base + path
+}
+
+// SitemapPageSize 返回单个 sitemap 中文章 URL 的上限（未配置或超出协议上限时回退）
+// 预留一条给首页，保证第一页也不会超过协议上限
+func SitemapPageSize() int {
+	size := AppConfig.Site.SitemapPageSize
+	if size <= 0 || size >= SitemapMaxURLs {
+		return SitemapMaxURLs - 1
+	}
+	return size
+}
+
+// MarshalSitemap 序列化 urlset / sitemapindex，并加上 XML 声明
+func MarshalSitemap(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
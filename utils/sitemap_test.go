@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalSitemap(t *testing.T) {
+	AppConfig.Site.BaseURL = "https://blog.example.com/"
+	defer func() { AppConfig.Site.BaseURL = "" }()
+
+	urls := []SitemapURL{
+		{Loc: SiteURL("blog/1"), LastMod: SitemapLastMod(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC))},
+	}
+	body, err := MarshalSitemap(NewSitemapURLSet(urls))
+	if err != nil {
+		t.Fatalf("MarshalSitemap failed: %v", err)
+	}
+
+	out := string(body)
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		`<loc>https://blog.example.com/blog/1</loc>`,
+		`<lastmod>2025-03-01T08:00:00Z</lastmod>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("sitemap missing %q:\n%s", want, out)
+		}
+	}
+}
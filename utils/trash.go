@@ -0,0 +1,20 @@
+package utils
+
+import "time"
+
+// TrashRetention 回收站保留时长（由 trash.retention_days 配置，默认 30 天），超过后由清理任务彻底删除
+func TrashRetention() time.Duration {
+	days := AppConfig.Trash.RetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// TrashPurgeSchedule 清理任务的 cron 表达式（由 trash.purge_schedule 配置，默认每天凌晨 3 点）
+func TrashPurgeSchedule() string {
+	if AppConfig.Trash.PurgeSchedule == "" {
+		return "0 3 * * *"
+	}
+	return AppConfig.Trash.PurgeSchedule
+}
@@ -0,0 +1,407 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// XLSXCell 单元格：Value 为字符串或数字，nil 为空单元格；Currency 使用 #,##0.00 金额格式；Bold 加粗（表头、合计行）
+type XLSXCell struct {
+	Value    interface{}
+	Currency bool
+	Bold     bool
+}
+
+// XLSXWriter 流式写出只有一个工作表的 xlsx 文件：逐行写入 zip，不在内存中保留整个表格
+type XLSXWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+// xlsx 的固定部件；styles.xml 中 cellXfs 的下标：0 默认、1 金额、2 加粗、3 加粗金额
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="#,##0.00"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="4">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="164" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+		`</cellXfs>` +
+		`</styleSheet>`},
+}
+
+// NewXLSXWriter 写出固定部件并开始工作表，之后用 WriteRow 逐行写入，最后必须调用 Close
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	parts := append(xlsxStaticParts, struct{ name, body string }{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`})
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetFormatPr defaultRowHeight="15" baseColWidth="16"/><sheetData>`); err != nil {
+		return nil, err
+	}
+	return &XLSXWriter{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow 写入一行
+func (w *XLSXWriter) WriteRow(cells ...XLSXCell) error {
+	w.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.row)
+	for i, cell := range cells {
+		if cell.Value == nil {
+			continue // 空单元格
+		}
+		style := 0
+		if cell.Currency {
+			style++
+		}
+		if cell.Bold {
+			style += 2
+		}
+		ref := xlsxColumnName(i) + strconv.Itoa(w.row)
+		if number, ok := xlsxNumber(cell.Value); ok {
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, number)
+			continue
+		}
+		fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		// EscapeText 会把 XML 不允许的控制字符替换为 U+FFFD
+		if err := xml.EscapeText(&b, []byte(fmt.Sprint(cell.Value))); err != nil {
+			return err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(w.sheet, b.String())
+	return err
+}
+
+// Close 结束工作表并写出 zip 目录
+func (w *XLSXWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// xlsxColumnName 列下标（从 0 开始）转换为 A、B、…、Z、AA…
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxNumber 数字类型的单元格值
+func xlsxNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	}
+	return "", false
+}
+
+// XLSXRow 读取到的一行：Line 为工作表中的行号（从 1 开始），Cells 按列对齐，空单元格为 ""
+type XLSXRow struct {
+	Line  int
+	Cells []string
+}
+
+// xlsxMaxColumns 工作表的最大列数（最后一列为 XFD）
+const xlsxMaxColumns = 16384
+
+// ReadXLSXRows 读取 xlsx 文件第一个工作表的行，maxRows > 0 时读到 maxRows 行即停止。
+// 数字按原样返回（日期为 Excel 序列号），公式取缓存的结果
+func ReadXLSXRows(data []byte, maxRows int) ([]XLSXRow, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("invalid xlsx file")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	sheetPath, err := xlsxFirstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var sharedStrings []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if sharedStrings, err = readXLSXSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("invalid xlsx file: worksheet not found")
+	}
+	return readXLSXSheet(sheet, sharedStrings, maxRows)
+}
+
+// xlsxFirstSheetPath 通过 workbook.xml 与其关系文件找到第一个工作表在压缩包中的路径
+func xlsxFirstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("invalid xlsx file: no worksheet")
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", errors.New("invalid xlsx file: worksheet not found")
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid xlsx file: %s not found", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("invalid xlsx file: %s: %v", name, err)
+	}
+	return nil
+}
+
+// readXLSXSharedStrings 读取共享字符串表，富文本的各段拼接为一个字符串（忽略注音）
+func readXLSXSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var result []string
+	var current strings.Builder
+	inText, inPhonetic := false, false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: sharedStrings.xml: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				inText = !inPhonetic
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				result = append(result, current.String())
+			case "rPh":
+				inPhonetic = false
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+// readXLSXSheet 逐个读取工作表中的单元格，按单元格引用中的列号放入对应位置
+func readXLSXSheet(f *zip.File, sharedStrings []string, maxRows int) ([]XLSXRow, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var rows []XLSXRow
+	var row *XLSXRow
+	var cellType, cellRef string
+	var value strings.Builder
+	inValue := false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: worksheet: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				if maxRows > 0 && len(rows) >= maxRows {
+					return rows, nil
+				}
+				line := len(rows) + 1
+				if len(rows) > 0 {
+					line = rows[len(rows)-1].Line + 1
+				}
+				if r := xlsxAttr(t, "r"); r != "" {
+					if n, err := strconv.Atoi(r); err == nil {
+						line = n
+					}
+				}
+				rows = append(rows, XLSXRow{Line: line})
+				row = &rows[len(rows)-1]
+			case "c":
+				cellType, cellRef = xlsxAttr(t, "t"), xlsxAttr(t, "r")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				if row == nil {
+					continue
+				}
+				col := len(row.Cells)
+				if cellRef != "" {
+					col = xlsxColumnIndex(cellRef)
+				}
+				if col < 0 || col >= xlsxMaxColumns {
+					return nil, fmt.Errorf("invalid xlsx file: bad cell reference %q", cellRef)
+				}
+				text := value.String()
+				switch cellType {
+				case "s":
+					index, err := strconv.Atoi(text)
+					if err != nil || index < 0 || index >= len(sharedStrings) {
+						return nil, fmt.Errorf("invalid xlsx file: bad shared string index in %s", cellRef)
+					}
+					text = sharedStrings[index]
+				case "b":
+					if text == "1" {
+						text = "TRUE"
+					} else {
+						text = "FALSE"
+					}
+				}
+				for len(row.Cells) <= col {
+					row.Cells = append(row.Cells, "")
+				}
+				row.Cells[col] = text
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+func xlsxAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xlsxColumnIndex 从单元格引用（如 AB12）中取出列下标（从 0 开始）；没有列字母时返回 -1，超过 XFD 时返回 xlsxMaxColumns
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > xlsxMaxColumns {
+			return xlsxMaxColumns
+		}
+	}
+	return index - 1
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf, "收益 & 明细")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter failed: %v", err)
+	}
+	rows := [][]XLSXCell{
+		{{Value: "平台", Bold: true}, {Value: "销售额", Bold: true}},
+		{{Value: "Google <Ads>\x01"}, {Value: 1234.5, Currency: true}},
+		{{Value: "合计", Bold: true}, {}, {Value: 1234.5, Currency: true, Bold: true}},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		// 每个部件都必须是合法的 XML
+		dec := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", f.Name, err)
+			}
+		}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = string(body)
+		}
+	}
+
+	for _, want := range []string{
+		`<c r="A1" s="2" t="inlineStr"><is><t xml:space="preserve">平台</t></is></c>`,
+		`<c r="B2" s="1"><v>1234.5</v></c>`,
+		`Google &lt;Ads&gt;`,
+		`<c r="C3" s="3"><v>1234.5</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %q", want)
+		}
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumnName(index); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestReadXLSXRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf, "Sheet1")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter failed: %v", err)
+	}
+	w.WriteRow(XLSXCell{Value: "日期"}, XLSXCell{Value: "花费"}, XLSXCell{Value: "备注"})
+	w.WriteRow(XLSXCell{Value: "2025-03-10"}, XLSXCell{}, XLSXCell{Value: "a & b"})
+	w.WriteRow(XLSXCell{Value: 45726}, XLSXCell{Value: 12.5, Currency: true})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	rows, err := ReadXLSXRows(buf.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("ReadXLSXRows failed: %v", err)
+	}
+	want := []XLSXRow{
+		{Line: 1, Cells: []string{"日期", "花费", "备注"}},
+		{Line: 2, Cells: []string{"2025-03-10", "", "a & b"}},
+		{Line: 3, Cells: []string{"45726", "12.5"}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %#v, want %#v", rows, want)
+	}
+
+	if _, err := ReadXLSXRows([]byte("not a zip"), 0); err == nil {
+		t.Error("ReadXLSXRows expected error for invalid data")
+	}
+}
+
+// testXLSX 生成只有一个工作表的 xlsx，sheetData 为工作表 <sheetData> 的内容
+func testXLSX(t *testing.T, sheetData string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Create %s: %v", name, err)
+		}
+		io.WriteString(f, body)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// 列号无效或超过 XFD 的单元格引用视为无效文件；maxRows 限制读取的行数
+func TestReadXLSXRowsLimits(t *testing.T) {
+	for _, ref := range []string{"1", "ZZZZZZ1", "XFE1"} {
+		data := testXLSX(t, `<row r="1"><c r="`+ref+`"><v>1</v></c></row>`)
+		if _, err := ReadXLSXRows(data, 0); err == nil || !strings.HasPrefix(err.Error(), "invalid xlsx file") {
+			t.Errorf("cell %s: got error %v, want invalid xlsx file", ref, err)
+		}
+	}
+	rows, err := ReadXLSXRows(testXLSX(t, `<row r="1"><c r="XFD1"><v>1</v></c></row>`), 0)
+	if err != nil || len(rows) != 1 || len(rows[0].Cells) != 16384 || rows[0].Cells[16383] != "1" {
+		t.Errorf("cell XFD1: got %d rows, error %v; want the last column", len(rows), err)
+	}
+
+	var sheet strings.Builder
+	for i := 1; i <= 5; i++ {
+		sheet.WriteString(`<row><c><v>` + string(rune('0'+i)) + `</v></c></row>`)
+	}
+	rows, err = ReadXLSXRows(testXLSX(t, sheet.String()), 3)
+	want := []XLSXRow{{Line: 1, Cells: []string{"1"}}, {Line: 2, Cells: []string{"2"}}, {Line: 3, Cells: []string{"3"}}}
+	if err != nil || !reflect.DeepEqual(rows, want) {
+		t.Errorf("maxRows 3: rows = %v, error %v; want %v", rows, err, want)
+	}
+}